@@ -10,6 +10,7 @@ require (
 	github.com/openkcm/plugin-sdk v0.12.0
 	github.com/samber/oops v1.22.0
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/time v0.15.0
 	google.golang.org/grpc v1.81.1
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,120 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	idmangv1 "github.com/openkcm/plugin-sdk/proto/plugin/identity_management/v1"
+
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+var ErrGetGroupsForUsers = errors.New("failed to get groups for users")
+
+// GetGroupsForUsers returns the groups of each of the users by user ID,
+// looking them up as GetGroupsForUser does with at most BatchConcurrency
+// lookups in flight. It fails on the first failing lookup.
+func (p *Plugin) GetGroupsForUsers(
+	ctx context.Context,
+	userIDs []string,
+	authContext *idmangv1.AuthContext,
+) (map[string][]*idmangv1.Group, error) {
+	resp, err := p.getGroupsForUsers(ctx, userIDs, authContext)
+	return resp, toStatusError(err)
+}
+
+func (p *Plugin) getGroupsForUsers(
+	ctx context.Context,
+	userIDs []string,
+	authContext *idmangv1.AuthContext,
+) (map[string][]*idmangv1.Group, error) {
+	s := p.state.Load()
+	if s == nil {
+		return nil, ErrNoScimClient
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	groups := make(map[string][]*idmangv1.Group, len(userIDs))
+	slots := make(chan struct{}, s.batchConcurrency())
+
+	for _, userID := range dedupe(userIDs) {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Go(func() {
+			defer func() { <-slots }()
+
+			resp, err := p.getGroupsForUser(ctx, &idmangv1.GetGroupsForUserRequest{
+				UserId:      userID,
+				AuthContext: authContext,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errs.Wrapf(err, "user "+userID)
+					cancel()
+				}
+
+				return
+			}
+
+			groups[userID] = resp.GetGroups()
+		})
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, errs.Wrap(ErrGetGroupsForUsers, firstErr)
+	}
+
+	// The context of the caller may have ended before any lookup failed
+	err := ctx.Err()
+	if err != nil {
+		return nil, errs.Wrap(ErrGetGroupsForUsers, err)
+	}
+
+	return groups, nil
+}
+
+func (s *pluginState) batchConcurrency() int {
+	if s.params.BatchConcurrency <= 0 {
+		return config.DefaultBatchConcurrency
+	}
+
+	return s.params.BatchConcurrency
+}
+
+// dedupe returns the values without duplicates, keeping their order.
+func dedupe(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	unique := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if _, ok := seen[value]; !ok {
+			seen[value] = struct{}{}
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
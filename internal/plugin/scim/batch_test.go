@@ -0,0 +1,124 @@
+package scim_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	idmangv1 "github.com/openkcm/plugin-sdk/proto/plugin/identity_management/v1"
+
+	plugin "github.com/openkcm/identity-management-plugins/internal/plugin/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim/scimtest"
+)
+
+func TestGetGroupsForUsers(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddGroups(
+		scim.Group{
+			BaseResource: scim.BaseResource{ID: "group1"},
+			DisplayName:  "KeyAdmin",
+			Members:      []scim.MultiValuedAttribute{{Value: "user1"}, {Value: "user2"}},
+		},
+		scim.Group{
+			BaseResource: scim.BaseResource{ID: "group2"},
+			DisplayName:  "Auditor",
+			Members:      []scim.MultiValuedAttribute{{Value: "user2"}},
+		},
+	)
+
+	tests := []struct {
+		name     string
+		userIDs  []string
+		expected map[string][]*idmangv1.Group
+	}{
+		{
+			name:    "Users with groups",
+			userIDs: []string{"user1", "user2"},
+			expected: map[string][]*idmangv1.Group{
+				"user1": {{Id: "group1", Name: "KeyAdmin"}},
+				"user2": {{Id: "group1", Name: "KeyAdmin"}, {Id: "group2", Name: "Auditor"}},
+			},
+		},
+		{
+			name:     "User without groups",
+			userIDs:  []string{"user3"},
+			expected: map[string][]*idmangv1.Group{"user3": {}},
+		},
+		{
+			name:    "Duplicate users",
+			userIDs: []string{"user1", "user1"},
+			expected: map[string][]*idmangv1.Group{
+				"user1": {{Id: "group1", Name: "KeyAdmin"}},
+			},
+		},
+		{
+			name:     "No users",
+			expected: map[string][]*idmangv1.Group{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "", "members.value")
+
+			groups, err := p.GetGroupsForUsers(t.Context(), tt.userIDs, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, groups)
+		})
+	}
+}
+
+func TestGetGroupsForUsersConcurrency(t *testing.T) {
+	const concurrency = 2
+
+	var inFlight, maxInFlight atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			peak := maxInFlight.Load()
+			if current <= peak || maxInFlight.CompareAndSwap(peak, current) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		if strings.Contains(r.URL.Query().Get("filter"), "failing") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		_, err := w.Write([]byte(ListGroupsResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	p := setupTest(t, server.URL, "", "members.value")
+	p.UpdateTestParams(func(params *plugin.Params) {
+		params.ListMethod = scim.ListMethodGet
+		params.BatchConcurrency = concurrency
+	})
+
+	groups, err := p.GetGroupsForUsers(t.Context(), []string{"user1", "user2", "user3", "user4", "user5"}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, groups, 5)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(concurrency))
+
+	_, err = p.GetGroupsForUsers(t.Context(), []string{"user1", "failing", "user3"}, nil)
+	assert.ErrorIs(t, err, plugin.ErrGetGroupsForUsers)
+	assert.ErrorContains(t, err, "user failing")
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
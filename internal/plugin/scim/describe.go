@@ -0,0 +1,70 @@
+package scim
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// Describe returns the effective configuration of the plugin, i.e. the
+// params as resolved from their SourceRefs, with credentials redacted.
+// It is empty until the plugin is configured.
+func (p *Plugin) Describe() string {
+	s := p.state.Load()
+	if s == nil {
+		return ""
+	}
+
+	return s.describe()
+}
+
+func (s *pluginState) describe() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Auth: %s %s\n", s.authType, redacted)
+
+	// Reflect over the params so that new ones are described without changes here
+	describeFields(&sb, reflect.ValueOf(s.params))
+
+	return sb.String()
+}
+
+// describeFields writes the fields of the struct, including those of
+// embedded structs such as the client params.
+func describeFields(sb *strings.Builder, params reflect.Value) {
+	for i := range params.NumField() {
+		field := params.Type().Field(i)
+		if field.Anonymous {
+			describeFields(sb, params.Field(i))
+			continue
+		}
+
+		value := params.Field(i).Interface()
+
+		if field.Tag.Get("describe") == "redact" {
+			value = redactValue(value)
+		}
+
+		fmt.Fprintf(sb, "%s: %v\n", field.Name, value)
+	}
+}
+
+// redactValue hides a value that may hold credentials, keeping only
+// the keys of a map such as headers.
+func redactValue(value any) any {
+	values, ok := value.(map[string]string)
+	if !ok {
+		return redacted
+	}
+
+	keys := slices.Sorted(maps.Keys(values))
+	for i, key := range keys {
+		keys[i] = key + "=" + redacted
+	}
+
+	return "[" + strings.Join(keys, " ") + "]"
+}
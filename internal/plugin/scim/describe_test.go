@@ -0,0 +1,51 @@
+package scim_test
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+
+	configv1 "github.com/openkcm/plugin-sdk/proto/service/common/config/v1"
+
+	plugin "github.com/openkcm/identity-management-plugins/internal/plugin/scim"
+)
+
+func TestDescribe(t *testing.T) {
+	p := plugin.NewPlugin(buildInfo)
+	p.SetLogger(hclog.NewNullLogger())
+
+	assert.Empty(t, p.Describe())
+
+	_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+		YamlConfiguration: getTestConfiguration("https://scim.example.com", "get"),
+	})
+	assert.NoError(t, err)
+
+	description := p.Describe()
+	assert.Contains(t, description, "BaseHost: https://scim.example.com\n")
+	assert.Contains(t, description, "GroupAttribute: displayName\n")
+	assert.Contains(t, description, "UserAttribute: userName\n")
+	assert.Contains(t, description, "GroupMembersAttribute: members\n")
+	assert.Contains(t, description, "ListMethod: GET\n")
+	assert.Contains(t, description, "Auth: basic [REDACTED]\n")
+	assert.NotContains(t, description, "secret")
+}
+
+func TestDescribeRedactsDefaultHeaders(t *testing.T) {
+	p := plugin.NewPlugin(buildInfo)
+	p.SetLogger(hclog.NewNullLogger())
+
+	_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+		YamlConfiguration: getTestConfiguration("https://scim.example.com", "get") + `  defaultHeaders:
+    source: embedded
+    value: "{X-Api-Key: apikey, X-Tenant: tenant1}"
+`,
+	})
+	assert.NoError(t, err)
+
+	description := p.Describe()
+	assert.Contains(t, description, "DefaultHeaders: [X-Api-Key=[REDACTED] X-Tenant=[REDACTED]]\n")
+	assert.NotContains(t, description, "apikey")
+	assert.NotContains(t, description, "tenant1")
+}
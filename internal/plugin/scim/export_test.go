@@ -14,7 +14,12 @@ func getLogger() hclog.Logger {
 	return hclog.New(&hclog.LoggerOptions{Level: hclog.Error})
 }
 
-func (p *Plugin) SetTestClient(t *testing.T, host string, groupFilterAttribute, userFilterAttribute string) {
+func (p *Plugin) SetTestClient(
+	t *testing.T,
+	host string,
+	groupFilterAttribute, userFilterAttribute string,
+	opts ...scim.Option,
+) {
 	t.Helper()
 
 	secretRef := commoncfg.SecretRef{
@@ -31,14 +36,27 @@ func (p *Plugin) SetTestClient(t *testing.T, host string, groupFilterAttribute,
 		},
 	}
 
-	client, err := scim.NewClient(secretRef, getLogger())
+	client, err := scim.NewClient(secretRef, getLogger(), opts...)
 	assert.NoError(t, err)
 
-	p.scimClient = client
-	p.params = Params{
-		BaseHost:                host,
-		GroupAttribute:          groupFilterAttribute,
-		UserAttribute:           userFilterAttribute,
-		AllowSearchUsersByGroup: true,
-	}
+	p.logger = getLogger()
+	p.state.Store(&pluginState{
+		client: client,
+		params: Params{
+			BaseHost:                host,
+			GroupAttribute:          groupFilterAttribute,
+			UserAttribute:           userFilterAttribute,
+			AllowSearchUsersByGroup: true,
+		},
+	})
+}
+
+func (p *Plugin) UpdateTestParams(update func(params *Params)) {
+	state := *p.state.Load()
+	update(&state.params)
+	p.state.Store(&state)
+}
+
+func (p *Plugin) TestClient() *scim.Client {
+	return p.state.Load().client
 }
@@ -0,0 +1,40 @@
+package scim
+
+import (
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+)
+
+// EmptyFilterPolicy decides how a lookup RPC handles a request
+// without a value to filter by, rejecting it with ErrNoID by default.
+type EmptyFilterPolicy = config.EmptyFilterPolicy
+
+const (
+	EmptyFilterReject   = config.EmptyFilterReject
+	EmptyFilterFetchAll = config.EmptyFilterFetchAll
+)
+
+var ErrInvalidEmptyFilterPolicy = config.ErrInvalidEmptyFilterPolicy
+
+func (s *pluginState) emptyFilterPolicy(op string) EmptyFilterPolicy {
+	policy, ok := s.params.EmptyFilterPolicies[op]
+	if !ok {
+		return EmptyFilterReject
+	}
+
+	return policy
+}
+
+// lookupFilters returns the candidate filters for the value, applying
+// the empty filter policy of the RPC if the value is empty.
+func (s *pluginState) lookupFilters(op, defaultAttribute, value, setAttribute string) ([]scim.FilterExpression, error) {
+	if value != "" {
+		return getFilters(defaultAttribute, value, setAttribute), nil
+	}
+
+	if s.emptyFilterPolicy(op) == EmptyFilterFetchAll {
+		return []scim.FilterExpression{allFilter}, nil
+	}
+
+	return nil, ErrNoID
+}
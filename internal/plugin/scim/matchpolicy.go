@@ -0,0 +1,63 @@
+package scim
+
+import (
+	idmangv1 "github.com/openkcm/plugin-sdk/proto/plugin/identity_management/v1"
+
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+)
+
+// MultipleMatchPolicy decides which group GetGroup returns if several
+// groups match the requested name, failing with ErrGetGroupMultipleGroups
+// by default.
+type MultipleMatchPolicy = config.MultipleMatchPolicy
+
+const (
+	MultipleMatchError     = config.MultipleMatchError
+	MultipleMatchFirst     = config.MultipleMatchFirst
+	MultipleMatchExactCase = config.MultipleMatchExactCase
+)
+
+var ErrInvalidMultipleMatchPolicy = config.ErrInvalidMultipleMatchPolicy
+
+// selectGroup returns the group matching the name among the listed
+// groups, applying the multiple match policy if there are several.
+// Servers may compare displayName case-insensitively, so with
+// ExactGroupNameMatch set groups differing in case are dropped first.
+func (s *pluginState) selectGroup(groups []*idmangv1.Group, name string) (*idmangv1.Group, error) {
+	if s.params.ExactGroupNameMatch {
+		groups = exactNameMatches(groups, name)
+	}
+
+	switch {
+	case len(groups) == 0:
+		return nil, ErrGetGroupNonExistent
+	case len(groups) == 1:
+		return groups[0], nil
+	}
+
+	switch s.params.MultipleMatchPolicy {
+	case MultipleMatchFirst:
+		return groups[0], nil
+	case MultipleMatchExactCase:
+		exact := exactNameMatches(groups, name)
+		if len(exact) == 1 {
+			return exact[0], nil
+		}
+	case MultipleMatchError:
+	}
+
+	return nil, ErrGetGroupMultipleGroups
+}
+
+// exactNameMatches returns the groups named exactly like name.
+func exactNameMatches(groups []*idmangv1.Group, name string) []*idmangv1.Group {
+	var exact []*idmangv1.Group
+
+	for _, group := range groups {
+		if group.GetName() == name {
+			exact = append(exact, group)
+		}
+	}
+
+	return exact
+}
@@ -0,0 +1,32 @@
+package scim
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// forwardMetadata returns the headers copied from the incoming gRPC
+// metadata of the context, limited to the allowlisted keys so that
+// other metadata, e.g. credentials of the caller, never reaches the
+// SCIM server. Values of a repeated key are joined with commas.
+func forwardMetadata(keys []string) func(ctx context.Context) map[string]string {
+	return func(ctx context.Context) map[string]string {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil
+		}
+
+		headers := make(map[string]string, len(keys))
+
+		for _, key := range keys {
+			values := md.Get(key)
+			if len(values) > 0 {
+				headers[key] = strings.Join(values, ", ")
+			}
+		}
+
+		return headers
+	}
+}
@@ -3,10 +3,12 @@ package scim
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
-	"net/http"
 	"net/url"
-	"strconv"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
@@ -15,7 +17,6 @@ import (
 	"github.com/samber/oops"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"gopkg.in/yaml.v3"
 
 	idmangv1 "github.com/openkcm/plugin-sdk/proto/plugin/identity_management/v1"
 	configv1 "github.com/openkcm/plugin-sdk/proto/service/common/config/v1"
@@ -26,54 +27,91 @@ import (
 )
 
 const (
-	defaultListMethod = http.MethodPost
-
 	defaultUserListAttribute     = "groups.display"
 	defaultGroupsFilterAttribute = "displayName"
 
 	modifiedByAttribute = "meta.lastModified"
+	groupIDAttribute    = "id"
+
+	// membersAttribute is excluded from group reads not resolving members,
+	// as it can make up most of the payload of large groups
+	membersAttribute = "members"
+
+	userIDAttribute   = "id"
+	userNameAttribute = "userName"
+	emailsAttribute   = "emails"
+
+	defaultRetryBackoff = 100 * time.Millisecond
+
+	// clientCloseGracePeriod lets in-flight requests of a replaced client finish
+	clientCloseGracePeriod = 30 * time.Second
+
+	workEmailType = "work"
+
+	opGetGroup         = "GetGroup"
+	opGetGroupsForUser = "GetGroupsForUser"
+	opGetUsersForGroup = "GetUsersForGroup"
+
+	// SCIM client calls made by the RPCs, labelled apart from the RPCs
+	opClientGetGroup        = "scim.GetGroup"
+	opClientGetUser         = "scim.GetUser"
+	opClientListUsers       = "scim.ListUsers"
+	opClientGetGroupMembers = "scim.GetGroupMembers"
 )
 
 var (
-	ErrID                     = oops.In("Identity management Plugin")
-	ErrNoScimClient           = errors.New("no scim client exists")
-	ErrGetGroup               = errors.New("failed to get group")
-	ErrGetUser                = errors.New("failed to get user")
-	ErrGetAllGroups           = errors.New("failed to get allx group")
-	ErrGetGroupNonExistent    = status.New(codes.NotFound, "group does not exist").Err()
-	ErrGetGroupMultipleGroups = errors.New("more than one group")
-	ErrGetGroupsForUser       = errors.New("failed to get groups for user")
-	ErrGetUsersForGroup       = errors.New("failed to get users for group")
-	ErrNoID                   = errors.New("no filter id provided")
+	ErrID                      = oops.In("Identity management Plugin")
+	ErrNoScimClient            = errors.New("no scim client exists")
+	ErrGetGroup                = errors.New("failed to get group")
+	ErrGetUser                 = errors.New("failed to get user")
+	ErrGetAllGroups            = errors.New("failed to get allx group")
+	ErrGetGroupNonExistent     = status.New(codes.NotFound, "group does not exist").Err()
+	ErrGetGroupMultipleGroups  = errors.New("more than one group")
+	ErrGetGroupsForUser        = errors.New("failed to get groups for user")
+	ErrGetUsersForGroup        = errors.New("failed to get users for group")
+	ErrNoID                    = errors.New("no filter id provided")
+	ErrNoGroupAttribute        = errors.New("no group attribute configured")
+	ErrGroupTooLarge           = errors.New("group exceeds the maximum number of members")
+	ErrMissingAuthContextField = errors.New("required auth context field missing")
+	ErrUnmappedRequiredHeader  = config.ErrUnmappedRequiredHeader
+	ErrMemberTimedOut          = errors.New("group member lookup timed out")
 )
 
+// memberUserAttributes are the attributes needed to map a user to an idmangv1.User.
+// They are always requested so that projection never drops the emails.
+var memberUserAttributes = []string{userIDAttribute, userNameAttribute, emailsAttribute}
+
 // allFilter is used to get all users or groups
 // by comparing the modified time to the zero timestamp
-var allFilter = scim.FilterComparison{
-	Attribute: modifiedByAttribute,
-	Operator:  scim.FilterOperatorGreater,
-	Value:     time.Unix(0, 0).Format(time.RFC3339),
-}
+var allFilter = scim.NewDatetimeFilter(modifiedByAttribute, scim.FilterOperatorGreater, time.Unix(0, 0))
 
-type Params struct {
-	BaseHost                string // Fallback host if not provided in auth context
-	GroupAttribute          string
-	UserAttribute           string
-	GroupMembersAttribute   string
-	ListMethod              string
-	AllowSearchUsersByGroup bool
-	AuthContext             config.AuthContextConfig
-}
+// Params are the params of the plugin as resolved by config.Load.
+type Params = config.ResolvedParams
 
 // Plugin is a simple test implementation of KeystoreProviderServer
 type Plugin struct {
 	idmangv1.UnsafeIdentityManagementServiceServer
 	configv1.UnsafeConfigServer
 
-	logger     hclog.Logger
-	scimClient *scim.Client
-	params     Params
-	buildInfo  string
+	logger    hclog.Logger
+	state     atomic.Pointer[pluginState]
+	buildInfo string
+}
+
+// pluginState holds the configured client and params, swapped
+// atomically on reconfiguration so each RPC sees a consistent pair.
+type pluginState struct {
+	client       *scim.Client
+	params       Params
+	authType     commoncfg.SecretType
+	clientConfig clientConfig
+}
+
+// clientConfig is what the client is built from, the client being
+// kept on reconfiguration while it is unchanged.
+type clientConfig struct {
+	auth   commoncfg.SecretRef
+	params config.ClientParams
 }
 
 var (
@@ -90,127 +128,183 @@ func NewPlugin(buildInfo string) *Plugin {
 func (p *Plugin) SetLogger(logger hclog.Logger) {
 	p.logger = logger // Keep a copy of the logger for client creation
 	slog.SetDefault(hclog2slog.New(logger))
+
+	p.logger.Info("Starting SCIM identity management plugin", "buildInfo", p.buildInfo)
 }
 
 func (p *Plugin) Configure(
 	_ context.Context,
 	req *configv1.ConfigureRequest,
 ) (*configv1.ConfigureResponse, error) {
-	slog.Info("Configuring plugin")
-
-	cfg := config.Config{}
+	slog.Info("Configuring plugin", "buildInfo", p.buildInfo)
 
-	err := yaml.Unmarshal([]byte(req.GetYamlConfiguration()), &cfg)
+	cfg, params, err := config.Load([]byte(req.GetYamlConfiguration()))
 	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed to get yaml Configuration")
+		return nil, ErrID.Wrapf(err, "Failed loading configuration")
 	}
 
-	baseHostBytes, err := commoncfg.LoadValueFromSourceRef(cfg.Host)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed loading base host")
-	}
+	clientConfig := clientConfig{auth: cfg.Auth, params: params.ClientParams}
 
-	groupAttrBytes, err := commoncfg.LoadValueFromSourceRef(cfg.Params.GroupAttribute)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed loading group attribute")
-	}
+	// Keep the client and its warm connections if only other params
+	// changed. Only basic credentials are reloaded by the client itself,
+	// so for other auth types the client is rebuilt to reload rotated
+	// certificates or secrets.
+	var client *scim.Client
+	if current := p.state.Load(); current != nil && cfg.Auth.Type == commoncfg.BasicSecretType &&
+		reflect.DeepEqual(current.clientConfig, clientConfig) {
+		client = current.client
+	} else {
+		opts := append(clientOptions(params.ClientParams, p.userAgent()), scim.WithObserver(p.observeRequest))
 
-	userAttrBytes, err := commoncfg.LoadValueFromSourceRef(cfg.Params.UserAttribute)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed loading user attribute")
+		client, err = scim.NewClient(cfg.Auth, p.logger, opts...)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	groupMemberAttrBytes, err := commoncfg.LoadValueFromSourceRef(cfg.Params.GroupMembersAttribute)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed loading group members attribute")
-	}
+	state := &pluginState{client: client, params: *params, authType: cfg.Auth.Type, clientConfig: clientConfig}
+	p.logger.Info("Configured plugin", "effectiveConfiguration", state.describe())
 
-	listMethodBytes, err := commoncfg.LoadValueFromSourceRef(cfg.Params.ListMethod)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed loading list method")
+	old := p.state.Swap(state)
+	if old != nil && old.client != client {
+		// Close the previous client once its in-flight requests are done
+		time.AfterFunc(clientCloseGracePeriod, old.client.Close)
 	}
 
-	allowSearchUsersByGroupBytes, err := commoncfg.LoadValueFromSourceRef(cfg.Params.AllowSearchUsersByGroup)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed loading allow search users by group")
+	return &configv1.ConfigureResponse{
+		BuildInfo: &p.buildInfo,
+	}, nil
+}
+
+// userAgent identifies the plugin and its deployed version to the SCIM server.
+func (p *Plugin) userAgent() string {
+	// Collapse whitespace as build info may be multi-line JSON
+	return fmt.Sprintf("%s (%s)", scim.DefaultUserAgent, strings.Join(strings.Fields(p.buildInfo), " "))
+}
+
+// clientOptions builds the SCIM client options from the client params.
+func clientOptions(params config.ClientParams, userAgent string) []scim.Option {
+	opts := []scim.Option{
+		scim.WithUserAgent(userAgent),
+		scim.WithRetries(params.MaxRetries, defaultRetryBackoff),
+		scim.WithMaxQueryLength(params.MaxQueryLength),
+		scim.WithPaginationParams(params.PaginationParams),
+		scim.WithMaxRequestBodySize(params.MaxRequestBodySize),
+		scim.WithHTTP2(params.EnableHTTP2),
+		scim.WithRedirectPolicy(params.RedirectPolicy),
+		scim.WithDefaultHeaders(params.DefaultHeaders),
+		scim.WithMaxConcurrentRequests(params.MaxConcurrentRequests),
 	}
 
-	allowSearchUsersByGroup, err := strconv.ParseBool(string(allowSearchUsersByGroupBytes))
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed parsing allow search users by group")
+	if len(params.ForwardedMetadata) > 0 {
+		opts = append(opts, scim.WithContextHeaders(forwardMetadata(params.ForwardedMetadata)))
 	}
 
-	authContextBytes, err := commoncfg.LoadValueFromSourceRef(cfg.AuthContext)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed loading auth context")
+	if params.RequestsPerSecond > 0 {
+		opts = append(opts, scim.WithRateLimit(float64(params.RequestsPerSecond), params.RequestBurst))
 	}
 
-	cfgAuthContext := config.AuthContextConfig{}
+	if params.MinimalFilterEncoding {
+		opts = append(opts, scim.WithMinimalFilterEncoding())
+	}
 
-	err = yaml.Unmarshal(authContextBytes, &cfgAuthContext)
-	if err != nil {
-		return nil, ErrID.Wrapf(err, "Failed to unmarshal auth context")
+	if params.NotFoundAsEmpty {
+		opts = append(opts, scim.WithNotFoundAsEmpty())
 	}
 
-	p.params = Params{
-		BaseHost:                string(baseHostBytes),
-		GroupAttribute:          string(groupAttrBytes),
-		UserAttribute:           string(userAttrBytes),
-		GroupMembersAttribute:   string(groupMemberAttrBytes),
-		ListMethod:              string(listMethodBytes),
-		AllowSearchUsersByGroup: allowSearchUsersByGroup,
-		AuthContext:             cfgAuthContext,
+	if params.ListResourcesKey != "" {
+		opts = append(opts, scim.WithListResourcesKey(params.ListResourcesKey))
 	}
 
-	client, err := scim.NewClient(cfg.Auth, p.logger)
-	if err != nil {
-		return nil, err
+	if params.ETagCacheTTL > 0 {
+		opts = append(opts,
+			scim.WithETagCache(params.ETagCacheTTL),
+			scim.WithCacheTTLJitter(params.CacheTTLJitterPercent),
+		)
 	}
 
-	p.scimClient = client
+	if params.CircuitBreakerThreshold > 0 {
+		opts = append(opts, scim.WithCircuitBreaker(params.CircuitBreakerThreshold, params.CircuitBreakerCooldown))
+	}
 
-	return &configv1.ConfigureResponse{
-		BuildInfo: &p.buildInfo,
-	}, nil
+	return opts
 }
 
 func (p *Plugin) GetGroup(
 	ctx context.Context,
 	request *idmangv1.GetGroupRequest,
 ) (*idmangv1.GetGroupResponse, error) {
-	if p.scimClient == nil {
+	resp, err := p.getGroup(ctx, request)
+	return resp, toStatusError(err)
+}
+
+func (p *Plugin) getGroup(
+	ctx context.Context,
+	request *idmangv1.GetGroupRequest,
+) (*idmangv1.GetGroupResponse, error) {
+	s := p.state.Load()
+	if s == nil {
 		return nil, ErrNoScimClient
 	}
 
-	attr := p.params.GroupAttribute
-	filter := getFilter(defaultGroupsFilterAttribute, request.GetGroupName(), attr)
+	group, err := p.findGroup(ctx, s, opGetGroup, request.GetGroupName(), request.GetAuthContext().GetData())
+	if errors.Is(err, ErrGetGroupNonExistent) {
+		return nil, err
+	} else if err != nil {
+		return nil, errs.WithOp(opGetGroup, errs.Wrap(ErrGetGroup, err))
+	}
+
+	return &idmangv1.GetGroupResponse{Group: group}, nil
+}
 
-	responseGroups, err := p.listGroups(ctx, filter, request.GetAuthContext().GetData())
+// findGroup returns the single group with the name, listing groups by
+// the group attribute and applying the match policies.
+func (p *Plugin) findGroup(
+	ctx context.Context,
+	s *pluginState,
+	op string,
+	name string,
+	authContextData map[string]string,
+) (*idmangv1.Group, error) {
+	filters, err := s.lookupFilters(op, defaultGroupsFilterAttribute, name, s.params.GroupAttribute)
 	if err != nil {
-		p.logger.Error("GetGroup: error listing groups", "error", err)
-		return nil, errs.Wrap(ErrGetGroup, err)
+		return nil, err
 	}
 
-	if len(responseGroups) == 0 {
-		return nil, ErrGetGroupNonExistent
-	} else if len(responseGroups) > 1 {
-		return nil, errs.Wrap(ErrGetGroup, ErrGetGroupMultipleGroups)
+	responseGroups, err := p.listGroups(ctx, s, filters, authContextData)
+	if err != nil {
+		p.logger.Error(op+": error listing groups", "error", err)
+		return nil, err
 	}
 
-	return &idmangv1.GetGroupResponse{Group: responseGroups[0]}, nil
+	return s.selectGroup(responseGroups, name)
 }
 
 func (p *Plugin) GetUser(
 	ctx context.Context,
 	request *idmangv1.GetUserRequest,
 ) (*idmangv1.GetUserResponse, error) {
-	if p.scimClient == nil {
+	resp, err := p.getUser(ctx, request)
+	return resp, toStatusError(err)
+}
+
+func (p *Plugin) getUser(
+	ctx context.Context,
+	request *idmangv1.GetUserRequest,
+) (*idmangv1.GetUserResponse, error) {
+	s := p.state.Load()
+	if s == nil {
 		return nil, ErrNoScimClient
 	}
 
-	host, headers := p.extractAuthContext(request.GetAuthContext().GetData())
+	host, headers, err := p.extractAuthContext(s, request.GetAuthContext().GetData())
+	if err != nil {
+		return nil, errs.Wrap(ErrGetUser, err)
+	}
 
-	user, err := p.scimClient.GetUser(ctx, request.GetUserId(), scim.RequestParams{
+	ctx = scim.ContextWithTenant(ctx, host)
+
+	user, err := s.client.GetUser(ctx, request.GetUserId(), scim.RequestParams{
 		Host:    host,
 		Headers: headers,
 	})
@@ -222,7 +316,7 @@ func (p *Plugin) GetUser(
 	return &idmangv1.GetUserResponse{User: &idmangv1.User{
 		Id:    user.ID,
 		Name:  user.UserName,
-		Email: getPrimaryEmailAddress(user),
+		Email: p.primaryEmailAddress(user),
 	}}, nil
 }
 
@@ -230,13 +324,32 @@ func (p *Plugin) GetAllGroups(
 	ctx context.Context,
 	request *idmangv1.GetAllGroupsRequest,
 ) (*idmangv1.GetAllGroupsResponse, error) {
-	host, headers := p.extractAuthContext(request.GetAuthContext().GetData())
+	resp, err := p.getAllGroups(ctx, request)
+	return resp, toStatusError(err)
+}
 
-	groups, err := p.scimClient.ListGroups(ctx, scim.RequestParams{
-		Host:    host,
-		Method:  p.getListMethod(),
-		Filter:  allFilter,
-		Headers: headers,
+func (p *Plugin) getAllGroups(
+	ctx context.Context,
+	request *idmangv1.GetAllGroupsRequest,
+) (*idmangv1.GetAllGroupsResponse, error) {
+	s := p.state.Load()
+	if s == nil {
+		return nil, ErrNoScimClient
+	}
+
+	host, headers, err := p.extractAuthContext(s, request.GetAuthContext().GetData())
+	if err != nil {
+		return nil, errs.Wrap(ErrGetAllGroups, err)
+	}
+
+	ctx = scim.ContextWithTenant(ctx, host)
+
+	groups, err := s.client.ListGroups(ctx, scim.RequestParams{
+		Host:               host,
+		Method:             s.getListMethod(),
+		Filter:             allFilter,
+		Headers:            headers,
+		ExcludedAttributes: []string{membersAttribute},
 	})
 	if err != nil {
 		return nil, errs.Wrap(ErrGetAllGroups, err)
@@ -258,24 +371,36 @@ func (p *Plugin) GetUsersForGroup(
 	ctx context.Context,
 	request *idmangv1.GetUsersForGroupRequest,
 ) (*idmangv1.GetUsersForGroupResponse, error) {
-	if p.scimClient == nil {
+	resp, err := p.getUsersForGroup(ctx, request)
+	return resp, toStatusError(err)
+}
+
+func (p *Plugin) getUsersForGroup(
+	ctx context.Context,
+	request *idmangv1.GetUsersForGroupRequest,
+) (*idmangv1.GetUsersForGroupResponse, error) {
+	s := p.state.Load()
+	if s == nil {
 		return nil, ErrNoScimClient
 	}
 
 	groupID := request.GetGroupId()
 
-	if groupID == "" {
-		return nil, errs.Wrap(ErrGetUsersForGroup, ErrNoID)
-	}
-
 	var (
 		responseUsers        []*idmangv1.User
-		getUsersForGroupFunc func(context.Context, string, string, map[string]string) ([]*idmangv1.User, error)
+		getUsersForGroupFunc func(context.Context, *pluginState, string, string, map[string]string) ([]*idmangv1.User, error)
 	)
 
-	if p.params.AllowSearchUsersByGroup {
+	switch {
+	case groupID == "" && s.emptyFilterPolicy(opGetUsersForGroup) == EmptyFilterFetchAll:
+		getUsersForGroupFunc = p.getAllUsers
+	case groupID == "":
+		return nil, errs.WithOp(opGetUsersForGroup, errs.Wrap(ErrGetUsersForGroup, ErrNoID))
+	case s.params.MemberIDsOnly:
+		getUsersForGroupFunc = p.getMemberIDsForGroup
+	case s.params.AllowSearchUsersByGroup:
 		getUsersForGroupFunc = p.getUsersForGroupUsingUserList
-	} else {
+	default:
 		// If SCIM API does not support filtering users by group attribute,
 		// we need to fall back to getting individual users by firstly
 		// getting the user IDs from the group members attribute and
@@ -283,11 +408,30 @@ func (p *Plugin) GetUsersForGroup(
 		getUsersForGroupFunc = p.getUsersForGroupUsingGroupMembers
 	}
 
-	host, headers := p.extractAuthContext(request.GetAuthContext().GetData())
+	host, headers, err := p.extractAuthContext(s, request.GetAuthContext().GetData())
+	if err != nil {
+		return nil, errs.WithOp(opGetUsersForGroup, errs.Wrap(ErrGetUsersForGroup, err))
+	}
+
+	ctx = scim.ContextWithTenant(ctx, host)
+
+	if s.params.RetryBudget > 0 {
+		// Bound the retries of the whole fan-out rather than each member request
+		ctx = scim.ContextWithRetryBudget(ctx, scim.NewRetryBudget(s.params.RetryBudget))
+	}
+
+	if s.params.ResolveGroupName && groupID != "" {
+		group, err := p.findGroup(ctx, s, opGetUsersForGroup, groupID, request.GetAuthContext().GetData())
+		if err != nil {
+			return nil, errs.WithOp(opGetUsersForGroup, errs.Wrap(ErrGetUsersForGroup, err))
+		}
+
+		groupID = group.GetId()
+	}
 
-	responseUsers, err := getUsersForGroupFunc(ctx, groupID, host, headers)
+	responseUsers, err = getUsersForGroupFunc(ctx, s, groupID, host, headers)
 	if err != nil {
-		return nil, errs.Wrap(ErrGetUsersForGroup, err)
+		return nil, errs.WithOp(opGetUsersForGroup, errs.Wrap(ErrGetUsersForGroup, err))
 	}
 
 	return &idmangv1.GetUsersForGroupResponse{Users: responseUsers}, nil
@@ -297,14 +441,25 @@ func (p *Plugin) GetGroupsForUser(
 	ctx context.Context,
 	request *idmangv1.GetGroupsForUserRequest,
 ) (*idmangv1.GetGroupsForUserResponse, error) {
-	if p.scimClient == nil {
+	resp, err := p.getGroupsForUser(ctx, request)
+	return resp, toStatusError(err)
+}
+
+func (p *Plugin) getGroupsForUser(
+	ctx context.Context,
+	request *idmangv1.GetGroupsForUserRequest,
+) (*idmangv1.GetGroupsForUserResponse, error) {
+	s := p.state.Load()
+	if s == nil {
 		return nil, ErrNoScimClient
 	}
 
-	attr := p.params.UserAttribute
-	filter := getFilter(defaultUserListAttribute, request.GetUserId(), attr)
+	filters, err := s.lookupFilters(opGetGroupsForUser, defaultUserListAttribute, request.GetUserId(), s.params.UserAttribute)
+	if err != nil {
+		return nil, errs.Wrap(ErrGetGroupsForUser, err)
+	}
 
-	responseGroups, err := p.listGroups(ctx, filter, request.GetAuthContext().GetData())
+	responseGroups, err := p.listGroups(ctx, s, filters, request.GetAuthContext().GetData())
 	if err != nil {
 		return nil, errs.Wrap(ErrGetGroupsForUser, err)
 	}
@@ -312,25 +467,44 @@ func (p *Plugin) GetGroupsForUser(
 	return &idmangv1.GetGroupsForUserResponse{Groups: responseGroups}, nil
 }
 
+// listGroups lists the groups matching the first of the candidate
+// filters that yields any result.
 func (p *Plugin) listGroups(
 	ctx context.Context,
-	filter scim.FilterExpression,
+	s *pluginState,
+	filters []scim.FilterExpression,
 	authContextData map[string]string,
 ) ([]*idmangv1.Group, error) {
-	if (filter == scim.NullFilterExpression{}) {
-		return nil, ErrNoID
+	host, headers, err := p.extractAuthContext(s, authContextData)
+	if err != nil {
+		return nil, err
 	}
 
-	host, headers := p.extractAuthContext(authContextData)
+	ctx = scim.ContextWithTenant(ctx, host)
 
-	groups, err := p.scimClient.ListGroups(ctx, scim.RequestParams{
-		Host:    host,
-		Method:  p.getListMethod(),
-		Filter:  filter,
-		Headers: headers,
-	})
-	if err != nil {
-		return nil, err
+	var groups *scim.GroupList
+
+	for _, filter := range filters {
+		if (filter == scim.NullFilterExpression{}) {
+			return nil, ErrNoID
+		}
+
+		var err error
+
+		groups, err = s.client.ListGroups(ctx, scim.RequestParams{
+			Host:               host,
+			Method:             s.getListMethod(),
+			Filter:             filter,
+			Headers:            headers,
+			ExcludedAttributes: []string{membersAttribute},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(groups.Resources) > 0 {
+			break
+		}
 	}
 
 	responseGroups := make([]*idmangv1.Group, len(groups.Resources))
@@ -345,44 +519,89 @@ func (p *Plugin) listGroups(
 	return responseGroups, nil
 }
 
-func (p *Plugin) getListMethod() string {
-	if p.params.ListMethod != "" {
-		return p.params.ListMethod
+func (s *pluginState) getListMethod() string {
+	if s.params.ListMethod != "" {
+		return string(s.params.ListMethod)
 	}
 
-	return defaultListMethod
+	return string(config.DefaultListMethod)
 }
 
 func (p *Plugin) getUsersForGroupUsingUserList(
 	ctx context.Context,
+	s *pluginState,
 	groupID string,
 	host string,
 	headers map[string]string,
 ) ([]*idmangv1.User, error) {
-	responseUsers := make([]*idmangv1.User, 0)
+	if len(candidateAttributes(s.params.GroupAttribute)) == 0 {
+		return nil, ErrNoGroupAttribute
+	}
 
-	attr := p.params.GroupAttribute
-	if attr == "" {
-		return nil, errs.Wrap(ErrGetUsersForGroup, errors.New("no group attribute configured"))
+	if s.params.VerifyGroupExists {
+		// An empty user list is otherwise indistinguishable from a missing group
+		_, err := s.client.GetGroup(ctx, groupID, groupIDAttribute, scim.RequestParams{
+			Host:    host,
+			Headers: headers,
+		})
+		if isNotFound(err) {
+			return nil, ErrGetGroupNonExistent
+		} else if err != nil {
+			return nil, errs.WithOp(opClientGetGroup, err)
+		}
 	}
 
-	filter := getFilter(defaultUserListAttribute, groupID, attr)
+	return p.listUsers(ctx, s, getFilters(defaultUserListAttribute, groupID, s.params.GroupAttribute), host, headers)
+}
 
-	users, err := p.scimClient.ListUsers(ctx, scim.RequestParams{
-		Host:    host,
-		Method:  p.getListMethod(),
-		Filter:  filter,
-		Headers: headers,
-	})
-	if err != nil {
-		return nil, errs.Wrap(ErrGetUsersForGroup, err)
+// getAllUsers lists all users, ignoring the empty group ID.
+func (p *Plugin) getAllUsers(
+	ctx context.Context,
+	s *pluginState,
+	_ string,
+	host string,
+	headers map[string]string,
+) ([]*idmangv1.User, error) {
+	return p.listUsers(ctx, s, []scim.FilterExpression{allFilter}, host, headers)
+}
+
+// listUsers lists the users matching the first of the candidate
+// filters that yields any result.
+func (p *Plugin) listUsers(
+	ctx context.Context,
+	s *pluginState,
+	filters []scim.FilterExpression,
+	host string,
+	headers map[string]string,
+) ([]*idmangv1.User, error) {
+	responseUsers := make([]*idmangv1.User, 0)
+
+	var users *scim.UserList
+
+	for _, filter := range filters {
+		var err error
+
+		users, err = s.client.ListUsers(ctx, scim.RequestParams{
+			Host:       host,
+			Method:     s.getListMethod(),
+			Filter:     filter,
+			Headers:    headers,
+			Attributes: memberUserAttributes,
+		})
+		if err != nil {
+			return nil, errs.WithOp(opClientListUsers, err)
+		}
+
+		if len(users.Resources) > 0 {
+			break
+		}
 	}
 
 	for _, user := range users.Resources {
 		responseUsers = append(responseUsers, &idmangv1.User{
 			Id:    user.ID,
 			Name:  user.UserName,
-			Email: getPrimaryEmailAddress(&user),
+			Email: p.primaryEmailAddress(&user),
 		})
 	}
 
@@ -391,67 +610,242 @@ func (p *Plugin) getUsersForGroupUsingUserList(
 
 func (p *Plugin) getUsersForGroupUsingGroupMembers(
 	ctx context.Context,
+	s *pluginState,
 	groupID string,
 	host string,
 	headers map[string]string,
 ) ([]*idmangv1.User, error) {
 	responseUsers := make([]*idmangv1.User, 0)
 
-	group, err := p.scimClient.GetGroup(
-		ctx, groupID, p.params.GroupMembersAttribute,
-		scim.RequestParams{
-			Host:    host,
-			Headers: headers,
-		},
-	)
+	members, err := p.getGroupMembers(ctx, s, groupID, host, headers)
 	if err != nil {
-		return nil, errs.Wrap(ErrGetUsersForGroup, err)
+		return nil, err
 	}
 
-	for _, member := range group.Members {
-		user, err := p.scimClient.GetUser(ctx, member.Value, scim.RequestParams{
-			Host:    host,
-			Headers: headers,
-		})
+	var memberErrs []error
+
+	for _, member := range members {
+		if s.params.UseMemberDisplay && member.Display != "" {
+			responseUsers = append(responseUsers, &idmangv1.User{Id: member.Value, Name: member.Display})
+
+			continue
+		}
+
+		user, err := p.getMember(ctx, s, member.Value, host, headers)
+		if errors.Is(err, ErrMemberTimedOut) && s.params.SkipTimedOutMembers {
+			p.logger.Warn("Skipping group member whose lookup timed out",
+				"groupID", groupID, "userID", member.Value, "memberTimeout", s.params.MemberTimeout)
+
+			continue
+		}
+
+		if err != nil && s.params.PartialMemberResults && ctx.Err() == nil {
+			memberErrs = append(memberErrs, errs.WithOp(member.Value, err))
+
+			continue
+		}
+
 		if err != nil {
-			return nil, errs.Wrap(ErrGetUsersForGroup, err)
+			return nil, errs.WithOp(opClientGetUser, err)
 		}
 
 		responseUsers = append(responseUsers, &idmangv1.User{
 			Id:    user.ID,
 			Name:  user.UserName,
-			Email: getPrimaryEmailAddress(user),
+			Email: p.primaryEmailAddress(user),
 		})
 	}
 
+	return p.partialMembers(groupID, responseUsers, memberErrs)
+}
+
+// partialMembers returns the users resolved despite the member errors,
+// logging a summary of the failures. It fails only if every member
+// lookup failed, as an empty result would hide the group's members.
+func (p *Plugin) partialMembers(
+	groupID string,
+	users []*idmangv1.User,
+	memberErrs []error,
+) ([]*idmangv1.User, error) {
+	if len(memberErrs) == 0 {
+		return users, nil
+	}
+
+	err := errs.Joinf(fmt.Sprintf("%d of %d members failed", len(memberErrs), len(memberErrs)+len(users)),
+		memberErrs...)
+	if len(users) == 0 {
+		return nil, errs.WithOp(opClientGetUser, err)
+	}
+
+	p.logger.Warn("Returning partial group members",
+		"groupID", groupID, "resolved", len(users), "failed", len(memberErrs), "error", err)
+
+	return users, nil
+}
+
+// getMember gets a group member, bounded by the member timeout if set.
+// A member exceeding it while the RPC is still live fails with
+// ErrMemberTimedOut.
+func (p *Plugin) getMember(
+	ctx context.Context,
+	s *pluginState,
+	userID string,
+	host string,
+	headers map[string]string,
+) (*scim.User, error) {
+	memberCtx := ctx
+
+	if s.params.MemberTimeout > 0 {
+		var cancel context.CancelFunc
+
+		memberCtx, cancel = context.WithTimeoutCause(ctx, s.params.MemberTimeout, ErrMemberTimedOut)
+		defer cancel()
+	}
+
+	user, err := s.client.GetUser(memberCtx, userID, scim.RequestParams{
+		Host:       host,
+		Headers:    headers,
+		Attributes: memberUserAttributes,
+	})
+	if err != nil && ctx.Err() == nil && errors.Is(context.Cause(memberCtx), ErrMemberTimedOut) {
+		return nil, errs.Wrapf(ErrMemberTimedOut, userID)
+	}
+
+	return user, err
+}
+
+// getMemberIDsForGroup returns the members of the group as users with
+// only their ID set, without a GetUser request per member.
+func (p *Plugin) getMemberIDsForGroup(
+	ctx context.Context,
+	s *pluginState,
+	groupID string,
+	host string,
+	headers map[string]string,
+) ([]*idmangv1.User, error) {
+	members, err := p.getGroupMembers(ctx, s, groupID, host, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	responseUsers := make([]*idmangv1.User, len(members))
+	for i, member := range members {
+		responseUsers[i] = &idmangv1.User{Id: member.Value}
+	}
+
 	return responseUsers, nil
 }
 
-func (p *Plugin) extractAuthContext(authContextData map[string]string) (string, map[string]string) {
-	hostField := p.params.AuthContext.HostField
-	host := authContextData[hostField]
+// getGroupMembers returns the members of the group, capped to MaxGroupMembers.
+func (p *Plugin) getGroupMembers(
+	ctx context.Context,
+	s *pluginState,
+	groupID string,
+	host string,
+	headers map[string]string,
+) ([]scim.MultiValuedAttribute, error) {
+	members, err := s.client.GetGroupMembers(
+		ctx, groupID, s.params.GroupMembersAttribute,
+		scim.RequestParams{
+			Host:    host,
+			Headers: headers,
+		},
+	)
+	if isNotFound(err) {
+		return nil, ErrGetGroupNonExistent
+	} else if err != nil {
+		return nil, errs.WithOp(opClientGetGroupMembers, err)
+	}
+
+	return p.capMembers(s, groupID, members)
+}
+
+// capMembers bounds the members to resolve to MaxGroupMembers, rejecting
+// larger groups with ErrGroupTooLarge unless TruncateLargeGroups is set.
+func (p *Plugin) capMembers(
+	s *pluginState,
+	groupID string,
+	members []scim.MultiValuedAttribute,
+) ([]scim.MultiValuedAttribute, error) {
+	maxMembers := s.params.MaxGroupMembers
+	if maxMembers <= 0 || len(members) <= maxMembers {
+		return members, nil
+	}
+
+	if !s.params.TruncateLargeGroups {
+		return nil, errs.Wrapf(ErrGroupTooLarge, fmt.Sprintf("%d members", len(members)))
+	}
+
+	p.logger.Warn("Truncating members of large group",
+		"groupID", groupID, "members", len(members), "maxGroupMembers", maxMembers)
+
+	return members[:maxMembers], nil
+}
+
+// observeRequest logs each SCIM request attempt with the tenant
+// of the RPC it was made for.
+func (p *Plugin) observeRequest(_ context.Context, info scim.RequestInfo) {
+	p.logger.Debug("SCIM request",
+		"tenant", info.Tenant,
+		"method", info.Method,
+		"url", info.URL,
+		"attempt", info.Attempt,
+		"status", info.StatusCode,
+		"duration", info.Duration,
+		"error", info.Err,
+	)
+}
+
+// extractAuthContext resolves the host and headers of a request from the
+// auth context data, failing if a required header cannot be rendered.
+func (p *Plugin) extractAuthContext(
+	s *pluginState,
+	authContextData map[string]string,
+) (string, map[string]string, error) {
+	hostFields := s.params.AuthContext.HostFieldCandidates()
+
+	var host string
 
-	if host != "" {
-		joinedURL, err := url.JoinPath(host, p.params.AuthContext.BasePath)
+	for _, field := range hostFields {
+		host = authContextData[field]
+		if host != "" {
+			break
+		}
+	}
+
+	switch {
+	case host != "":
+		joinedURL, err := url.JoinPath(host, s.params.AuthContext.BasePath)
 		if err != nil {
 			p.logger.Warn("Failed to join host and base path, using host as is",
-				"error", err, "host", host, "basePath", p.params.AuthContext.BasePath)
+				"error", err, "host", host, "basePath", s.params.AuthContext.BasePath)
 		} else {
 			host = joinedURL
 		}
-	} else {
-		host = p.params.BaseHost
+	case s.params.RequireAuthContextHost:
+		// Falling back could route a tenant's request to the global host
+		return "", nil, errs.Wrapf(ErrMissingAuthContextField,
+			"host fields "+strings.Join(hostFields, ", "))
+	default:
+		host = s.params.BaseHost
 	}
 
 	headers := make(map[string]string)
 
-	for key, field := range p.params.AuthContext.HeaderFields {
-		if val, ok := authContextData[field]; ok {
+	for key, header := range s.params.AuthContext.HeaderFields {
+		if val, ok := header.Render(authContextData); ok {
 			headers[key] = val
 		}
 	}
 
-	return host, headers
+	for _, key := range s.params.AuthContext.RequiredHeaderFields {
+		if _, ok := headers[key]; !ok {
+			return "", nil, errs.Wrapf(ErrMissingAuthContextField,
+				fmt.Sprintf("header %s from %q", key, s.params.AuthContext.HeaderFields[key]))
+		}
+	}
+
+	return host, headers, nil
 }
 
 func getFilter(defaultAttribute, value string, setAttribute string) scim.FilterExpression {
@@ -472,14 +866,66 @@ func getFilter(defaultAttribute, value string, setAttribute string) scim.FilterE
 	return filter
 }
 
-func getPrimaryEmailAddress(user *scim.User) string {
+// getFilters returns a filter per candidate attribute of setAttribute,
+// to be tried in order, or a single filter on defaultAttribute if unset.
+func getFilters(defaultAttribute, value string, setAttribute string) []scim.FilterExpression {
+	attributes := candidateAttributes(setAttribute)
+	if len(attributes) == 0 {
+		return []scim.FilterExpression{getFilter(defaultAttribute, value, "")}
+	}
+
+	filters := make([]scim.FilterExpression, len(attributes))
+	for i, attribute := range attributes {
+		filters[i] = getFilter(defaultAttribute, value, attribute)
+	}
+
+	return filters
+}
+
+// candidateAttributes splits a comma-separated attribute list.
+func candidateAttributes(attributes string) []string {
+	var candidates []string
+
+	for attribute := range strings.SplitSeq(attributes, ",") {
+		attribute = strings.TrimSpace(attribute)
+		if attribute != "" {
+			candidates = append(candidates, attribute)
+		}
+	}
+
+	return candidates
+}
+
+// primaryEmailAddress returns the email address of the user flagged
+// primary, or else its work or first email address. Of several
+// addresses flagged primary, which the SCIM spec disallows, the first
+// in document order is returned and a warning logged.
+func (p *Plugin) primaryEmailAddress(user *scim.User) string {
+	var primaries []string
+
+	for _, email := range user.Emails {
+		if email.Primary && email.Value != "" {
+			primaries = append(primaries, email.Value)
+		}
+	}
+
+	if len(primaries) > 1 {
+		p.logger.Warn("User has multiple primary emails, using the first",
+			"userID", user.ID, "primaryEmails", len(primaries))
+	}
+
+	if len(primaries) > 0 {
+		return primaries[0]
+	}
+
+	// Prefer a work email over other types if no primary is set
 	for _, email := range user.Emails {
-		if email.Primary {
+		if strings.EqualFold(email.Type, workEmailType) {
 			return email.Value
 		}
 	}
 
-	// Fallback to the first email if no primary is set
+	// Fallback to the first email if no primary or work email is set
 	if len(user.Emails) > 0 {
 		return user.Emails[0].Value
 	}
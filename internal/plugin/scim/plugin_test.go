@@ -1,19 +1,31 @@
 package scim_test
 
 import (
-	"io"
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
-	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
-	"github.com/openkcm/common-sdk/pkg/pointers"
+	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	idmangv1 "github.com/openkcm/plugin-sdk/proto/plugin/identity_management/v1"
+	configv1 "github.com/openkcm/plugin-sdk/proto/service/common/config/v1"
 
 	plugin "github.com/openkcm/identity-management-plugins/internal/plugin/scim"
 	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim/scimtest"
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
 )
 
 const (
@@ -32,9 +44,6 @@ const (
 		`"mailVerified":false, "userId":"P000011", "status":"active",` +
 		`"passwordDetails":{"failedLoginAttempts":0, "setTime":"2020-04-10T11:29:36Z",` +
 		`"status":"initial", "policy":"https://dummy.domain.com/policy/passwords/comp/web/1.1"}}}`
-	ListUsersResponse = `{"Resources":[` + GetUserResponse + `],` +
-		`"totalResults":1, "startIndex": 1, "itemsPerPage":1,` +
-		`"schemas":["urn:ietf:params:scim:api:messages:2.0:ListResponse"]}`
 
 	GetGroupResponse = `{"id":"16e720aa-a009-4949-9bf9-aaaaaaaaaaaa",` +
 		`"meta":{"created":"2020-11-12T14:55:12Z","lastModified":"2021-03-31T14:56:01Z",` +
@@ -54,10 +63,73 @@ const (
 )
 
 var (
-	NonExistentFieldPtr *string = pointers.To(NonExistentField)
+	NonExistentFieldPtr *string = ptr.To(NonExistentField)
 	buildInfo                   = "{}"
+
+	// analystUser and keyAdminGroup seed the SCIM test servers with the
+	// user and group of GetUserResponse and GetGroupResponse.
+	analystUser = scim.User{
+		BaseResource: scim.BaseResource{
+			ID:   "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+			Meta: scim.Meta{LastModified: "2021-05-18T15:18:00Z"},
+		},
+		UserName:    "cloudanalyst",
+		DisplayName: "None",
+		UserType:    "employee",
+		Active:      true,
+		Emails:      []scim.MultiValuedAttribute{{Value: "cloud.analyst@example.com", Primary: true}},
+		Groups:      []scim.MultiValuedAttribute{{Value: "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa", Display: "KeyAdmin"}},
+	}
+	keyAdminGroup = scim.Group{
+		BaseResource: scim.BaseResource{
+			ID:   "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa",
+			Meta: scim.Meta{LastModified: "2021-03-31T14:56:01Z"},
+		},
+		DisplayName: "KeyAdmin",
+		Members:     []scim.MultiValuedAttribute{{Value: "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee", Type: "User"}},
+	}
 )
 
+// newMembersServer returns a SCIM server with group1 having the members,
+// and a user named after its ID for each of the users.
+func newMembersServer(members []scim.MultiValuedAttribute, users ...string) *scimtest.Server {
+	server := scimtest.NewServer()
+	server.AddGroups(scim.Group{
+		BaseResource: scim.BaseResource{ID: "group1", Meta: keyAdminGroup.Meta},
+		DisplayName:  "KeyAdmin",
+		Members:      members,
+	})
+
+	for _, id := range users {
+		server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: id}, UserName: id})
+	}
+
+	return server
+}
+
+// memberRefs returns member references to the users with the IDs.
+func memberRefs(ids ...string) []scim.MultiValuedAttribute {
+	members := make([]scim.MultiValuedAttribute, len(ids))
+	for i, id := range ids {
+		members[i] = scim.MultiValuedAttribute{Value: id}
+	}
+
+	return members
+}
+
+// countRequests counts the requests to the server whose path has the prefix.
+func countRequests(server *scimtest.Server, prefix string) int32 {
+	var count int32
+
+	for _, request := range server.Requests() {
+		if strings.HasPrefix(request.Path, prefix) {
+			count++
+		}
+	}
+
+	return count
+}
+
 func setupTest(t *testing.T, url string, groupFilterAttribute, userFilterAttribute string) *plugin.Plugin {
 	t.Helper()
 
@@ -85,15 +157,11 @@ func TestNoScimClient(t *testing.T) {
 }
 
 func TestGetAllGroups(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := w.Write([]byte(ListGroupsResponse))
-
-		assert.NoError(t, err)
-
-		w.WriteHeader(http.StatusOK)
-	}))
+	server := scimtest.NewServer()
 	defer server.Close()
 
+	server.AddGroups(keyAdminGroup)
+
 	tests := []struct {
 		name              string
 		serverUrl         string
@@ -151,24 +219,11 @@ func TestGetAllGroups(t *testing.T) {
 }
 
 func TestGetUsersForGroup(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		bodyBytes, err := io.ReadAll(r.Body)
-		assert.NoError(t, err)
-
-		// Quick and dirty mock server filtering. Fine since we aren't testing server here
-		reqStr := string(bodyBytes)
-		if strings.Contains(reqStr, NonExistentField) {
-			_, err = w.Write([]byte(EmptyResponse))
-		} else {
-			_, err = w.Write([]byte(ListUsersResponse))
-		}
-
-		assert.NoError(t, err)
-
-		w.WriteHeader(http.StatusOK)
-	}))
+	server := scimtest.NewServer()
 	defer server.Close()
 
+	server.AddUsers(analystUser)
+
 	tests := []struct {
 		name                 string
 		serverUrl            string
@@ -262,166 +317,1951 @@ func TestGetUsersForGroup(t *testing.T) {
 	}
 }
 
-func TestGetUser(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, err := w.Write([]byte(GetUserResponse))
-		assert.NoError(t, err)
-		w.WriteHeader(http.StatusOK)
-	}))
+func TestGetUsersForGroupMissingGroup(t *testing.T) {
+	server := scimtest.NewServer()
 	defer server.Close()
 
+	server.AddGroups(scim.Group{BaseResource: scim.BaseResource{ID: "group1"}, DisplayName: "Empty"})
+
 	tests := []struct {
-		name          string
-		serverUrl     string
-		userId        string
-		expectedID    string
-		expectedName  string
-		expectedEmail string
-		expectedError error
+		name                    string
+		groupID                 string
+		allowSearchUsersByGroup bool
+		verifyGroupExists       bool
+		expectedCode            codes.Code
 	}{
 		{
-			name:          "User found",
-			serverUrl:     server.URL,
-			userId:        "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
-			expectedID:    "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
-			expectedName:  "cloudanalyst",
-			expectedEmail: "cloud.analyst@example.com",
-			expectedError: nil,
+			name:         "Group members of missing group",
+			groupID:      "missing",
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:         "Group members of empty group",
+			groupID:      "group1",
+			expectedCode: codes.OK,
+		},
+		{
+			name:                    "User list of missing group without verification",
+			groupID:                 "missing",
+			allowSearchUsersByGroup: true,
+			expectedCode:            codes.OK,
+		},
+		{
+			name:                    "User list of missing group with verification",
+			groupID:                 "missing",
+			allowSearchUsersByGroup: true,
+			verifyGroupExists:       true,
+			expectedCode:            codes.NotFound,
+		},
+		{
+			name:                    "User list of empty group with verification",
+			groupID:                 "group1",
+			allowSearchUsersByGroup: true,
+			verifyGroupExists:       true,
+			expectedCode:            codes.OK,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := setupTest(t, tt.serverUrl, "", "")
+			p := setupTest(t, server.URL, "groups.value", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = tt.allowSearchUsersByGroup
+				params.VerifyGroupExists = tt.verifyGroupExists
+			})
 
-			resp, err := p.GetUser(
-				t.Context(),
-				&idmangv1.GetUserRequest{
-					UserId: tt.userId,
-				},
-			)
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: tt.groupID})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
 
-			if tt.expectedError == nil {
-				assert.NoError(t, err)
-				assert.NotNil(t, resp)
-				assert.Equal(t, tt.expectedID, resp.GetUser().GetId())
-				assert.Equal(t, tt.expectedName, resp.GetUser().GetName())
-				assert.Equal(t, tt.expectedEmail, resp.GetUser().GetEmail())
+			if tt.expectedCode == codes.OK {
+				assert.Empty(t, resp.GetUsers())
 			} else {
-				assert.Error(t, err)
-				assert.ErrorIs(t, err, tt.expectedError)
+				assert.ErrorIs(t, err, plugin.ErrGetUsersForGroup)
 			}
 		})
 	}
 }
 
-func TestGetGroupsForUser(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		bodyBytes, err := io.ReadAll(r.Body)
-		assert.NoError(t, err)
+func TestClientCallOpLabels(t *testing.T) {
+	server := scimtest.NewServer()
+	server.Close()
 
-		// Quick and dirty mock server filtering. Fine since we aren't testing server here
-		reqStr := string(bodyBytes)
-		if strings.Contains(reqStr, NonExistentField) {
-			_, err = w.Write([]byte(EmptyResponse))
-		} else {
-			_, err = w.Write([]byte(ListGroupsResponse))
-		}
+	tests := []struct {
+		name          string
+		verifyGroup   bool
+		expectedLabel string
+	}{
+		{name: "Group verification", verifyGroup: true, expectedLabel: "GetUsersForGroup: " + plugin.ErrGetUsersForGroup.Error() + ": scim.GetGroup: "},
+		{name: "User list", expectedLabel: "GetUsersForGroup: " + plugin.ErrGetUsersForGroup.Error() + ": scim.ListUsers: "},
+	}
 
-		assert.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "groups.value", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = true
+				params.VerifyGroupExists = tt.verifyGroup
+			})
+
+			_, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+			assert.ErrorIs(t, err, plugin.ErrGetUsersForGroup)
+			assert.ErrorContains(t, err, tt.expectedLabel)
+		})
+	}
+}
 
-		w.WriteHeader(http.StatusOK)
-	}))
+func TestEmptyFilterPolicy(t *testing.T) {
+	server := scimtest.NewServer()
 	defer server.Close()
 
+	meta := scim.Meta{LastModified: "2024-01-01T00:00:00Z"}
+
+	server.AddGroups(scim.Group{BaseResource: scim.BaseResource{ID: "group1", Meta: meta}, DisplayName: "KeyAdmin"})
+	server.AddUsers(scim.User{
+		BaseResource: scim.BaseResource{ID: "user1", Meta: meta},
+		UserName:     "user1",
+		Groups:       []scim.MultiValuedAttribute{{Value: "group1"}},
+	})
+
 	tests := []struct {
-		name                string
-		serverUrl           string
-		userFilterAttribute string
-		userFilterValue     string
-		testNumGroups       int
-		testGroupId         string
-		testGroupName       string
-		testExpectedError   *error
+		name         string
+		policy       plugin.EmptyFilterPolicy
+		expectedCode codes.Code
+	}{
+		{name: "Reject", policy: plugin.EmptyFilterReject, expectedCode: codes.InvalidArgument},
+		{name: "Fetch all", policy: plugin.EmptyFilterFetchAll, expectedCode: codes.OK},
+		{name: "Unset", expectedCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "groups.value", "id")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				if tt.policy != "" {
+					params.EmptyFilterPolicies = map[string]plugin.EmptyFilterPolicy{
+						"GetGroup":         tt.policy,
+						"GetGroupsForUser": tt.policy,
+						"GetUsersForGroup": tt.policy,
+					}
+				}
+			})
+
+			groupResp, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+
+			groupsResp, err := p.GetGroupsForUser(t.Context(), &idmangv1.GetGroupsForUserRequest{})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+
+			usersResp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, "group1", groupResp.GetGroup().GetId())
+				assert.Len(t, groupsResp.GetGroups(), 1)
+				assert.Len(t, usersResp.GetUsers(), 1)
+			} else {
+				assert.ErrorIs(t, err, plugin.ErrNoID)
+			}
+		})
+	}
+}
+
+func TestMaxGroupMembers(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(
+		scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"},
+		scim.User{BaseResource: scim.BaseResource{ID: "user2"}, UserName: "user2"},
+		scim.User{BaseResource: scim.BaseResource{ID: "user3"}, UserName: "user3"},
+	)
+	server.AddGroups(scim.Group{
+		BaseResource: scim.BaseResource{ID: "group1"},
+		DisplayName:  "KeyAdmin",
+		Members:      []scim.MultiValuedAttribute{{Value: "user1"}, {Value: "user2"}, {Value: "user3"}},
+	})
+
+	tests := []struct {
+		name          string
+		maxMembers    int
+		truncate      bool
+		expectedUsers int
+		expectedCode  codes.Code
+	}{
+		{name: "Unlimited", maxMembers: 0, expectedUsers: 3, expectedCode: codes.OK},
+		{name: "Cap above size", maxMembers: 4, expectedUsers: 3, expectedCode: codes.OK},
+		{name: "Cap at size", maxMembers: 3, expectedUsers: 3, expectedCode: codes.OK},
+		{name: "Cap below size", maxMembers: 2, expectedCode: codes.ResourceExhausted},
+		{name: "Cap below size truncated", maxMembers: 2, truncate: true, expectedUsers: 2, expectedCode: codes.OK},
+		{name: "Cap at size truncated", maxMembers: 3, truncate: true, expectedUsers: 3, expectedCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = false
+				params.GroupMembersAttribute = "members"
+				params.MaxGroupMembers = tt.maxMembers
+				params.TruncateLargeGroups = tt.truncate
+			})
+
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+
+			if tt.expectedCode == codes.OK {
+				assert.Len(t, resp.GetUsers(), tt.expectedUsers)
+			} else {
+				assert.ErrorIs(t, err, plugin.ErrGroupTooLarge)
+			}
+		})
+	}
+}
+
+func TestGroupReadsExcludeMembers(t *testing.T) {
+	tests := []struct {
+		name                       string
+		call                       func(p *plugin.Plugin) error
+		expectedAttributes         string
+		expectedExcludedAttributes string
 	}{
 		{
-			name:                "Bad Server",
-			serverUrl:           "badurl",
-			userFilterAttribute: "displayName",
-			userFilterValue:     "None",
-			testNumGroups:       0,
-			testGroupId:         "",
-			testGroupName:       "",
-			testExpectedError:   &scim.ErrListGroups,
+			name: "GetGroup",
+			call: func(p *plugin.Plugin) error {
+				_, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{GroupName: "KeyAdmin"})
+				return err
+			},
+			expectedExcludedAttributes: "members",
 		},
 		{
-			name:                "Good request",
-			serverUrl:           server.URL,
-			userFilterAttribute: "displayName",
-			userFilterValue:     "None",
-			testNumGroups:       1,
-			testGroupId:         "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa",
-			testGroupName:       "KeyAdmin",
-			testExpectedError:   nil,
+			name: "GetAllGroups",
+			call: func(p *plugin.Plugin) error {
+				_, err := p.GetAllGroups(t.Context(), &idmangv1.GetAllGroupsRequest{})
+				return err
+			},
+			expectedExcludedAttributes: "members",
 		},
 		{
-			name:                "Non-existent filter value",
-			serverUrl:           server.URL,
-			userFilterAttribute: "displayName",
-			userFilterValue:     NonExistentField,
-			testNumGroups:       0,
-			testGroupId:         "",
-			testGroupName:       "",
-			testExpectedError:   nil,
+			name: "GetGroupsForUser",
+			call: func(p *plugin.Plugin) error {
+				_, err := p.GetGroupsForUser(t.Context(), &idmangv1.GetGroupsForUserRequest{UserId: "user1"})
+				return err
+			},
+			expectedExcludedAttributes: "members",
 		},
 		{
-			name:                "Non-existent filter attribute",
-			serverUrl:           server.URL,
-			userFilterAttribute: NonExistentField,
-			userFilterValue:     "None",
-			testNumGroups:       0,
-			testGroupId:         "",
-			testGroupName:       "",
-			testExpectedError:   nil,
+			name: "GetUsersForGroup using group members",
+			call: func(p *plugin.Plugin) error {
+				p.UpdateTestParams(func(params *plugin.Params) {
+					params.AllowSearchUsersByGroup = false
+					params.GroupMembersAttribute = "members"
+				})
+
+				_, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+
+				return err
+			},
+			expectedAttributes: "members",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			p := setupTest(t, tt.serverUrl, "", tt.userFilterAttribute)
+			server := newMembersServer(memberRefs("user1"), "user1")
+			defer server.Close()
 
-			userFilterValue := idmangv1.GetGroupsForUserRequest{}
-			if tt.userFilterValue != "" {
-				userFilterValue.UserId = tt.userFilterValue
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.ListMethod = scim.ListMethodGet
+			})
+
+			assert.NoError(t, tt.call(p))
+
+			for _, request := range server.Requests() {
+				if strings.HasPrefix(request.Path, scim.BasePathGroups) {
+					assert.Equal(t, tt.expectedAttributes, request.Query.Get("attributes"))
+					assert.Equal(t, tt.expectedExcludedAttributes, request.Query.Get("excludedAttributes"))
+				}
 			}
+		})
+	}
+}
 
-			responseMsg, err := p.GetGroupsForUser(t.Context(),
-				&userFilterValue)
+func TestGroupMemberProjection(t *testing.T) {
+	server := newMembersServer(memberRefs(analystUser.ID))
+	defer server.Close()
 
-			if tt.testExpectedError == nil {
-				assert.NoError(t, err)
-				assert.Len(t, responseMsg.GetGroups(), tt.testNumGroups)
+	server.AddUsers(analystUser)
 
-				if tt.testNumGroups > 0 {
-					assert.Equal(
-						t,
-						&idmangv1.GetGroupsForUserResponse{
-							Groups: []*idmangv1.Group{{
-								Id:   tt.testGroupId,
-								Name: tt.testGroupName,
-							}},
-						},
-						responseMsg,
-					)
+	p := setupTest(t, server.URL, "", "")
+	p.UpdateTestParams(func(params *plugin.Params) {
+		params.AllowSearchUsersByGroup = false
+		params.GroupMembersAttribute = "members"
+	})
+
+	resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetUsers(), 1)
+	assert.NotEmpty(t, resp.GetUsers()[0].GetEmail())
+
+	for _, request := range server.Requests() {
+		if strings.HasPrefix(request.Path, scim.BasePathUsers+"/") {
+			assert.Equal(t, "id,userName,emails", request.Query.Get("attributes"))
+		}
+	}
+}
+
+func TestUserListRequestsEmails(t *testing.T) {
+	tests := []struct {
+		name   string
+		method scim.ListMethod
+	}{
+		{name: "GET", method: scim.ListMethodGet},
+		{name: "POST", method: scim.ListMethodPost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := scimtest.NewServer()
+			defer server.Close()
+
+			server.AddUsers(analystUser)
+
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = true
+				params.GroupAttribute = "groups.value"
+				params.ListMethod = tt.method
+			})
+
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: keyAdminGroup.ID})
+			assert.NoError(t, err)
+
+			if assert.Len(t, resp.GetUsers(), 1) {
+				assert.Equal(t, "cloud.analyst@example.com", resp.GetUsers()[0].GetEmail())
+			}
+
+			// The server returns only the attributes requested
+			requests := server.Requests()
+			if assert.Len(t, requests, 1) {
+				attributes := requests[0].Query.Get("attributes")
+				if requests[0].Method == http.MethodPost {
+					var body struct {
+						Attributes []string `json:"attributes"`
+					}
+
+					assert.NoError(t, json.Unmarshal(requests[0].Body, &body))
+
+					attributes = strings.Join(body.Attributes, ",")
 				}
-			} else {
-				assert.ErrorIs(t, err, *tt.testExpectedError)
+
+				assert.Contains(t, strings.Split(attributes, ","), "emails")
 			}
 		})
 	}
 }
 
-func TestNewPlugin(t *testing.T) {
-	p := setupTest(t, "", "", "")
-	assert.NotNil(t, p)
+func TestMemberTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		memberTimeout time.Duration
+		skip          bool
+		expectedIDs   []string
+		expectedCode  codes.Code
+	}{
+		{
+			name:          "Skip slow member",
+			memberTimeout: 50 * time.Millisecond,
+			skip:          true,
+			expectedIDs:   []string{"user1", "user3"},
+		},
+		{
+			name:          "Fail on slow member",
+			memberTimeout: 50 * time.Millisecond,
+			expectedCode:  codes.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMembersServer(memberRefs("user1", "user2", "user3"), "user1", "user2", "user3")
+			defer server.Close()
+
+			server.OnRequest(func(_ http.ResponseWriter, r *http.Request) bool {
+				if r.URL.Path != "/Users/user2" {
+					return false
+				}
+
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+
+				return true
+			})
+
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = false
+				params.GroupMembersAttribute = "members"
+				params.MemberTimeout = tt.memberTimeout
+				params.SkipTimedOutMembers = tt.skip
+			})
+
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+			if tt.expectedCode != codes.OK {
+				assert.Equal(t, tt.expectedCode, status.Code(err))
+				assert.ErrorIs(t, err, plugin.ErrMemberTimedOut)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			ids := make([]string, len(resp.GetUsers()))
+			for i, user := range resp.GetUsers() {
+				ids[i] = user.GetId()
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestPartialMemberResults(t *testing.T) {
+	tests := []struct {
+		name         string
+		partial      bool
+		members      []string
+		expectedIDs  []string
+		expectedCode codes.Code
+	}{
+		{
+			name:         "Fail on missing member",
+			members:      []string{"user1", "deleted", "user3"},
+			expectedCode: codes.NotFound,
+		},
+		{
+			name:        "Skip missing members",
+			partial:     true,
+			members:     []string{"user1", "deleted", "user3", "forbidden"},
+			expectedIDs: []string{"user1", "user3"},
+		},
+		{
+			name:         "Fail if every member fails",
+			partial:      true,
+			members:      []string{"forbidden", "deleted"},
+			expectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The deleted member is not seeded, so the server answers 404
+			server := newMembersServer(memberRefs(tt.members...), "user1", "user3", "forbidden")
+			defer server.Close()
+
+			server.OnRequest(func(w http.ResponseWriter, r *http.Request) bool {
+				if r.URL.Path != "/Users/forbidden" {
+					return false
+				}
+
+				w.WriteHeader(http.StatusForbidden)
+
+				return true
+			})
+
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = false
+				params.GroupMembersAttribute = "members"
+				params.PartialMemberResults = tt.partial
+			})
+
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+			if tt.expectedCode != codes.OK {
+				assert.Equal(t, tt.expectedCode, status.Code(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			ids := make([]string, len(resp.GetUsers()))
+			for i, user := range resp.GetUsers() {
+				ids[i] = user.GetId()
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+		})
+	}
+}
+
+func TestMemberIDsOnly(t *testing.T) {
+	tests := []struct {
+		name                    string
+		memberIDsOnly           bool
+		allowSearchUsersByGroup bool
+		maxMembers              int
+		expectedIDs             []string
+		expectedUserRequests    int32
+	}{
+		{
+			name:                 "Resolve users",
+			expectedIDs:          []string{"user1", "user2", "user3"},
+			expectedUserRequests: 3,
+		},
+		{
+			name:          "IDs only",
+			memberIDsOnly: true,
+			expectedIDs:   []string{"user1", "user2", "user3"},
+		},
+		{
+			name:                    "IDs only over user search",
+			memberIDsOnly:           true,
+			allowSearchUsersByGroup: true,
+			expectedIDs:             []string{"user1", "user2", "user3"},
+		},
+		{
+			name:          "IDs only truncated",
+			memberIDsOnly: true,
+			maxMembers:    2,
+			expectedIDs:   []string{"user1", "user2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMembersServer(memberRefs("user1", "user2", "user3"), "user1", "user2", "user3")
+			defer server.Close()
+
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = tt.allowSearchUsersByGroup
+				params.GroupMembersAttribute = "members"
+				params.MemberIDsOnly = tt.memberIDsOnly
+				params.MaxGroupMembers = tt.maxMembers
+				params.TruncateLargeGroups = true
+			})
+
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+			assert.NoError(t, err)
+
+			ids := make([]string, len(resp.GetUsers()))
+			for i, user := range resp.GetUsers() {
+				ids[i] = user.GetId()
+			}
+
+			assert.Equal(t, tt.expectedIDs, ids)
+			assert.Equal(t, int32(1), countRequests(server, "/Groups/group1"))
+			assert.Equal(t, int32(1), countRequests(server, scim.BasePathGroups))
+			assert.Equal(t, tt.expectedUserRequests, countRequests(server, scim.BasePathUsers+"/"))
+		})
+	}
+}
+
+func TestUseMemberDisplay(t *testing.T) {
+	tests := []struct {
+		name                 string
+		useMemberDisplay     bool
+		expectedUsers        []*idmangv1.User
+		expectedUserRequests int32
+	}{
+		{
+			name: "Resolve users",
+			expectedUsers: []*idmangv1.User{
+				{Id: "user1", Name: "user1", Email: "user1@example.com"},
+				{Id: "user2", Name: "user2", Email: "user2@example.com"},
+			},
+			expectedUserRequests: 2,
+		},
+		{
+			name:             "Display used if present",
+			useMemberDisplay: true,
+			expectedUsers: []*idmangv1.User{
+				{Id: "user1", Name: "Alice"},
+				{Id: "user2", Name: "user2", Email: "user2@example.com"},
+			},
+			expectedUserRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newMembersServer([]scim.MultiValuedAttribute{{Value: "user1", Display: "Alice"}, {Value: "user2"}})
+			defer server.Close()
+
+			for _, id := range []string{"user1", "user2"} {
+				server.AddUsers(scim.User{
+					BaseResource: scim.BaseResource{ID: id},
+					UserName:     id,
+					Emails:       []scim.MultiValuedAttribute{{Value: id + "@example.com"}},
+				})
+			}
+
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = false
+				params.GroupMembersAttribute = "members"
+				params.UseMemberDisplay = tt.useMemberDisplay
+			})
+
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+			assert.NoError(t, err)
+
+			assert.Len(t, resp.GetUsers(), len(tt.expectedUsers))
+
+			for i, user := range resp.GetUsers() {
+				assert.Equal(t, tt.expectedUsers[i].GetId(), user.GetId())
+				assert.Equal(t, tt.expectedUsers[i].GetName(), user.GetName())
+				assert.Equal(t, tt.expectedUsers[i].GetEmail(), user.GetEmail())
+			}
+
+			assert.Equal(t, tt.expectedUserRequests, countRequests(server, scim.BasePathUsers+"/"))
+		})
+	}
+}
+
+func TestGetGroup(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddGroups(
+		scim.Group{BaseResource: scim.BaseResource{ID: "group1"}, DisplayName: "KeyAdmin"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group2"}, DisplayName: "Auditor"},
+	)
+
+	tests := []struct {
+		name          string
+		groupName     string
+		expectedGroup *idmangv1.Group
+		expectedError error
+	}{
+		{
+			name:          "Group found",
+			groupName:     "KeyAdmin",
+			expectedGroup: &idmangv1.Group{Id: "group1", Name: "KeyAdmin"},
+		},
+		{
+			name:          "Group not found",
+			groupName:     "Unknown",
+			expectedError: plugin.ErrGetGroupNonExistent,
+		},
+		{
+			name:          "No group name",
+			groupName:     "",
+			expectedError: plugin.ErrNoID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "", "")
+
+			resp, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{GroupName: tt.groupName})
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedGroup, resp.GetGroup())
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestMultipleMatchPolicy(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddGroups(
+		scim.Group{BaseResource: scim.BaseResource{ID: "group1"}, DisplayName: "admin"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group2"}, DisplayName: "Admin"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group3"}, DisplayName: "Ops"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group4"}, DisplayName: "Ops"},
+	)
+
+	tests := []struct {
+		name            string
+		policy          plugin.MultipleMatchPolicy
+		groupName       string
+		expectedGroupID string
+		expectedErr     error
+	}{
+		{name: "Error", policy: plugin.MultipleMatchError, groupName: "Admin", expectedErr: plugin.ErrGetGroupMultipleGroups},
+		{name: "First", policy: plugin.MultipleMatchFirst, groupName: "Admin", expectedGroupID: "group1"},
+		{name: "First with exact duplicates", policy: plugin.MultipleMatchFirst, groupName: "Ops", expectedGroupID: "group3"},
+		{name: "Exact case", policy: plugin.MultipleMatchExactCase, groupName: "Admin", expectedGroupID: "group2"},
+		{name: "Exact case lower", policy: plugin.MultipleMatchExactCase, groupName: "admin", expectedGroupID: "group1"},
+		{name: "Exact case with exact duplicates", policy: plugin.MultipleMatchExactCase, groupName: "Ops", expectedErr: plugin.ErrGetGroupMultipleGroups},
+		{name: "Exact case without exact match", policy: plugin.MultipleMatchExactCase, groupName: "ADMIN", expectedErr: plugin.ErrGetGroupMultipleGroups},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.MultipleMatchPolicy = tt.policy
+			})
+
+			resp, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{GroupName: tt.groupName})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedGroupID, resp.GetGroup().GetId())
+		})
+	}
+}
+
+func TestResolveGroupName(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(
+		scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "alice"},
+		scim.User{BaseResource: scim.BaseResource{ID: "user2"}, UserName: "bob"},
+	)
+	server.AddGroups(
+		scim.Group{
+			BaseResource: scim.BaseResource{ID: "group1"},
+			DisplayName:  "KeyAdmin",
+			Members:      []scim.MultiValuedAttribute{{Value: "user1"}, {Value: "user2"}},
+		},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group2"}, DisplayName: "Ops"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group3"}, DisplayName: "Ops"},
+	)
+
+	tests := []struct {
+		name             string
+		resolveGroupName bool
+		groupID          string
+		expectedUsers    []string
+		expectedError    error
+	}{
+		{
+			name:             "Name resolved to members",
+			resolveGroupName: true,
+			groupID:          "KeyAdmin",
+			expectedUsers:    []string{"alice", "bob"},
+		},
+		{
+			name:          "ID used as is",
+			groupID:       "group1",
+			expectedUsers: []string{"alice", "bob"},
+		},
+		{
+			name:             "Group not found",
+			resolveGroupName: true,
+			groupID:          "Unknown",
+			expectedError:    plugin.ErrGetGroupNonExistent,
+		},
+		{
+			name:             "Ambiguous name",
+			resolveGroupName: true,
+			groupID:          "Ops",
+			expectedError:    plugin.ErrGetGroupMultipleGroups,
+		},
+		{
+			name:             "ID not resolved as a name",
+			resolveGroupName: true,
+			groupID:          "group1",
+			expectedError:    plugin.ErrGetGroupNonExistent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AllowSearchUsersByGroup = false
+				params.GroupMembersAttribute = "members"
+				params.ResolveGroupName = tt.resolveGroupName
+			})
+
+			resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: tt.groupID})
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			names := make([]string, 0, len(resp.GetUsers()))
+			for _, user := range resp.GetUsers() {
+				names = append(names, user.GetName())
+			}
+
+			assert.Equal(t, tt.expectedUsers, names)
+		})
+	}
+}
+
+func TestExactGroupNameMatch(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddGroups(
+		scim.Group{BaseResource: scim.BaseResource{ID: "group1"}, DisplayName: "admin"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group2"}, DisplayName: "Admin"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group3"}, DisplayName: "OPS"},
+	)
+
+	tests := []struct {
+		name            string
+		exact           bool
+		groupName       string
+		expectedGroupID string
+		expectedErr     error
+	}{
+		{name: "Disabled with case variants", groupName: "Admin", expectedErr: plugin.ErrGetGroupMultipleGroups},
+		{name: "Disabled with single case variant", groupName: "Ops", expectedGroupID: "group3"},
+		{name: "Enabled with case variants", exact: true, groupName: "Admin", expectedGroupID: "group2"},
+		{name: "Enabled with lower case variant", exact: true, groupName: "admin", expectedGroupID: "group1"},
+		{name: "Enabled with single case variant", exact: true, groupName: "Ops", expectedErr: plugin.ErrGetGroupNonExistent},
+		{name: "Enabled with exact match", exact: true, groupName: "OPS", expectedGroupID: "group3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.ExactGroupNameMatch = tt.exact
+			})
+
+			resp, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{GroupName: tt.groupName})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedGroupID, resp.GetGroup().GetId())
+		})
+	}
+}
+
+func TestAttributeFallbackChain(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddGroups(scim.Group{
+		BaseResource: scim.BaseResource{ID: "group1"},
+		DisplayName:  "KeyAdmin",
+		Members:      []scim.MultiValuedAttribute{{Value: "user1"}},
+	})
+	server.AddUsers(scim.User{
+		BaseResource: scim.BaseResource{ID: "user1"},
+		UserName:     "user1",
+		Groups:       []scim.MultiValuedAttribute{{Value: "group1", Display: "KeyAdmin"}},
+	})
+
+	t.Run("GetGroup", func(t *testing.T) {
+		p := setupTest(t, server.URL, "externalId, displayName", "")
+
+		resp, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{GroupName: "KeyAdmin"})
+		assert.NoError(t, err)
+		assert.Equal(t, &idmangv1.Group{Id: "group1", Name: "KeyAdmin"}, resp.GetGroup())
+	})
+
+	t.Run("GetGroupsForUser", func(t *testing.T) {
+		p := setupTest(t, server.URL, "", "externalId,members.value")
+
+		resp, err := p.GetGroupsForUser(t.Context(), &idmangv1.GetGroupsForUserRequest{UserId: "user1"})
+		assert.NoError(t, err)
+		assert.Equal(t, []*idmangv1.Group{{Id: "group1", Name: "KeyAdmin"}}, resp.GetGroups())
+	})
+
+	t.Run("GetUsersForGroup", func(t *testing.T) {
+		p := setupTest(t, server.URL, "externalId,groups.value", "")
+
+		resp, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+		assert.NoError(t, err)
+		assert.Equal(t, []*idmangv1.User{{Id: "user1", Name: "user1"}}, resp.GetUsers())
+	})
+
+	t.Run("No candidate matches", func(t *testing.T) {
+		p := setupTest(t, server.URL, "externalId,userName", "")
+
+		_, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{GroupName: "KeyAdmin"})
+		assert.ErrorIs(t, err, plugin.ErrGetGroupNonExistent)
+	})
+}
+
+func TestGetUser(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(analystUser)
+
+	tests := []struct {
+		name          string
+		serverUrl     string
+		userId        string
+		expectedID    string
+		expectedName  string
+		expectedEmail string
+		expectedError error
+	}{
+		{
+			name:          "User found",
+			serverUrl:     server.URL,
+			userId:        "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+			expectedID:    "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+			expectedName:  "cloudanalyst",
+			expectedEmail: "cloud.analyst@example.com",
+			expectedError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, tt.serverUrl, "", "")
+
+			resp, err := p.GetUser(
+				t.Context(),
+				&idmangv1.GetUserRequest{
+					UserId: tt.userId,
+				},
+			)
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+				assert.Equal(t, tt.expectedID, resp.GetUser().GetId())
+				assert.Equal(t, tt.expectedName, resp.GetUser().GetName())
+				assert.Equal(t, tt.expectedEmail, resp.GetUser().GetEmail())
+			} else {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestGetUserEmailSelection(t *testing.T) {
+	tests := []struct {
+		name            string
+		emails          []scim.MultiValuedAttribute
+		expectedEmail   string
+		expectedWarning bool
+	}{
+		{
+			name: "Primary email preferred over work email",
+			emails: []scim.MultiValuedAttribute{
+				{Value: "home@example.com", Type: "home"},
+				{Value: "work@example.com", Type: "work"},
+				{Value: "primary@example.com", Type: "other", Primary: true},
+			},
+			expectedEmail: "primary@example.com",
+		},
+		{
+			name: "First of multiple primary emails used",
+			emails: []scim.MultiValuedAttribute{
+				{Value: "work@example.com", Type: "work"},
+				{Value: "first@example.com", Type: "other", Primary: true},
+				{Value: "second@example.com", Type: "work", Primary: true},
+			},
+			expectedEmail:   "first@example.com",
+			expectedWarning: true,
+		},
+		{
+			name: "First of multiple primary emails used regardless of type",
+			emails: []scim.MultiValuedAttribute{
+				{Value: "first@example.com", Type: "home", Primary: true},
+				{Value: "second@example.com", Type: "work", Primary: true},
+			},
+			expectedEmail:   "first@example.com",
+			expectedWarning: true,
+		},
+		{
+			name: "Empty primary email ignored",
+			emails: []scim.MultiValuedAttribute{
+				{Value: "", Primary: true},
+				{Value: "primary@example.com", Primary: true},
+			},
+			expectedEmail: "primary@example.com",
+		},
+		{
+			name: "Work email preferred when no primary is set",
+			emails: []scim.MultiValuedAttribute{
+				{Value: "home@example.com", Type: "home"},
+				{Value: "work@example.com", Type: "work"},
+			},
+			expectedEmail: "work@example.com",
+		},
+		{
+			name: "Work email type matched case-insensitively",
+			emails: []scim.MultiValuedAttribute{
+				{Value: "home@example.com", Type: "Home"},
+				{Value: "work@example.com", Type: "Work"},
+			},
+			expectedEmail: "work@example.com",
+		},
+		{
+			name: "First email used when no primary or work email is set",
+			emails: []scim.MultiValuedAttribute{
+				{Value: "home@example.com", Type: "home"},
+				{Value: "other@example.com", Type: "other"},
+			},
+			expectedEmail: "home@example.com",
+		},
+		{
+			name:          "No emails",
+			expectedEmail: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := scimtest.NewServer()
+			defer server.Close()
+
+			server.AddUsers(scim.User{
+				BaseResource: scim.BaseResource{ID: "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"},
+				UserName:     "cloudanalyst",
+				Emails:       tt.emails,
+			})
+
+			p := setupTest(t, server.URL, "", "")
+
+			var logs strings.Builder
+
+			p.SetLogger(hclog.New(&hclog.LoggerOptions{Output: &logs, Level: hclog.Warn}))
+
+			resp, err := p.GetUser(
+				t.Context(),
+				&idmangv1.GetUserRequest{
+					UserId: "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+				},
+			)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedEmail, resp.GetUser().GetEmail())
+			assert.Equal(t, tt.expectedWarning, strings.Contains(logs.String(), "multiple primary emails"))
+		})
+	}
+}
+
+func TestGetGroupsForUser(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddGroups(keyAdminGroup)
+
+	tests := []struct {
+		name                string
+		serverUrl           string
+		userFilterAttribute string
+		userFilterValue     string
+		testNumGroups       int
+		testGroupId         string
+		testGroupName       string
+		testExpectedError   *error
+	}{
+		{
+			name:                "Bad Server",
+			serverUrl:           "badurl",
+			userFilterAttribute: "displayName",
+			userFilterValue:     "None",
+			testNumGroups:       0,
+			testGroupId:         "",
+			testGroupName:       "",
+			testExpectedError:   &scim.ErrListGroups,
+		},
+		{
+			name:                "Good request",
+			serverUrl:           server.URL,
+			userFilterAttribute: "members.value",
+			userFilterValue:     analystUser.ID,
+			testNumGroups:       1,
+			testGroupId:         "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa",
+			testGroupName:       "KeyAdmin",
+			testExpectedError:   nil,
+		},
+		{
+			name:                "Non-existent filter value",
+			serverUrl:           server.URL,
+			userFilterAttribute: "members.value",
+			userFilterValue:     NonExistentField,
+			testNumGroups:       0,
+			testGroupId:         "",
+			testGroupName:       "",
+			testExpectedError:   nil,
+		},
+		{
+			name:                "Non-existent filter attribute",
+			serverUrl:           server.URL,
+			userFilterAttribute: NonExistentField,
+			userFilterValue:     analystUser.ID,
+			testNumGroups:       0,
+			testGroupId:         "",
+			testGroupName:       "",
+			testExpectedError:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, tt.serverUrl, "", tt.userFilterAttribute)
+
+			userFilterValue := idmangv1.GetGroupsForUserRequest{}
+			if tt.userFilterValue != "" {
+				userFilterValue.UserId = tt.userFilterValue
+			}
+
+			responseMsg, err := p.GetGroupsForUser(t.Context(),
+				&userFilterValue)
+
+			if tt.testExpectedError == nil {
+				assert.NoError(t, err)
+				assert.Len(t, responseMsg.GetGroups(), tt.testNumGroups)
+
+				if tt.testNumGroups > 0 {
+					assert.Equal(
+						t,
+						&idmangv1.GetGroupsForUserResponse{
+							Groups: []*idmangv1.Group{{
+								Id:   tt.testGroupId,
+								Name: tt.testGroupName,
+							}},
+						},
+						responseMsg,
+					)
+				}
+			} else {
+				assert.ErrorIs(t, err, *tt.testExpectedError)
+			}
+		})
+	}
+}
+
+func TestGetUsersForGroupRetryBudget(t *testing.T) {
+	const (
+		members     = 5
+		maxRetries  = 3
+		retryBudget = 2
+	)
+
+	ids := make([]string, members)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("user%d", i)
+	}
+
+	server := newMembersServer(memberRefs(ids...), ids...)
+	defer server.Close()
+
+	server.OnRequest(func(w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasPrefix(r.URL.Path, scim.BasePathUsers) {
+			return false
+		}
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		return true
+	})
+
+	p := plugin.NewPlugin(buildInfo)
+	p.SetTestClient(t, server.URL, "", "", scim.WithRetries(maxRetries, time.Millisecond))
+	p.UpdateTestParams(func(params *plugin.Params) {
+		params.AllowSearchUsersByGroup = false
+		params.RetryBudget = retryBudget
+	})
+
+	_, err := p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{GroupId: "group1"})
+	assert.ErrorIs(t, err, plugin.ErrGetUsersForGroup)
+
+	// Each member is requested at most once plus the shared retry budget
+	userRequests := countRequests(server, scim.BasePathUsers)
+	assert.LessOrEqual(t, int(userRequests), members+retryBudget)
+	assert.Equal(t, 1+retryBudget, int(userRequests))
+}
+
+func getTestConfiguration(host, listMethod string) string {
+	return `
+host:
+  source: embedded
+  value: ` + host + `
+auth:
+  type: basic
+  basic:
+    username:
+      source: embedded
+      value: user
+    password:
+      source: embedded
+      value: secret
+authContext:
+  source: embedded
+  value: "{}"
+params:
+  groupAttribute:
+    source: embedded
+    value: displayName
+  userAttribute:
+    source: embedded
+    value: userName
+  groupMembersAttribute:
+    source: embedded
+    value: members
+  listMethod:
+    source: embedded
+    value: "` + listMethod + `"
+  allowSearchUsersByGroup:
+    source: embedded
+    value: "true"
+`
+}
+
+func TestConfigureListMethod(t *testing.T) {
+	tests := []struct {
+		name          string
+		listMethod    string
+		expectedError error
+	}{
+		{name: "GET", listMethod: "GET"},
+		{name: "POST", listMethod: "POST"},
+		{name: "Lowercase", listMethod: "post"},
+		{name: "Default", listMethod: ""},
+		{name: "Unknown method", listMethod: "Search", expectedError: scim.ErrInvalidListMethod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+				YamlConfiguration: getTestConfiguration("https://scim.example.com", tt.listMethod),
+			})
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestConfigureEmptyFilterPolicies(t *testing.T) {
+	tests := []struct {
+		name          string
+		policies      string
+		expectedError error
+	}{
+		{name: "Valid", policies: "{GetUsersForGroup: fetchAll, GetGroup: reject}"},
+		{name: "Unknown policy", policies: "{GetGroup: all}", expectedError: plugin.ErrInvalidEmptyFilterPolicy},
+		{name: "Unknown method", policies: "{GetUser: fetchAll}", expectedError: plugin.ErrInvalidEmptyFilterPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+				YamlConfiguration: getTestConfiguration("https://scim.example.com", "GET") + `  emptyFilterPolicies:
+    source: embedded
+    value: "` + tt.policies + `"
+`,
+			})
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestConfigureMultipleMatchPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		expectedError error
+	}{
+		{name: "Error", policy: "error"},
+		{name: "First", policy: "first"},
+		{name: "Exact case", policy: "exactCase"},
+		{name: "Unknown policy", policy: "last", expectedError: plugin.ErrInvalidMultipleMatchPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+				YamlConfiguration: getTestConfiguration("https://scim.example.com", "GET") + `  multipleMatchPolicy:
+    source: embedded
+    value: ` + tt.policy + `
+`,
+			})
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestConfigurePaginationParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		names       string
+		expected    string
+		expectError bool
+	}{
+		{name: "Custom", names: "{count: limit, cursor: next}", expected: "{limit startIndex next}"},
+		{name: "Invalid", names: "[limit", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+				YamlConfiguration: getTestConfiguration("https://scim.example.com", "GET") + `  paginationParams:
+    source: embedded
+    value: "` + tt.names + `"
+`,
+			})
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Contains(t, p.Describe(), "PaginationParams: "+tt.expected+"\n")
+		})
+	}
+}
+
+func TestConfigureRedirectPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		expectedError error
+	}{
+		{name: "None", policy: "none"},
+		{name: "Same host", policy: "sameHost"},
+		{name: "Unknown policy", policy: "all", expectedError: scim.ErrInvalidRedirectPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+				YamlConfiguration: getTestConfiguration("https://scim.example.com", "GET") + `  redirectPolicy:
+    source: embedded
+    value: ` + tt.policy + `
+`,
+			})
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Contains(t, p.Describe(), "RedirectPolicy: "+tt.policy+"\n")
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestConfigureUserAgent(t *testing.T) {
+	const testBuildInfo = `{"version": "1.2.3"}`
+
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"})
+
+	p := plugin.NewPlugin(testBuildInfo)
+	p.SetLogger(hclog.NewNullLogger())
+
+	resp, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+		YamlConfiguration: getTestConfiguration(server.URL, "GET"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, testBuildInfo, resp.GetBuildInfo())
+
+	_, err = p.GetUser(t.Context(), &idmangv1.GetUserRequest{UserId: "user1"})
+	assert.NoError(t, err)
+
+	requests := server.Requests()
+	if assert.Len(t, requests, 1) {
+		assert.Equal(t, scim.DefaultUserAgent+" ("+testBuildInfo+")", requests[0].Header.Get("User-Agent"))
+	}
+}
+
+func TestConfigureConcurrentWithRPCs(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"})
+
+	p := plugin.NewPlugin(buildInfo)
+	p.SetLogger(hclog.NewNullLogger())
+
+	configureRequest := &configv1.ConfigureRequest{YamlConfiguration: getTestConfiguration(server.URL, "GET")}
+
+	_, err := p.Configure(t.Context(), configureRequest)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Go(func() {
+			for range 20 {
+				resp, err := p.GetUser(t.Context(), &idmangv1.GetUserRequest{UserId: "user1"})
+				assert.NoError(t, err)
+				assert.Equal(t, "user1", resp.GetUser().GetName())
+			}
+		})
+	}
+
+	wg.Go(func() {
+		for range 20 {
+			_, err := p.Configure(t.Context(), configureRequest)
+			assert.NoError(t, err)
+		}
+	})
+
+	wg.Wait()
+}
+
+func TestTenantPropagation(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"})
+
+	tests := []struct {
+		name           string
+		baseHost       string
+		authContext    map[string]string
+		expectedTenant string
+	}{
+		{
+			name:           "Base host",
+			baseHost:       server.URL,
+			expectedTenant: server.URL,
+		},
+		{
+			name:           "Host from auth context",
+			baseHost:       "https://unused.example.com",
+			authContext:    map[string]string{"host": server.URL},
+			expectedTenant: server.URL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tenants []string
+
+			p := plugin.NewPlugin(buildInfo)
+			p.SetTestClient(t, tt.baseHost, "", "", scim.WithObserver(func(_ context.Context, info scim.RequestInfo) {
+				tenants = append(tenants, info.Tenant)
+			}))
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AuthContext.HostField = "host"
+			})
+
+			_, err := p.GetUser(t.Context(), &idmangv1.GetUserRequest{
+				UserId:      "user1",
+				AuthContext: &idmangv1.AuthContext{Data: tt.authContext},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []string{tt.expectedTenant}, tenants)
+		})
+	}
+}
+
+func TestAuthContextHeaderTemplates(t *testing.T) {
+	tests := []struct {
+		name            string
+		headerField     string
+		authContext     map[string]string
+		expectedHeader  string
+		expectedPresent bool
+	}{
+		{
+			name:            "Bearer template",
+			headerField:     "Bearer {{.accessToken}}",
+			authContext:     map[string]string{"accessToken": "abc123"},
+			expectedHeader:  "Bearer abc123",
+			expectedPresent: true,
+		},
+		{
+			name:            "Plain field",
+			headerField:     "accessToken",
+			authContext:     map[string]string{"accessToken": "abc123"},
+			expectedHeader:  "abc123",
+			expectedPresent: true,
+		},
+		{
+			name:        "Template with missing field",
+			headerField: "Bearer {{.accessToken}}",
+			authContext: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := scimtest.NewServer()
+			defer server.Close()
+
+			server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"})
+
+			header, err := config.ParseHeaderTemplate(tt.headerField)
+			assert.NoError(t, err)
+
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AuthContext.HeaderFields = map[string]config.HeaderTemplate{"X-Forwarded-Token": header}
+			})
+
+			_, err = p.GetUser(t.Context(), &idmangv1.GetUserRequest{
+				UserId:      "user1",
+				AuthContext: &idmangv1.AuthContext{Data: tt.authContext},
+			})
+			assert.NoError(t, err)
+
+			for _, request := range server.Requests() {
+				values, present := request.Header["X-Forwarded-Token"]
+				assert.Equal(t, tt.expectedPresent, present)
+
+				if tt.expectedPresent {
+					assert.Equal(t, []string{tt.expectedHeader}, values)
+				}
+			}
+		})
+	}
+}
+
+func TestRequiredAuthContextFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		required      []string
+		authContext   map[string]string
+		expectedCode  codes.Code
+		expectedCalls int
+	}{
+		{
+			name:          "Required field present",
+			required:      []string{"Authorization"},
+			authContext:   map[string]string{"accessToken": "abc123"},
+			expectedCode:  codes.OK,
+			expectedCalls: 1,
+		},
+		{
+			name:         "Required field missing",
+			required:     []string{"Authorization"},
+			authContext:  map[string]string{"tenantId": "tenant1"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:          "Optional field missing",
+			authContext:   map[string]string{"tenantId": "tenant1"},
+			expectedCode:  codes.OK,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := scimtest.NewServer()
+			defer server.Close()
+
+			server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"})
+
+			header, err := config.ParseHeaderTemplate("Bearer {{.accessToken}}")
+			assert.NoError(t, err)
+
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AuthContext.HeaderFields = map[string]config.HeaderTemplate{"Authorization": header}
+				params.AuthContext.RequiredHeaderFields = tt.required
+			})
+
+			_, err = p.GetUser(t.Context(), &idmangv1.GetUserRequest{
+				UserId:      "user1",
+				AuthContext: &idmangv1.AuthContext{Data: tt.authContext},
+			})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			assert.Len(t, server.Requests(), tt.expectedCalls)
+
+			if tt.expectedCode != codes.OK {
+				assert.ErrorIs(t, err, plugin.ErrMissingAuthContextField)
+			}
+		})
+	}
+}
+
+func TestConfigureRequiredHeaderFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		authContext   string
+		expectedError error
+	}{
+		{
+			name:        "Required header mapped",
+			authContext: `{headerFields: {Authorization: "Bearer {{.accessToken}}"}, requiredHeaderFields: [Authorization]}`,
+		},
+		{
+			name:          "Required header not mapped",
+			authContext:   `{headerFields: {}, requiredHeaderFields: [Authorization]}`,
+			expectedError: plugin.ErrUnmappedRequiredHeader,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			yamlConfig := strings.Replace(getTestConfiguration("https://scim.example.com", "GET"),
+				`value: "{}"`, "value: '"+tt.authContext+"'", 1)
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{YamlConfiguration: yamlConfig})
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestRequireAuthContextHost(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"})
+
+	tests := []struct {
+		name         string
+		require      bool
+		authContext  map[string]string
+		expectedCode codes.Code
+	}{
+		{name: "Permissive with host", authContext: map[string]string{"host": server.URL}, expectedCode: codes.OK},
+		{name: "Permissive without host falls back", expectedCode: codes.OK},
+		{name: "Strict with host", require: true, authContext: map[string]string{"host": server.URL}, expectedCode: codes.OK},
+		{name: "Strict without host", require: true, expectedCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, server.URL, "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AuthContext.HostField = "host"
+				params.RequireAuthContextHost = tt.require
+			})
+
+			_, err := p.GetUser(t.Context(), &idmangv1.GetUserRequest{
+				UserId:      "user1",
+				AuthContext: &idmangv1.AuthContext{Data: tt.authContext},
+			})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+
+			if tt.expectedCode != codes.OK {
+				assert.ErrorIs(t, err, plugin.ErrMissingAuthContextField)
+			}
+		})
+	}
+}
+
+func TestHostFieldCandidates(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "user1"})
+
+	tests := []struct {
+		name         string
+		authContext  map[string]string
+		expectedCode codes.Code
+	}{
+		{
+			name:         "First field supplies host",
+			authContext:  map[string]string{"host": server.URL, "tenantHost": "https://unused.example.com"},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Second field supplies host",
+			authContext:  map[string]string{"host": "", "tenantHost": server.URL},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Third field supplies host",
+			authContext:  map[string]string{"url": server.URL},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "No field supplies host",
+			authContext:  map[string]string{},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := setupTest(t, "https://unused.example.com", "", "")
+			p.UpdateTestParams(func(params *plugin.Params) {
+				params.AuthContext.HostField = "host"
+				params.AuthContext.HostFields = []string{"tenantHost", "url"}
+				params.RequireAuthContextHost = true
+			})
+
+			_, err := p.GetUser(t.Context(), &idmangv1.GetUserRequest{
+				UserId:      "user1",
+				AuthContext: &idmangv1.AuthContext{Data: tt.authContext},
+			})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestNewPlugin(t *testing.T) {
+	p := setupTest(t, "", "", "")
+	assert.NotNil(t, p)
+}
+
+// oauth2Auth is the auth of getTestConfiguration for OAuth2 client credentials.
+const oauth2Auth = `auth:
+  type: oauth2
+  oauth2:
+    url:
+      source: embedded
+      value: https://idp.example.com/token
+    credentials:
+      clientID:
+        source: embedded
+        value: client
+      clientSecret:
+        source: embedded
+        value: secret
+authContext:`
+
+func TestReconfigurePreservesClient(t *testing.T) {
+	basicConfiguration := getTestConfiguration("https://scim.example.com", "GET")
+	oauth2Configuration := basicConfiguration[:strings.Index(basicConfiguration, "auth:")] +
+		oauth2Auth + basicConfiguration[strings.Index(basicConfiguration, "authContext:")+len("authContext:"):]
+
+	tests := []struct {
+		name            string
+		configuration   string
+		reconfiguration string
+		expectPreserved bool
+	}{
+		{
+			name:            "Only params changed",
+			reconfiguration: getTestConfiguration("https://scim.example.com", "POST"),
+			expectPreserved: true,
+		},
+		{
+			name:            "Host changed",
+			reconfiguration: getTestConfiguration("https://other.example.com", "GET"),
+			expectPreserved: true,
+		},
+		{
+			name: "Auth changed",
+			reconfiguration: strings.Replace(getTestConfiguration("https://scim.example.com", "GET"),
+				"value: secret", "value: rotated", 1),
+			expectPreserved: false,
+		},
+		{
+			name: "Client params changed",
+			reconfiguration: getTestConfiguration("https://scim.example.com", "GET") + `  maxRetries:
+    source: embedded
+    value: "3"
+`,
+			expectPreserved: false,
+		},
+		{
+			// The client secret may have been rotated behind the same reference
+			name:            "OAuth2 auth unchanged",
+			configuration:   oauth2Configuration,
+			reconfiguration: oauth2Configuration,
+			expectPreserved: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			configuration := tt.configuration
+			if configuration == "" {
+				configuration = basicConfiguration
+			}
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+				YamlConfiguration: configuration,
+			})
+			assert.NoError(t, err)
+
+			client := p.TestClient()
+
+			_, err = p.Configure(t.Context(), &configv1.ConfigureRequest{
+				YamlConfiguration: tt.reconfiguration,
+			})
+			assert.NoError(t, err)
+
+			if tt.expectPreserved {
+				assert.Same(t, client, p.TestClient())
+			} else {
+				assert.NotSame(t, client, p.TestClient())
+			}
+		})
+	}
+}
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	const maxConcurrentRequests = 2
+
+	var inFlight, maxInFlight atomic.Int32
+
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	for i := range 10 {
+		id := fmt.Sprintf("user%d", i)
+		server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: id}, UserName: id})
+	}
+
+	server.OnRequest(func(http.ResponseWriter, *http.Request) bool {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		return false
+	})
+
+	p := plugin.NewPlugin(buildInfo)
+	p.SetLogger(hclog.NewNullLogger())
+
+	_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{
+		YamlConfiguration: getTestConfiguration(server.URL, "GET") + `  maxConcurrentRequests:
+    source: embedded
+    value: "` + strconv.Itoa(maxConcurrentRequests) + `"
+`,
+	})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := range 10 {
+		wg.Go(func() {
+			userID := fmt.Sprintf("user%d", i)
+
+			resp, err := p.GetUser(t.Context(), &idmangv1.GetUserRequest{UserId: userID})
+			assert.NoError(t, err)
+			assert.Equal(t, userID, resp.GetUser().GetId())
+		})
+	}
+
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(maxConcurrentRequests))
+	assert.Positive(t, maxInFlight.Load())
+}
+
+func TestForwardedMetadata(t *testing.T) {
+	tests := []struct {
+		name              string
+		forwardedMetadata string
+		metadata          metadata.MD
+		expectedHeaders   map[string]string
+	}{
+		{
+			name:              "Only allowlisted keys forwarded",
+			forwardedMetadata: "traceparent, Accept-Language",
+			metadata: metadata.Pairs(
+				"traceparent", "00-trace-span-01",
+				"accept-language", "de",
+				"authorization", "Bearer caller",
+				"x-internal", "secret",
+			),
+			expectedHeaders: map[string]string{
+				"Traceparent":     "00-trace-span-01",
+				"Accept-Language": "de",
+				"X-Internal":      "",
+			},
+		},
+		{
+			name:              "Repeated key joined",
+			forwardedMetadata: "accept-language",
+			metadata:          metadata.Pairs("accept-language", "de", "accept-language", "en"),
+			expectedHeaders:   map[string]string{"Accept-Language": "de, en"},
+		},
+		{
+			name:              "Allowlisted key missing",
+			forwardedMetadata: "traceparent",
+			metadata:          metadata.Pairs("x-internal", "secret"),
+			expectedHeaders:   map[string]string{"Traceparent": "", "X-Internal": ""},
+		},
+		{
+			name:            "Nothing forwarded without allowlist",
+			metadata:        metadata.Pairs("traceparent", "00-trace-span-01"),
+			expectedHeaders: map[string]string{"Traceparent": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := scimtest.NewServer()
+			defer server.Close()
+
+			server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "alice"})
+
+			configuration := getTestConfiguration(server.URL, "GET")
+			if tt.forwardedMetadata != "" {
+				configuration += `  forwardedMetadata:
+    source: embedded
+    value: "` + tt.forwardedMetadata + `"
+`
+			}
+
+			p := plugin.NewPlugin(buildInfo)
+			p.SetLogger(hclog.NewNullLogger())
+
+			_, err := p.Configure(t.Context(), &configv1.ConfigureRequest{YamlConfiguration: configuration})
+			assert.NoError(t, err)
+
+			ctx := metadata.NewIncomingContext(t.Context(), tt.metadata)
+
+			_, err = p.GetUser(ctx, &idmangv1.GetUserRequest{UserId: "user1"})
+			assert.NoError(t, err)
+
+			for _, request := range server.Requests() {
+				for key, value := range tt.expectedHeaders {
+					assert.Equal(t, value, request.Header.Get(key), key)
+				}
+
+				// The client credentials are never replaced by forwarded metadata
+				assert.True(t, strings.HasPrefix(request.Header.Get("Authorization"), "Basic "))
+			}
+		})
+	}
 }
@@ -0,0 +1,100 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+// statusError attaches a gRPC status code to an error while keeping
+// it unwrappable, so errors.Is still matches the plugin sentinels.
+type statusError struct {
+	code codes.Code
+	err  error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+func (e *statusError) GRPCStatus() *status.Status {
+	return status.New(e.code, e.err.Error())
+}
+
+// toStatusError maps an RPC error to a gRPC status error so that
+// callers can rely on its code. Errors already carrying a status
+// are returned as is.
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		return err
+	}
+
+	return &statusError{code: statusCode(err), err: err}
+}
+
+// isNotFound reports whether the SCIM server answered 404 Not Found.
+func isNotFound(err error) bool {
+	var statusCodeErr *httpclient.StatusCodeError
+
+	return errors.As(err, &statusCodeErr) && statusCodeErr.StatusCode == http.StatusNotFound
+}
+
+func statusCode(err error) codes.Code {
+	var statusCodeErr *httpclient.StatusCodeError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ErrMemberTimedOut):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, ErrNoScimClient), errors.Is(err, ErrNoGroupAttribute),
+		errors.Is(err, scim.ErrLoadCredentials):
+		return codes.FailedPrecondition
+	case errors.Is(err, ErrNoID), errors.Is(err, ErrMissingAuthContextField):
+		return codes.InvalidArgument
+	case errors.Is(err, ErrGroupTooLarge):
+		return codes.ResourceExhausted
+	case errors.Is(err, scim.ErrCircuitOpen):
+		return codes.Unavailable
+	case errors.As(err, &statusCodeErr):
+		return httpStatusCode(statusCodeErr.StatusCode)
+	default:
+		return codes.Internal
+	}
+}
+
+func httpStatusCode(statusCode int) codes.Code {
+	switch {
+	case statusCode == http.StatusBadRequest:
+		return codes.InvalidArgument
+	case statusCode == http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case statusCode == http.StatusForbidden:
+		return codes.PermissionDenied
+	case statusCode == http.StatusNotFound:
+		return codes.NotFound
+	case statusCode == http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case statusCode == http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case statusCode >= http.StatusInternalServerError:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,116 @@
+package scim_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	idmangv1 "github.com/openkcm/plugin-sdk/proto/plugin/identity_management/v1"
+
+	plugin "github.com/openkcm/identity-management-plugins/internal/plugin/scim"
+)
+
+func TestErrorStatusCodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		responseCode  int
+		delay         time.Duration
+		noClient      bool
+		expectedCode  codes.Code
+		expectedError error
+	}{
+		{
+			name:          "No SCIM client",
+			noClient:      true,
+			expectedCode:  codes.FailedPrecondition,
+			expectedError: plugin.ErrNoScimClient,
+		},
+		{
+			name:          "User not found",
+			responseCode:  http.StatusNotFound,
+			expectedCode:  codes.NotFound,
+			expectedError: plugin.ErrGetUser,
+		},
+		{
+			name:          "Unauthorized",
+			responseCode:  http.StatusUnauthorized,
+			expectedCode:  codes.Unauthenticated,
+			expectedError: plugin.ErrGetUser,
+		},
+		{
+			name:          "Forbidden",
+			responseCode:  http.StatusForbidden,
+			expectedCode:  codes.PermissionDenied,
+			expectedError: plugin.ErrGetUser,
+		},
+		{
+			name:          "Server error",
+			responseCode:  http.StatusInternalServerError,
+			expectedCode:  codes.Unavailable,
+			expectedError: plugin.ErrGetUser,
+		},
+		{
+			name:          "Timeout",
+			responseCode:  http.StatusOK,
+			delay:         100 * time.Millisecond,
+			expectedCode:  codes.DeadlineExceeded,
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-time.After(tt.delay):
+				case <-r.Context().Done():
+					return
+				}
+
+				w.WriteHeader(tt.responseCode)
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			p := plugin.NewPlugin(buildInfo)
+			if !tt.noClient {
+				p = setupTest(t, server.URL, "", "")
+			}
+
+			ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+			defer cancel()
+
+			_, err := p.GetUser(ctx, &idmangv1.GetUserRequest{UserId: "user1"})
+			assert.ErrorIs(t, err, tt.expectedError)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestErrorStatusCodesGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(EmptyResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	p := setupTest(t, server.URL, "", "")
+
+	_, err := p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{GroupName: "Unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = p.GetGroup(t.Context(), &idmangv1.GetGroupRequest{})
+	assert.ErrorIs(t, err, plugin.ErrNoID)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = p.GetUsersForGroup(t.Context(), &idmangv1.GetUsersForGroupRequest{})
+	assert.ErrorIs(t, err, plugin.ErrNoID)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,136 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ResolveAttribute resolves a dotted SCIM attribute path such as
+// "name.familyName" or "emails.value" against a resource, which may be
+// a decoded JSON value or any JSON-serializable struct. Attribute names
+// are matched case-insensitively, and paths may be qualified by an
+// extension schema URN, e.g. "urn:...:extension:sap:2.0:User:status".
+// When the path traverses a multi-valued attribute, the values of all
+// elements are collected into a slice.
+func ResolveAttribute(resource any, path string) (any, bool) {
+	current, ok := toGeneric(resource)
+	if !ok {
+		return nil, false
+	}
+
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		current, path, ok = resolveSchemaURN(current, path)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	multiValued := false
+
+	for _, part := range strings.Split(path, ".") {
+		var next []any
+
+		for _, item := range flattenValue(current) {
+			object, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			value, ok := lookupKey(object, part)
+			if !ok {
+				continue
+			}
+
+			next = append(next, value)
+		}
+
+		if len(next) == 0 {
+			return nil, false
+		}
+
+		if _, isList := current.([]any); isList {
+			multiValued = true
+		}
+
+		if len(next) == 1 && !multiValued {
+			current = next[0]
+		} else {
+			current = flattenValues(next)
+			multiValued = true
+		}
+	}
+
+	return current, true
+}
+
+// resolveSchemaURN resolves the extension schema URN prefixing the path
+// and returns the extension object along with the remaining path.
+func resolveSchemaURN(resource any, path string) (any, string, bool) {
+	object, ok := resource.(map[string]any)
+	if !ok {
+		return nil, "", false
+	}
+
+	for key, value := range object {
+		prefix := key + ":"
+		if len(path) > len(prefix) && strings.EqualFold(path[:len(prefix)], prefix) {
+			return value, path[len(prefix):], true
+		}
+	}
+
+	return nil, "", false
+}
+
+// toGeneric converts a resource to its generic JSON representation
+// of maps, slices and scalars.
+func toGeneric(resource any) (any, bool) {
+	switch resource.(type) {
+	case map[string]any, []any:
+		return resource, true
+	}
+
+	encoded, err := json.Marshal(resource)
+	if err != nil {
+		return nil, false
+	}
+
+	var decoded any
+
+	err = json.Unmarshal(encoded, &decoded)
+	if err != nil {
+		return nil, false
+	}
+
+	return decoded, true
+}
+
+func lookupKey(object map[string]any, key string) (any, bool) {
+	if value, ok := object[key]; ok {
+		return value, true
+	}
+
+	for k, value := range object {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+func flattenValue(value any) []any {
+	if values, ok := value.([]any); ok {
+		return values
+	}
+
+	return []any{value}
+}
+
+func flattenValues(values []any) []any {
+	flattened := make([]any, 0, len(values))
+	for _, value := range values {
+		flattened = append(flattened, flattenValue(value)...)
+	}
+
+	return flattened
+}
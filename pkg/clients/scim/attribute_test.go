@@ -0,0 +1,97 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestResolveAttribute(t *testing.T) {
+	var payload map[string]any
+
+	err := json.Unmarshal([]byte(GetUserResponse), &payload)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		resource      any
+		path          string
+		expected      any
+		expectedFound bool
+	}{
+		{
+			name:          "Top-level attribute",
+			resource:      payload,
+			path:          "userName",
+			expected:      "cloudanalyst",
+			expectedFound: true,
+		},
+		{
+			name:          "Nested attribute",
+			resource:      payload,
+			path:          "meta.lastModified",
+			expected:      "2021-05-18T15:18:00Z",
+			expectedFound: true,
+		},
+		{
+			name:          "Case-insensitive attribute",
+			resource:      payload,
+			path:          "Name.FamilyName",
+			expected:      "Analyst",
+			expectedFound: true,
+		},
+		{
+			name:          "Multi-valued attribute",
+			resource:      payload,
+			path:          "emails.value",
+			expected:      []any{"cloud.analyst@example.com"},
+			expectedFound: true,
+		},
+		{
+			name:          "Extension attribute",
+			resource:      payload,
+			path:          "urn:ietf:params:scim:schemas:extension:sap:2.0:User:passwordDetails.status",
+			expected:      "initial",
+			expectedFound: true,
+		},
+		{
+			name:          "Struct resource",
+			resource:      ExpectedUser,
+			path:          "groups.display",
+			expected:      []any{"CloudAnalyst"},
+			expectedFound: true,
+		},
+		{
+			name:          "Multiple values",
+			resource:      map[string]any{"emails": []any{map[string]any{"value": "a"}, map[string]any{"value": "b"}}},
+			path:          "emails.value",
+			expected:      []any{"a", "b"},
+			expectedFound: true,
+		},
+		{
+			name:          "Missing attribute",
+			resource:      payload,
+			path:          "meta.version",
+			expected:      nil,
+			expectedFound: false,
+		},
+		{
+			name:          "Unknown extension",
+			resource:      payload,
+			path:          "urn:unknown:extension:status",
+			expected:      nil,
+			expectedFound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, found := scim.ResolveAttribute(tt.resource, tt.path)
+			assert.Equal(t, tt.expectedFound, found)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
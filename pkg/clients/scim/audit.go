@@ -0,0 +1,102 @@
+package scim
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+type actorKey struct{}
+
+// AuditOutcome is the result of an audited write operation.
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+)
+
+// AuditEntry describes a completed write operation. It holds only
+// identifiers, never the written attributes, so that it carries no
+// secrets or personal data.
+type AuditEntry struct {
+	// Actor is the identity carried by the request context, if any
+	Actor string
+	// Tenant is the identifier carried by the request context, if any
+	Tenant       string
+	Operation    string // Client method, e.g. DeleteUser
+	ResourceType string // User or Group
+	ResourceID   string // Empty if a creation failed
+	Outcome      AuditOutcome
+	Err          error
+}
+
+// AuditLogger is called after each write operation, once its retries
+// are done. It runs synchronously and should return quickly.
+type AuditLogger func(ctx context.Context, entry AuditEntry)
+
+// WithAuditLogger registers an audit logger of the write operations.
+func WithAuditLogger(logger AuditLogger) Option {
+	return func(c *Client) {
+		c.auditLogger = logger
+	}
+}
+
+// NewHCLogAuditLogger returns an audit logger writing an entry per
+// write operation to the logger, with the audit field set.
+func NewHCLogAuditLogger(logger hclog.Logger) AuditLogger {
+	return func(_ context.Context, entry AuditEntry) {
+		args := []any{
+			"audit", true,
+			"actor", entry.Actor,
+			"tenant", entry.Tenant,
+			"operation", entry.Operation,
+			"resourceType", entry.ResourceType,
+			"resourceID", entry.ResourceID,
+			"outcome", entry.Outcome,
+		}
+
+		if entry.Err != nil {
+			args = append(args, "error", entry.Err)
+		}
+
+		logger.Info("SCIM write operation", args...)
+	}
+}
+
+// ContextWithActor returns a context whose write operations are audited
+// as performed by the actor, e.g. the caller identified by the auth context.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by the context, if any.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+func (c *Client) audit(ctx context.Context, operation, resourceType, resourceID string, err error) {
+	if c.auditLogger == nil {
+		return
+	}
+
+	actor, _ := ActorFromContext(ctx)
+	tenant, _ := TenantFromContext(ctx)
+
+	entry := AuditEntry{
+		Actor:        actor,
+		Tenant:       tenant,
+		Operation:    operation,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Outcome:      AuditSuccess,
+		Err:          err,
+	}
+
+	if err != nil {
+		entry.Outcome = AuditFailure
+	}
+
+	c.auditLogger(ctx, entry)
+}
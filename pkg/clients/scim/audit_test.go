@@ -0,0 +1,146 @@
+package scim_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func getAuditedClient(t *testing.T, auditLogger scim.AuditLogger) *scim.Client {
+	t.Helper()
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithAuditLogger(auditLogger),
+	)
+	assert.NoError(t, err)
+
+	return client
+}
+
+func TestAuditLogger(t *testing.T) {
+	tests := []struct {
+		name          string
+		write         func(ctx context.Context, client *scim.Client, params scim.RequestParams) error
+		status        int
+		responseBody  string
+		expectedEntry scim.AuditEntry
+	}{
+		{
+			name: "Delete user",
+			write: func(ctx context.Context, client *scim.Client, params scim.RequestParams) error {
+				return client.DeleteUser(ctx, "user1", params)
+			},
+			status: http.StatusNoContent,
+			expectedEntry: scim.AuditEntry{
+				Actor:        "alice",
+				Tenant:       "tenant1",
+				Operation:    "DeleteUser",
+				ResourceType: scim.ResourceTypeUser,
+				ResourceID:   "user1",
+				Outcome:      scim.AuditSuccess,
+			},
+		},
+		{
+			name: "Create group",
+			write: func(ctx context.Context, client *scim.Client, params scim.RequestParams) error {
+				_, err := client.CreateGroup(ctx, scim.Group{DisplayName: "KeyAdmin"}, params)
+				return err
+			},
+			status:       http.StatusCreated,
+			responseBody: `{"id":"group1","displayName":"KeyAdmin"}`,
+			expectedEntry: scim.AuditEntry{
+				Actor:        "alice",
+				Tenant:       "tenant1",
+				Operation:    "CreateGroup",
+				ResourceType: scim.ResourceTypeGroup,
+				ResourceID:   "group1",
+				Outcome:      scim.AuditSuccess,
+			},
+		},
+		{
+			name: "Failed group members replacement",
+			write: func(ctx context.Context, client *scim.Client, params scim.RequestParams) error {
+				return client.ReplaceGroupMembers(ctx, "group1", []string{"user1"}, params)
+			},
+			status: http.StatusForbidden,
+			expectedEntry: scim.AuditEntry{
+				Actor:        "alice",
+				Tenant:       "tenant1",
+				Operation:    "ReplaceGroupMembers",
+				ResourceType: scim.ResourceTypeGroup,
+				ResourceID:   "group1",
+				Outcome:      scim.AuditFailure,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, err := w.Write([]byte(tt.responseBody))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			var entries []scim.AuditEntry
+
+			client := getAuditedClient(t, func(_ context.Context, entry scim.AuditEntry) {
+				entries = append(entries, entry)
+			})
+
+			ctx := scim.ContextWithTenant(scim.ContextWithActor(t.Context(), "alice"), "tenant1")
+			err := tt.write(ctx, client, scim.RequestParams{Host: server.URL})
+
+			assert.Len(t, entries, 1)
+
+			entry := entries[0]
+			if tt.expectedEntry.Outcome == scim.AuditFailure {
+				assert.Error(t, err)
+				assert.Error(t, entry.Err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			entry.Err = nil
+			assert.Equal(t, tt.expectedEntry, entry)
+		})
+	}
+}
+
+func TestHCLogAuditLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+
+	logger := hclog.New(&hclog.LoggerOptions{Output: &out, JSONFormat: true})
+	client := getAuditedClient(t, scim.NewHCLogAuditLogger(logger))
+
+	err := client.DeleteUser(scim.ContextWithActor(t.Context(), "alice"), "user1", scim.RequestParams{Host: server.URL})
+	assert.NoError(t, err)
+
+	assert.Contains(t, out.String(), `"audit":true`)
+	assert.Contains(t, out.String(), `"actor":"alice"`)
+	assert.Contains(t, out.String(), `"operation":"DeleteUser"`)
+	assert.Contains(t, out.String(), `"resourceID":"user1"`)
+	assert.Contains(t, out.String(), `"outcome":"success"`)
+}
@@ -0,0 +1,37 @@
+package scim
+
+import (
+	"context"
+)
+
+// WithBaseContext sets the parent context of the background work of the
+// client, such as purging expired cache entries. Background work stops
+// when it is canceled or the client is closed.
+func WithBaseContext(ctx context.Context) Option {
+	return func(c *Client) {
+		c.baseCtx = ctx
+	}
+}
+
+// startBackground derives the context of the background work from the
+// base context, so that Close can cancel it.
+func (c *Client) startBackground() {
+	parent := c.baseCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	c.baseCtx, c.cancelBackground = context.WithCancel(parent)
+}
+
+// goBackground runs the function in a goroutine tracked by the client,
+// passing it the context canceled on Close.
+func (c *Client) goBackground(run func(ctx context.Context)) {
+	c.background.Add(1)
+
+	go func() {
+		defer c.background.Done()
+
+		run(c.baseCtx)
+	}()
+}
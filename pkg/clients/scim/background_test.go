@@ -0,0 +1,83 @@
+package scim_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestBackgroundWork(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(client *scim.Client, cancel context.CancelFunc)
+	}{
+		{
+			name: "Stopped by Close",
+			stop: func(client *scim.Client, _ context.CancelFunc) {
+				client.Close()
+			},
+		},
+		{
+			name: "Stopped by base context",
+			stop: func(_ *scim.Client, cancel context.CancelFunc) {
+				cancel()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithETagCache(time.Millisecond),
+				scim.WithBaseContext(ctx),
+			)
+			assert.NoError(t, err)
+
+			defer client.Close()
+
+			client.PutETagCacheEntry("https://scim.example.com/Users/1")
+
+			// The expired entry is purged without being requested again
+			assert.Eventually(t, func() bool {
+				return client.ETagCacheLen() == 0
+			}, time.Second, time.Millisecond)
+
+			done := client.BackgroundDone()
+			assert.Never(t, func() bool {
+				select {
+				case <-done:
+					return true
+				default:
+					return false
+				}
+			}, 20*time.Millisecond, time.Millisecond)
+
+			tt.stop(client, cancel)
+
+			assert.Eventually(t, func() bool {
+				select {
+				case <-done:
+					return true
+				default:
+					return false
+				}
+			}, time.Second, time.Millisecond)
+		})
+	}
+}
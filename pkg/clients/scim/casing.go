@@ -0,0 +1,145 @@
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// WithAttributeCaseNormalization rewrites the top-level attribute names
+// of decoded resources, and of the resources of list responses, to the
+// casing of the client types, as SCIM attribute names are
+// case-insensitive. encoding/json already matches names ignoring case,
+// but lets the last of several keys differing only in case win; with
+// normalization the key cased as expected wins, e.g. userName over
+// UserName.
+func WithAttributeCaseNormalization() Option {
+	return func(c *Client) {
+		c.normalizeAttributeCase = true
+	}
+}
+
+// canonicalAttributeNames maps the lower-cased top-level attribute names
+// of the resource and list types to their casing.
+var canonicalAttributeNames = sync.OnceValue(func() map[string]string {
+	names := make(map[string]string)
+
+	for _, t := range []reflect.Type{
+		reflect.TypeFor[User](),
+		reflect.TypeFor[Group](),
+		reflect.TypeFor[UserList](),
+	} {
+		for _, name := range jsonFieldNames(t) {
+			names[strings.ToLower(name)] = name
+		}
+	}
+
+	return names
+})
+
+// jsonFieldNames returns the JSON names of the fields of the struct
+// type, including those of embedded structs.
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		switch {
+		case name == "-":
+		case field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct:
+			names = append(names, jsonFieldNames(field.Type)...)
+		case name != "":
+			names = append(names, name)
+		case field.IsExported():
+			names = append(names, field.Name)
+		}
+	}
+
+	return names
+}
+
+// normalizeResponseCase rewrites a successful response body with the
+// attribute names normalized, if enabled.
+func (c *Client) normalizeResponseCase(resp *http.Response) error {
+	if !c.normalizeAttributeCase || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	normalized, err := normalizeAttributeCase(body)
+	if err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(normalized))
+
+	return nil
+}
+
+// normalizeAttributeCase normalizes the top-level attribute names of the
+// JSON object, and of the objects under its Resources attribute.
+// Bodies that are not objects are returned as is, to fail decoding.
+func normalizeAttributeCase(body []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+
+	if json.Unmarshal(body, &fields) != nil {
+		return body, nil
+	}
+
+	fields = normalizeKeys(fields)
+
+	var resources []json.RawMessage
+
+	err := json.Unmarshal(fields[listResourcesKey], &resources)
+	if err == nil && resources != nil {
+		for i, resource := range resources {
+			resources[i], err = normalizeAttributeCase(resource)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		fields[listResourcesKey], err = json.Marshal(resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// normalizeKeys renames the known attributes to their casing. Of keys
+// differing only in case, the one already cased as expected wins, or
+// else the first in lexical order.
+func normalizeKeys(fields map[string]json.RawMessage) map[string]json.RawMessage {
+	names := canonicalAttributeNames()
+	normalized := make(map[string]json.RawMessage, len(fields))
+
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		name, ok := names[strings.ToLower(key)]
+		if !ok {
+			name = key
+		}
+
+		_, exact := fields[name]
+		if _, seen := normalized[name]; seen || (exact && key != name) {
+			continue
+		}
+
+		normalized[name] = fields[key]
+	}
+
+	return normalized
+}
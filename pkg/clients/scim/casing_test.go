@@ -0,0 +1,105 @@
+package scim_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestAttributeCaseNormalization(t *testing.T) {
+	tests := []struct {
+		name             string
+		normalize        bool
+		responseBody     string
+		list             bool
+		expectedID       string
+		expectedUserName string
+		expectedDisplay  string
+		expectedEmails   int
+	}{
+		{
+			name:             "Mixed-case attributes",
+			normalize:        true,
+			responseBody:     `{"ID":"user1","UserName":"alice","DisplayName":"Alice","EMAILS":[{"value":"alice@example.com"}]}`,
+			expectedID:       "user1",
+			expectedUserName: "alice",
+			expectedDisplay:  "Alice",
+			expectedEmails:   1,
+		},
+		{
+			name:             "Expected casing wins over variants",
+			normalize:        true,
+			responseBody:     `{"id":"user1","userName":"alice","UserName":"bob","USERNAME":"carol"}`,
+			expectedID:       "user1",
+			expectedUserName: "alice",
+		},
+		{
+			name:             "Last variant wins without normalization",
+			normalize:        false,
+			responseBody:     `{"id":"user1","userName":"alice","UserName":"bob"}`,
+			expectedID:       "user1",
+			expectedUserName: "bob",
+		},
+		{
+			name:      "Mixed-case list and resource attributes",
+			normalize: true,
+			responseBody: `{"SCHEMAS":["urn:ietf:params:scim:api:messages:2.0:ListResponse"],"TotalResults":1,` +
+				`"resources":[{"Id":"user1","username":"bob","userName":"alice","displayname":"Alice"}]}`,
+			list:             true,
+			expectedID:       "user1",
+			expectedUserName: "alice",
+			expectedDisplay:  "Alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, http.StatusOK, tt.responseBody)
+			defer server.Close()
+
+			var opts []scim.Option
+			if tt.normalize {
+				opts = append(opts, scim.WithAttributeCaseNormalization(), scim.WithStrictDecoding())
+			}
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				opts...,
+			)
+			assert.NoError(t, err)
+
+			params := scim.RequestParams{Host: server.URL}
+
+			var user *scim.User
+
+			if tt.list {
+				users, err := client.ListUsers(t.Context(), params)
+				assert.NoError(t, err)
+				assert.Equal(t, 1, users.TotalResults)
+				assert.Equal(t, []string{scim.ListResponseSchema}, users.Schemas)
+				assert.Len(t, users.Resources, 1)
+
+				user = &users.Resources[0]
+			} else {
+				user, err = client.GetUser(t.Context(), "user1", params)
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expectedID, user.ID)
+			assert.Equal(t, tt.expectedUserName, user.UserName)
+			assert.Equal(t, tt.expectedDisplay, user.DisplayName)
+			assert.Len(t, user.Emails, tt.expectedEmails)
+		})
+	}
+}
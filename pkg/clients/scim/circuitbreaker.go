@@ -0,0 +1,124 @@
+package scim
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker trips open after threshold consecutive failures and
+// rejects requests until cooldown has elapsed. It then lets a single
+// probe through (half-open) which either closes or reopens the circuit.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+	now      func() time.Time
+}
+
+// WithCircuitBreaker enables a circuit breaker per SCIM host, tripping after
+// threshold consecutive failed requests and short-circuiting with
+// ErrCircuitOpen until cooldown has elapsed.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.breakerThreshold = threshold
+		c.breakerCooldown = cooldown
+		c.breakers = make(map[string]*circuitBreaker)
+	}
+}
+
+// breakerFor returns the circuit breaker of the host, or nil if disabled.
+func (c *Client) breakerFor(host string) *circuitBreaker {
+	if c.breakerThreshold <= 0 {
+		return nil
+	}
+
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+
+	breaker, ok := c.breakers[host]
+	if !ok {
+		breaker = &circuitBreaker{
+			threshold: c.breakerThreshold,
+			cooldown:  c.breakerCooldown,
+			now:       c.now,
+		}
+		c.breakers[host] = breaker
+	}
+
+	return breaker
+}
+
+// allow reports whether a request may be made, and whether it is the
+// probe of a half-open breaker.
+func (b *circuitBreaker) allow() (bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false, false
+		}
+
+		b.state = circuitHalfOpen
+
+		return true, true
+	case circuitHalfOpen:
+		// Only the single probe is allowed while half-open
+		return false, false
+	default:
+		return true, false
+	}
+}
+
+// abandonProbe reopens a half-open breaker whose probe ended without an
+// outcome, letting the next request probe instead.
+func (b *circuitBreaker) abandonProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitHalfOpen {
+		b.state = circuitOpen
+	}
+}
+
+// record updates the breaker with the outcome of a request,
+// reporting whether it opened the circuit.
+func (b *circuitBreaker) record(failed bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state = circuitClosed
+		b.failures = 0
+
+		return false
+	}
+
+	b.failures++
+
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		wasOpen := b.state == circuitOpen
+		b.state = circuitOpen
+		b.openedAt = b.now()
+
+		return !wasOpen
+	}
+
+	return false
+}
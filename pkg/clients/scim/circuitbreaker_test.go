@@ -0,0 +1,156 @@
+package scim_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		requests atomic.Int32
+		failing  atomic.Bool
+	)
+
+	failing.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	const (
+		threshold = 3
+		cooldown  = 50 * time.Millisecond
+	)
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithCircuitBreaker(threshold, cooldown),
+	)
+	assert.NoError(t, err)
+
+	params := scim.RequestParams{Host: server.URL}
+
+	// Trip the breaker
+	for range threshold {
+		_, err = client.GetUser(t.Context(), "123", params)
+		assert.ErrorIs(t, err, scim.ErrGetUser)
+		assert.NotErrorIs(t, err, scim.ErrCircuitOpen)
+	}
+
+	// Open: requests are short-circuited without reaching the server
+	_, err = client.GetUser(t.Context(), "123", params)
+	assert.ErrorIs(t, err, scim.ErrCircuitOpen)
+	assert.Equal(t, threshold, int(requests.Load()))
+
+	// Half-open probe fails and reopens the breaker
+	time.Sleep(cooldown)
+
+	_, err = client.GetUser(t.Context(), "123", params)
+	assert.NotErrorIs(t, err, scim.ErrCircuitOpen)
+
+	_, err = client.GetUser(t.Context(), "123", params)
+	assert.ErrorIs(t, err, scim.ErrCircuitOpen)
+	assert.Equal(t, threshold+1, int(requests.Load()))
+
+	// Half-open probe succeeds and closes the breaker
+	failing.Store(false)
+	time.Sleep(cooldown)
+
+	for range threshold {
+		user, err := client.GetUser(t.Context(), "123", params)
+		assert.NoError(t, err)
+		assert.Equal(t, &ExpectedUser, user)
+	}
+
+	assert.Equal(t, 2*threshold+1, int(requests.Load()))
+}
+
+func TestCircuitBreakerProbeWaitingForSlot(t *testing.T) {
+	var failing atomic.Bool
+
+	failing.Store(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	holder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer holder.Close()
+
+	const cooldown = 50 * time.Millisecond
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithCircuitBreaker(1, cooldown),
+		scim.WithMaxConcurrentRequests(1),
+	)
+	assert.NoError(t, err)
+
+	params := scim.RequestParams{Host: server.URL}
+
+	_, err = client.GetUser(t.Context(), "123", params)
+	assert.ErrorIs(t, err, scim.ErrGetUser)
+
+	time.Sleep(cooldown)
+
+	// Hold the only slot with a response of another host until its body is closed
+	resp, err := client.Do(t.Context(), http.MethodGet, "/Users/123", nil, scim.RequestParams{Host: holder.URL})
+	assert.NoError(t, err)
+
+	// The context ends while waiting for the slot, before the probe is taken
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = client.GetUser(ctx, "123", params)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.NoError(t, resp.Body.Close())
+	failing.Store(false)
+
+	// The probe is still available and closes the breaker
+	user, err := client.GetUser(t.Context(), "123", params)
+	assert.NoError(t, err)
+	assert.Equal(t, &ExpectedUser, user)
+}
@@ -0,0 +1,69 @@
+package scim
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithMaxConcurrentRequests bounds the SCIM requests in flight across
+// all callers of the client to n, so that concurrent fan-outs do not
+// open an unbounded number of connections to the server. A request is
+// in flight until its response body is read to the end or closed, and
+// requests block until a slot is free or their context is done.
+// Disabled if n is not positive.
+func WithMaxConcurrentRequests(n int) Option {
+	return func(c *Client) {
+		c.inFlight = nil
+		if n > 0 {
+			c.inFlight = make(chan struct{}, n)
+		}
+	}
+}
+
+// acquireInFlight waits for a free in-flight slot, returning the
+// function releasing it.
+func (c *Client) acquireInFlight(req *http.Request) (func(), error) {
+	if c.inFlight == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case c.inFlight <- struct{}{}:
+		return sync.OnceFunc(func() { <-c.inFlight }), nil
+	default:
+	}
+
+	c.logger.Debug("Waiting for a SCIM request slot", "method", req.Method, "url", req.URL.Redacted())
+
+	select {
+	case c.inFlight <- struct{}{}:
+		return sync.OnceFunc(func() { <-c.inFlight }), nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
+// releasingBody releases the in-flight slot of its request once read
+// to the end or closed, as the transport then frees the connection.
+type releasingBody struct {
+	io.ReadCloser
+
+	release func()
+}
+
+func (b *releasingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		b.release()
+	}
+
+	return n, err
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+
+	return b.ReadCloser.Close()
+}
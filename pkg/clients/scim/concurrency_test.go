@@ -0,0 +1,88 @@
+package scim_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestMaxConcurrentRequests(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxConcurrent int
+		expectedError error
+	}{
+		{name: "Unbounded"},
+		{name: "Slot free", maxConcurrent: 2},
+		{name: "Waits for slot until context done", maxConcurrent: 1, expectedError: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan struct{})
+			unblock := make(chan struct{})
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/Users/slow" {
+					close(received)
+					<-unblock
+				}
+
+				_, err := w.Write([]byte(`{"id":"123","userName":"john"}`))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithMaxConcurrentRequests(tt.maxConcurrent),
+			)
+			assert.NoError(t, err)
+
+			params := scim.RequestParams{Host: server.URL}
+
+			// Hold a slot with a request the server does not answer yet
+			slowDone := make(chan struct{})
+
+			go func() {
+				defer close(slowDone)
+
+				_, err := client.GetUser(context.WithoutCancel(t.Context()), "slow", params)
+				assert.NoError(t, err)
+			}()
+
+			<-received
+
+			ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+			defer cancel()
+
+			_, err = client.GetUser(ctx, "123", params)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			close(unblock)
+			<-slowDone
+
+			// The slot is released once the response is read
+			_, err = client.GetUser(t.Context(), "123", params)
+			assert.NoError(t, err)
+		})
+	}
+}
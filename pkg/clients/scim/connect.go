@@ -1,19 +1,25 @@
 package scim
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/openkcm/common-sdk/pkg/commoncfg"
-	"github.com/openkcm/common-sdk/pkg/pointers"
+	"golang.org/x/time/rate"
 
 	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
 	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
 )
 
 const (
@@ -26,6 +32,18 @@ const (
 	PostSearchPath = ".search"
 
 	HeaderAuthorization = "Authorization"
+	HeaderUserAgent     = "User-Agent"
+
+	DefaultUserAgent = "openkcm-identity-management-plugins/scim"
+
+	defaultGroupMembersAttribute = "members"
+
+	// http2Proto is the ALPN protocol of HTTP/2 over TLS
+	http2Proto = "h2"
+
+	// defaultSessionCacheSize is the number of TLS sessions cached,
+	// one per SCIM host
+	defaultSessionCacheSize = 64
 )
 
 var (
@@ -46,63 +64,261 @@ type RequestParams struct {
 	Cursor  *string
 	Count   *int
 	Headers map[string]string
+
+	// StartIndex is the 1-based index of the first resource
+	// of a page with index-based pagination.
+	StartIndex *int
+
+	// UseSearchPost overrides Method for list requests if set,
+	// using POST /.search when true and GET when false.
+	UseSearchPost *bool
+
+	// Attributes and ExcludedAttributes project the attributes
+	// returned for the resources of requests.
+	Attributes         []string
+	ExcludedAttributes []string
+
+	// IfMatch is the version, e.g. meta.version, a write request
+	// applies to, sent normalized as If-Match if set.
+	IfMatch string
+
+	// MaxResults bounds the resources ListAllUsers and ListAllGroups
+	// return, which stop paging once reached; unbounded if zero.
+	MaxResults int
 }
 
 type Client struct {
 	logger     hclog.Logger
 	httpClient *http.Client
-
-	basicAuth *basicAuth
-}
-type basicAuth struct {
-	clientID     string
-	clientSecret string
+	transport  *http.Transport
+	http2      bool
+
+	insecureSkipVerify     bool
+	sessionCache           tls.ClientSessionCache
+	sessionTicketsDisabled bool
+	nextProtos             []string
+
+	credentials  CredentialProvider
+	oauth2       *oauth2Credentials
+	oauth2Scopes []string
+	tokenCache   *TokenCache
+
+	maxRetries   int
+	retryBackoff time.Duration
+
+	limiter  *rate.Limiter
+	inFlight chan struct{} // Semaphore of the requests in flight, unbounded if nil
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+	breakersMu       sync.Mutex
+	breakers         map[string]*circuitBreaker
+
+	strictSchemas  bool
+	strictDecoding bool
+
+	pagination             PaginationParams
+	paginationStrategy     PaginationStrategy // PaginationCursor if empty
+	redirectPolicy         RedirectPolicy     // RedirectNone if empty
+	maxQueryLength         int
+	listResourcesKey       string
+	maxRequestBodySize     int
+	minimalFilterEncoding  bool
+	minimalFilterParens    bool
+	normalizeAttributeCase bool
+	notFoundAsEmpty        bool
+
+	userAgent      string
+	defaultHeaders map[string]string
+	contextHeaders func(ctx context.Context) map[string]string
+	observer       Observer
+	auditLogger    AuditLogger
+
+	etags          *etagCache
+	cacheTTLJitter float64
+
+	now func() time.Time
+
+	baseCtx          context.Context //nolint:containedctx // Parent of the background work
+	cancelBackground context.CancelFunc
+	background       sync.WaitGroup
+
+	closeOnce sync.Once
 }
 
-func NewClient(authRef commoncfg.SecretRef, logger hclog.Logger) (*Client, error) {
+func NewClient(authRef commoncfg.SecretRef, logger hclog.Logger, opts ...Option) (*Client, error) {
+	transport := newTransport()
+
+	client := &Client{
+		logger:     logger,
+		httpClient: &http.Client{Transport: transport},
+		transport:  transport,
+		userAgent:  DefaultUserAgent,
+		http2:      true,
+		now:        time.Now,
+		pagination: DefaultPaginationParams,
+	}
+
 	switch authRef.Type {
 	case commoncfg.BasicSecretType:
-		clientId, err := commoncfg.LoadValueFromSourceRef(authRef.Basic.Username)
-		if err != nil {
-			return nil, ErrClientID
-		}
+		provider := NewSourceRefCredentialProvider(authRef.Basic, 0)
 
-		clientSecret, err := commoncfg.LoadValueFromSourceRef(authRef.Basic.Password)
+		// Load the credentials once upfront so misconfiguration fails fast
+		_, _, err := provider.Credentials(context.Background())
 		if err != nil {
-			return nil, ErrClientSecret
+			return nil, err
 		}
 
-		return &Client{
-			logger:     logger,
-			httpClient: &http.Client{},
-			basicAuth: &basicAuth{
-				clientID:     string(clientId),
-				clientSecret: string(clientSecret),
-			},
-		}, nil
+		client.credentials = provider
 	case commoncfg.MTLSSecretType:
 		mtls, err := commoncfg.LoadMTLSConfig(&authRef.MTLS)
 		if err != nil {
 			return nil, errs.Wrap(ErrParsingClientCertificate, err)
 		}
 
-		return &Client{
-			logger: logger,
-			httpClient: &http.Client{
-				Transport: &http.Transport{
-					TLSClientConfig: mtls,
-				},
-			},
-		}, nil
+		transport.TLSClientConfig = mtls
+	case commoncfg.OAuth2SecretType:
+		credentials, err := loadOAuth2Credentials(authRef.OAuth2)
+		if err != nil {
+			return nil, err
+		}
+
+		client.oauth2 = credentials
+		client.tokenCache = sharedTokenCache
 	default:
 		return nil, ErrAuthNotImplemented
 	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	if client.logger == nil {
+		client.logger = hclog.NewNullLogger()
+	}
+
+	client.configureInsecureSkipVerify()
+	client.configureSessionResumption()
+	client.configureNextProtos()
+	client.configureHTTP2()
+	client.httpClient.CheckRedirect = client.checkRedirect
+
+	client.startBackground()
+
+	if client.etags != nil {
+		client.etags.jitter = client.cacheTTLJitter
+		client.etags.now = client.now
+
+		if client.etags.ttl > 0 {
+			client.goBackground(client.etags.purgeExpiredEvery(client.etags.ttl))
+		}
+	}
+
+	return client, nil
+}
+
+// configureInsecureSkipVerify disables server certificate verification
+// if requested, warning loudly as it must never be used in production.
+func (c *Client) configureInsecureSkipVerify() {
+	if !c.insecureSkipVerify {
+		return
+	}
+
+	c.logger.Warn("TLS certificate verification of the SCIM server is DISABLED, " +
+		"connections are vulnerable to interception; use for testing only")
+
+	if c.transport.TLSClientConfig == nil {
+		c.transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	c.transport.TLSClientConfig.InsecureSkipVerify = true //nolint:gosec // Explicitly requested for testing
+}
+
+// configureSessionResumption sets the TLS session cache, unless
+// session tickets are disabled.
+func (c *Client) configureSessionResumption() {
+	if c.transport.TLSClientConfig == nil {
+		c.transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	if c.sessionTicketsDisabled {
+		c.transport.TLSClientConfig.SessionTicketsDisabled = true
+		c.transport.TLSClientConfig.ClientSessionCache = nil
+
+		return
+	}
+
+	if c.sessionCache == nil {
+		c.sessionCache = tls.NewLRUClientSessionCache(defaultSessionCacheSize)
+	}
+
+	c.transport.TLSClientConfig.ClientSessionCache = c.sessionCache
+}
+
+// configureNextProtos sets the ALPN protocols if given, disabling
+// HTTP/2 if they leave it out as the transport would offer it anyway.
+func (c *Client) configureNextProtos() {
+	if c.nextProtos == nil {
+		return
+	}
+
+	c.transport.TLSClientConfig.NextProtos = c.nextProtos
+
+	if !slices.Contains(c.nextProtos, http2Proto) {
+		c.http2 = false
+	}
+}
+
+// configureHTTP2 enables or disables HTTP/2 over TLS on the transport.
+// A custom TLS config, as with mTLS, otherwise disables it implicitly.
+func (c *Client) configureHTTP2() {
+	if c.http2 {
+		c.transport.ForceAttemptHTTP2 = true
+		return
+	}
+
+	c.transport.ForceAttemptHTTP2 = false
+	// A non-nil empty map prevents the transport from upgrading to HTTP/2
+	c.transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+
+	// The TLS config cloned from the default transport may already offer h2 via ALPN
+	if c.transport.TLSClientConfig != nil {
+		c.transport.TLSClientConfig.NextProtos = slices.DeleteFunc(
+			slices.Clone(c.transport.TLSClientConfig.NextProtos),
+			func(proto string) bool { return proto == http2Proto },
+		)
+	}
 }
 
-// GetUser retrieves a SCIM user by its ID.
+// newTransport returns a dedicated copy of the default transport
+// so that closing a client does not affect any other.
+func newTransport() *http.Transport {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return &http.Transport{}
+	}
+
+	return transport.Clone()
+}
+
+// Close stops the background work of the client, waiting for it to
+// return, and releases the idle connections held by its transport.
+// It is safe to call multiple times.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		c.cancelBackground()
+		c.background.Wait()
+		c.httpClient.CloseIdleConnections()
+	})
+}
+
+// GetUser retrieves a SCIM user by its ID, returning only the
+// attributes selected by the projection of the params, if any.
 func (c *Client) GetUser(ctx context.Context, id string, params RequestParams) (*User, error) {
+	queryString := ptr.String(projectionQuery(params.Attributes, params.ExcludedAttributes).Encode())
+
 	resp, err := c.baseCreateAndExecuteHTTPRequest(
-		ctx, params.Host, http.MethodGet, BasePathUsers+"/"+id, nil, nil, params.Headers,
+		ctx, params.Host, http.MethodGet, BasePathUsers+"/"+id, queryString, nil, params.Headers,
 	)
 
 	if resp != nil {
@@ -118,11 +334,23 @@ func (c *Client) GetUser(ctx context.Context, id string, params RequestParams) (
 		return nil, errs.Wrap(ErrGetUser, err)
 	}
 
-	user, err := httpclient.DecodeResponse[User](ctx, "SCIM", resp, http.StatusOK)
+	err = c.normalizeResponseCase(resp)
+	if err != nil {
+		return nil, errs.Wrap(ErrGetUser, err)
+	}
+
+	user, err := httpclient.DecodeResponse[User](ctx, "SCIM", resp, http.StatusOK, c.decodeOptions()...)
+	if err != nil {
+		return nil, errs.Wrap(ErrGetUser, err)
+	}
+
+	err = c.validateSchema(user.Schemas, UserSchema)
 	if err != nil {
 		return nil, errs.Wrap(ErrGetUser, err)
 	}
 
+	user.ResolveID()
+
 	return user, nil
 }
 
@@ -142,11 +370,39 @@ func (c *Client) ListUsers(ctx context.Context, params RequestParams) (*UserList
 		}
 	}()
 
-	users, err := httpclient.DecodeResponse[UserList](ctx, "SCIM", resp, http.StatusOK)
+	if c.isEmptyListResponse(resp) {
+		return &UserList{}, nil
+	}
+
+	err = c.renameListResourcesKey(resp)
+	if err != nil {
+		return nil, errs.Wrap(ErrListUsers, err)
+	}
+
+	err = c.normalizeResponseCase(resp)
+	if err != nil {
+		return nil, errs.Wrap(ErrListUsers, err)
+	}
+
+	users, err := httpclient.DecodeResponse[UserList](ctx, "SCIM", resp, http.StatusOK, c.decodeOptions()...)
+	if err != nil {
+		return nil, errs.Wrap(ErrListUsers, err)
+	}
+
+	err = c.validateListSchema(users.Schemas)
 	if err != nil {
 		return nil, errs.Wrap(ErrListUsers, err)
 	}
 
+	for i := range users.Resources {
+		err = c.validateSchema(users.Resources[i].Schemas, UserSchema)
+		if err != nil {
+			return nil, errs.Wrap(ErrListUsers, err)
+		}
+
+		users.Resources[i].ResolveID()
+	}
+
 	return users, nil
 }
 
@@ -157,12 +413,13 @@ func (c *Client) GetGroup(
 	groupMemberAttribute string,
 	params RequestParams,
 ) (*Group, error) {
-	var queryString *string
-
+	attributes := params.Attributes
 	if groupMemberAttribute != "" {
-		queryString = pointers.String("attributes=" + groupMemberAttribute)
+		attributes = append([]string{groupMemberAttribute}, attributes...)
 	}
 
+	queryString := ptr.String(projectionQuery(attributes, params.ExcludedAttributes).Encode())
+
 	resp, err := c.baseCreateAndExecuteHTTPRequest(
 		ctx, params.Host, http.MethodGet, BasePathGroups+"/"+id, queryString, nil, params.Headers,
 	)
@@ -180,14 +437,50 @@ func (c *Client) GetGroup(
 		return nil, errs.Wrap(ErrGetGroup, err)
 	}
 
-	group, err := httpclient.DecodeResponse[Group](ctx, "SCIM", resp, http.StatusOK)
+	err = c.normalizeResponseCase(resp)
+	if err != nil {
+		return nil, errs.Wrap(ErrGetGroup, err)
+	}
+
+	group, err := httpclient.DecodeResponse[Group](ctx, "SCIM", resp, http.StatusOK, c.decodeOptions()...)
+	if err != nil {
+		return nil, errs.Wrap(ErrGetGroup, err)
+	}
+
+	err = c.validateSchema(group.Schemas, GroupSchema)
 	if err != nil {
 		return nil, errs.Wrap(ErrGetGroup, err)
 	}
 
+	group.ResolveID()
+
 	return group, nil
 }
 
+// GetGroupMembers retrieves the member references of a SCIM group by its
+// ID, with only the member attribute projected and without resolving the
+// members. The member attribute defaults to members if empty.
+func (c *Client) GetGroupMembers(
+	ctx context.Context,
+	id string,
+	groupMemberAttribute string,
+	params RequestParams,
+) ([]MultiValuedAttribute, error) {
+	if groupMemberAttribute == "" {
+		groupMemberAttribute = defaultGroupMembersAttribute
+	}
+
+	params.Attributes = nil
+	params.ExcludedAttributes = nil
+
+	group, err := c.GetGroup(ctx, id, groupMemberAttribute, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return group.Members, nil
+}
+
 // ListGroups retrieves a list of SCIM groups.
 // It supports filtering, pagination (using cursor), and count parameters.
 // The useHTTPPost parameter determines whether to use POST method + /.search path for the request.
@@ -210,27 +503,197 @@ func (c *Client) ListGroups(
 		return nil, errs.Wrap(ErrListGroups, err)
 	}
 
-	groups, err := httpclient.DecodeResponse[GroupList](ctx, "SCIM", resp, http.StatusOK)
+	if c.isEmptyListResponse(resp) {
+		return &GroupList{}, nil
+	}
+
+	err = c.renameListResourcesKey(resp)
+	if err != nil {
+		return nil, errs.Wrap(ErrListGroups, err)
+	}
+
+	err = c.normalizeResponseCase(resp)
 	if err != nil {
 		return nil, errs.Wrap(ErrListGroups, err)
 	}
 
+	groups, err := httpclient.DecodeResponse[GroupList](ctx, "SCIM", resp, http.StatusOK, c.decodeOptions()...)
+	if err != nil {
+		return nil, errs.Wrap(ErrListGroups, err)
+	}
+
+	err = c.validateListSchema(groups.Schemas)
+	if err != nil {
+		return nil, errs.Wrap(ErrListGroups, err)
+	}
+
+	for i := range groups.Resources {
+		err = c.validateSchema(groups.Resources[i].Schemas, GroupSchema)
+		if err != nil {
+			return nil, errs.Wrap(ErrListGroups, err)
+		}
+
+		groups.Resources[i].ResolveID()
+	}
+
 	return groups, nil
 }
 
 func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
-	if req.Method == http.MethodPost || req.Method == http.MethodPut || req.Method == http.MethodPatch {
+	// Per-request headers take precedence over context ones and the client defaults
+	if c.contextHeaders != nil {
+		for key, value := range c.contextHeaders(req.Context()) {
+			if req.Header.Get(key) == "" {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+
+	for key, value := range c.defaultHeaders {
+		if req.Header.Get(key) == "" {
+			req.Header.Set(key, value)
+		}
+	}
+
+	if hasRequestBody(req.Method) {
 		req.Header.Set("Content-Type", ApplicationSCIMJson)
 	}
 
 	req.Header.Set("Accept", ApplicationSCIMJson)
 
-	if c.basicAuth != nil {
-		basicCreds := []byte(c.basicAuth.clientID + ":" + c.basicAuth.clientSecret)
-		req.Header.Set(HeaderAuthorization, "Basic "+base64.RawStdEncoding.EncodeToString(basicCreds))
+	// Keep a User-Agent explicitly passed in the request headers
+	if req.Header.Get(HeaderUserAgent) == "" {
+		req.Header.Set(HeaderUserAgent, c.userAgent)
 	}
 
-	return c.httpClient.Do(req)
+	if c.credentials != nil {
+		clientID, clientSecret, err := c.credentials.Credentials(req.Context())
+		if err != nil {
+			return nil, errs.Wrap(ErrLoadCredentials, err)
+		}
+
+		basicCreds := []byte(clientID + ":" + clientSecret)
+		req.Header.Set(HeaderAuthorization, "Basic "+base64.StdEncoding.EncodeToString(basicCreds))
+	}
+
+	if c.oauth2 != nil {
+		token, err := c.accessToken(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set(HeaderAuthorization, "Bearer "+token)
+	}
+
+	if c.etags != nil && req.Method == http.MethodGet {
+		return c.doCachedRequest(req)
+	}
+
+	return c.doWithRetries(req)
+}
+
+// doWithRetries executes the request, retrying it as configured.
+func (c *Client) doWithRetries(req *http.Request) (*http.Response, error) {
+	breaker := c.breakerFor(req.URL.Host)
+
+	for attempt := 0; ; attempt++ {
+		err := c.waitRateLimit(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Wait for a slot before taking a half-open probe, which would
+		// otherwise be lost if the context ends while waiting
+		release, err := c.acquireInFlight(req)
+		if err != nil {
+			return nil, err
+		}
+
+		allowed, probe := true, false
+		if breaker != nil {
+			allowed, probe = breaker.allow()
+		}
+
+		if !allowed {
+			release()
+			c.logger.Debug("SCIM request rejected by open circuit breaker", "host", req.URL.Host)
+
+			return nil, ErrCircuitOpen
+		}
+
+		start := time.Now()
+		resp, err := c.httpClient.Do(req)
+		c.observe(req, attempt, start, resp, err)
+
+		// A response returned with an error already has its body closed
+		if err == nil {
+			resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+		} else {
+			release()
+		}
+
+		// A request the caller gave up on tells nothing about the server
+		if err != nil && req.Context().Err() != nil {
+			if probe {
+				breaker.abandonProbe()
+			}
+
+			return resp, err
+		}
+
+		retryable := isRetryable(resp, err)
+
+		if breaker != nil && breaker.record(retryable) {
+			c.logger.Warn("SCIM circuit breaker opened",
+				"host", req.URL.Host, "threshold", c.breakerThreshold, "cooldown", c.breakerCooldown)
+		}
+
+		if !retryable || !isIdempotent(req) {
+			return resp, err
+		}
+
+		if !c.acquireRetry(req.Context(), attempt) {
+			if c.maxRetries > 0 {
+				c.logger.Warn("Giving up retrying SCIM request", requestLogArgs(req, attempt, resp, err)...)
+			}
+
+			return resp, err
+		}
+
+		c.logger.Debug("Retrying SCIM request",
+			append(requestLogArgs(req, attempt, resp, err), "backoff", c.backoff(attempt))...)
+
+		if resp != nil {
+			c.closeBody(resp, "retried")
+		}
+
+		err = c.waitBackoff(req.Context(), attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// requestLogArgs returns the log arguments describing a failed request attempt.
+func requestLogArgs(req *http.Request, attempt int, resp *http.Response, err error) []any {
+	args := []any{"method", req.Method, "url", req.URL.Redacted(), "attempt", attempt}
+
+	if resp != nil {
+		args = append(args, "status", resp.StatusCode)
+	}
+
+	if err != nil {
+		args = append(args, "error", err)
+	}
+
+	return args
 }
 
 func (c *Client) baseCreateAndExecuteHTTPRequest(
@@ -239,14 +702,26 @@ func (c *Client) baseCreateAndExecuteHTTPRequest(
 	method string,
 	resourcePath string,
 	queryString *string,
-	body io.Reader,
+	body []byte,
 	headers map[string]string,
 ) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, method, host+resourcePath, body)
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, host+resourcePath, reader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// Let retries and redirects replay the buffered body
+	if body != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
 	if queryString != nil {
 		req.URL.RawQuery = *queryString
 	}
@@ -260,6 +735,11 @@ func (c *Client) baseCreateAndExecuteHTTPRequest(
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 
+	err = captureRawBody(ctx, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
 	return resp, nil
 }
 
@@ -274,26 +754,63 @@ func (c *Client) createAndExecuteHTTPRequest(
 	basePath string,
 ) (*http.Response, error) {
 	resourcePath := basePath + "/"
+	method := listRequestMethod(params)
+
+	if c.minimalFilterParens && !isNullFilter(params.Filter) {
+		params.Filter = MinimalParentheses{Expression: params.Filter}
+	}
 
 	var (
-		body        io.Reader
+		body        []byte
 		queryString string
 	)
 
-	if params.Method == http.MethodPost || params.Method == http.MethodPut || params.Method == http.MethodPatch {
+	if !hasRequestBody(method) {
+		queryString = buildQueryStringFromParams(params, c.pagination, c.minimalFilterEncoding)
+
+		// Large filters may exceed server URL length limits, so send them in the body instead
+		if c.maxQueryLength > 0 && len(queryString) > c.maxQueryLength {
+			method = http.MethodPost
+			queryString = ""
+		}
+	}
+
+	if hasRequestBody(method) {
 		resourcePath += PostSearchPath
 
 		var err error
 
-		body, err = buildBodyFromParams(params.Filter, params.Count, params.Cursor)
+		body, err = buildBodyFromParams(params, c.pagination)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build request: %w", err)
 		}
-	} else {
-		queryString = buildQueryStringFromParams(params.Filter, params.Cursor, params.Count)
 	}
 
 	return c.baseCreateAndExecuteHTTPRequest(
-		ctx, params.Host, params.Method, resourcePath, pointers.String(queryString), body, params.Headers,
+		ctx, params.Host, method, resourcePath, ptr.String(queryString), body, params.Headers,
 	)
 }
+
+// isEmptyListResponse reports whether a list response is to be treated
+// as matching no resources despite its status.
+func (c *Client) isEmptyListResponse(resp *http.Response) bool {
+	return c.notFoundAsEmpty && resp.StatusCode == http.StatusNotFound
+}
+
+func hasRequestBody(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+// listRequestMethod returns the HTTP method of a list request,
+// applying the UseSearchPost override if set.
+func listRequestMethod(params RequestParams) string {
+	if params.UseSearchPost == nil {
+		return params.Method
+	}
+
+	if *params.UseSearchPost {
+		return http.MethodPost
+	}
+
+	return http.MethodGet
+}
@@ -1,15 +1,26 @@
 package scim_test
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/openkcm/common-sdk/pkg/commoncfg"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
 )
 
 const (
@@ -50,7 +61,13 @@ var (
 		BaseResource: scim.BaseResource{
 			ID:         "d1a6888d-7fd5-4c3f-ae33-177b24aae627",
 			ExternalID: "",
-			Meta:       struct{}{},
+			Meta: scim.Meta{
+				ResourceType: "User",
+				Created:      "2020-04-10T11:29:36Z",
+				LastModified: "2021-05-18T15:18:00Z",
+				Location:     "https://a2e15w1y0.accounts400.ondemand.com/scim/Users/d1a6888d-7fd5-4c3f-ae33-177b24aae627",
+				GroupsCount:  ptr.To(0),
+			},
 			Schemas: []string{
 				"urn:ietf:params:scim:schemas:core:2.0:User",
 				"urn:ietf:params:scim:schemas:extension:sap:2.0:User",
@@ -74,12 +91,25 @@ var (
 			},
 		},
 		UserType: "employee",
+		SAP: &scim.SAPUserExtension{
+			Status: "active",
+			PasswordDetails: scim.SAPPasswordDetails{
+				Status:              "initial",
+				FailedLoginAttempts: 0,
+			},
+		},
 	}
 	ExpectedGroup = scim.Group{
 		BaseResource: scim.BaseResource{
 			ID:         "16e720aa-a009-4949-9bf9-847fb0660522",
 			ExternalID: "",
-			Meta:       struct{}{},
+			Meta: scim.Meta{
+				ResourceType: "Group",
+				Created:      "2020-11-12T14:55:12Z",
+				LastModified: "2021-03-31T14:56:01Z",
+				Location:     "https://a2e15w1y0.accounts400.ondemand.com/scim/Groups/16e720aa-a009-4949-9bf9-847fb0660522",
+				Version:      "f5c7bafe-b86f-4741-a35a-b53fe07b25e6",
+			},
 			Schemas: []string{
 				"urn:ietf:params:scim:schemas:core:2.0:Group",
 				"urn:sap:cloud:scim:schemas:extension:custom:2.0:Group",
@@ -88,6 +118,7 @@ var (
 		DisplayName: "KeyAdmin",
 		Members: []scim.MultiValuedAttribute{
 			{
+				Type:  "User",
 				Value: "700223c4-3b58-4358-8594-59d14e619f4a",
 			},
 		},
@@ -271,16 +302,32 @@ func TestListUsers(t *testing.T) {
 			name:           "Success GET",
 			responseStatus: http.StatusOK,
 			responseBody:   ListUsersResponse,
-			expectedUsers:  &scim.UserList{Resources: []scim.User{ExpectedUser}},
-			expectError:    false,
+			expectedUsers: &scim.UserList{
+				ListMeta: scim.ListMeta{
+					Schemas:      []string{scim.ListResponseSchema},
+					TotalResults: 1,
+					StartIndex:   1,
+					ItemsPerPage: 1,
+				},
+				Resources: []scim.User{ExpectedUser},
+			},
+			expectError: false,
 		},
 		{
 			name:           "Success POST",
 			method:         http.MethodPost,
 			responseStatus: http.StatusOK,
 			responseBody:   ListUsersResponse,
-			expectedUsers:  &scim.UserList{Resources: []scim.User{ExpectedUser}},
-			expectError:    false,
+			expectedUsers: &scim.UserList{
+				ListMeta: scim.ListMeta{
+					Schemas:      []string{scim.ListResponseSchema},
+					TotalResults: 1,
+					StartIndex:   1,
+					ItemsPerPage: 1,
+				},
+				Resources: []scim.User{ExpectedUser},
+			},
+			expectError: false,
 		},
 		{
 			name:           "Invalid JSON",
@@ -381,6 +428,123 @@ func TestGetGroup(t *testing.T) {
 	}
 }
 
+func TestGetGroupMembers(t *testing.T) {
+	tests := []struct {
+		name                 string
+		groupMemberAttribute string
+		responseStatus       int
+		responseBody         string
+		expectedAttributes   string
+		expectedMembers      []scim.MultiValuedAttribute
+		expectedError        error
+	}{
+		{
+			name:           "Members without expansion",
+			responseStatus: http.StatusOK,
+			responseBody: `{"id":"123","members":[` +
+				`{"value":"user1","type":"User","display":"Alice"},` +
+				`{"value":"group2","type":"Group","display":"Admins"}]}`,
+			expectedAttributes: "members",
+			expectedMembers: []scim.MultiValuedAttribute{
+				{Value: "user1", Type: "User", Display: "Alice"},
+				{Value: "group2", Type: "Group", Display: "Admins"},
+			},
+		},
+		{
+			name:                 "Member sub-attribute projected",
+			groupMemberAttribute: "members.value",
+			responseStatus:       http.StatusOK,
+			responseBody:         `{"id":"123","members":[{"value":"user1"}]}`,
+			expectedAttributes:   "members.value",
+			expectedMembers:      []scim.MultiValuedAttribute{{Value: "user1"}},
+		},
+		{
+			name:               "No members",
+			responseStatus:     http.StatusOK,
+			responseBody:       `{"id":"123"}`,
+			expectedAttributes: "members",
+		},
+		{
+			name:               "Group not found",
+			responseStatus:     http.StatusNotFound,
+			responseBody:       `{"detail":"Group not found"}`,
+			expectedAttributes: "members",
+			expectedError:      scim.ErrGetGroup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				assert.Equal(t, "/Groups/123", r.URL.Path)
+				assert.Equal(t, tt.expectedAttributes, r.URL.Query().Get("attributes"))
+				assert.Empty(t, r.URL.Query().Get("excludedAttributes"))
+
+				w.WriteHeader(tt.responseStatus)
+				_, err := w.Write([]byte(tt.responseBody))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			members, err := getBasicClient().GetGroupMembers(
+				t.Context(), "123", tt.groupMemberAttribute,
+				scim.RequestParams{
+					Host:               server.URL,
+					Attributes:         []string{"displayName"},
+					ExcludedAttributes: []string{"members"},
+				},
+			)
+
+			// A single group request, without per-member user requests
+			assert.Equal(t, int32(1), requests.Load())
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMembers, members)
+		})
+	}
+}
+
+func TestIDFromLocation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		switch r.URL.Path {
+		case "/Users/456":
+			_, err = w.Write([]byte(`{"meta":{"location":"https://scim.example.com/Users/456"},"userName":"alice"}`))
+		case "/Groups/":
+			_, err = w.Write([]byte(`{"totalResults":2,"Resources":[` +
+				`{"meta":{"location":"https://scim.example.com/Groups/g1"},"displayName":"A"},` +
+				`{"id":"g2","meta":{"location":"https://scim.example.com/Groups/other"},"displayName":"B"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := getBasicClient()
+	params := scim.RequestParams{Host: server.URL}
+
+	user, err := client.GetUser(t.Context(), "456", params)
+	assert.NoError(t, err)
+	assert.Equal(t, "456", user.ID)
+
+	groups, err := client.ListGroups(t.Context(), params)
+	assert.NoError(t, err)
+	assert.Len(t, groups.Resources, 2)
+	assert.Equal(t, "g1", groups.Resources[0].ID)
+	assert.Equal(t, "g2", groups.Resources[1].ID)
+}
+
 func TestListGroups(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -395,16 +559,32 @@ func TestListGroups(t *testing.T) {
 			name:           "Success GET",
 			responseStatus: http.StatusOK,
 			responseBody:   ListGroupsResponse,
-			expectedGroups: &scim.GroupList{Resources: []scim.Group{ExpectedGroup}},
-			expectError:    false,
+			expectedGroups: &scim.GroupList{
+				ListMeta: scim.ListMeta{
+					Schemas:      []string{scim.ListResponseSchema},
+					TotalResults: 36,
+					StartIndex:   1,
+					ItemsPerPage: 100,
+				},
+				Resources: []scim.Group{ExpectedGroup},
+			},
+			expectError: false,
 		},
 		{
 			name:           "Success POST",
 			method:         http.MethodPost,
 			responseStatus: http.StatusOK,
 			responseBody:   ListGroupsResponse,
-			expectedGroups: &scim.GroupList{Resources: []scim.Group{ExpectedGroup}},
-			expectError:    false,
+			expectedGroups: &scim.GroupList{
+				ListMeta: scim.ListMeta{
+					Schemas:      []string{scim.ListResponseSchema},
+					TotalResults: 36,
+					StartIndex:   1,
+					ItemsPerPage: 100,
+				},
+				Resources: []scim.Group{ExpectedGroup},
+			},
+			expectError: false,
 		},
 		{
 			name:           "Invalid JSON",
@@ -445,3 +625,954 @@ func TestListGroups(t *testing.T) {
 		})
 	}
 }
+
+func TestClose(t *testing.T) {
+	closed := make(chan struct{}, 1)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case closed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	server.Start()
+
+	defer server.Close()
+
+	client := getBasicClient()
+
+	_, err := client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+	assert.NoError(t, err)
+
+	client.Close()
+	client.Close()
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("idle connection was not closed")
+	}
+}
+
+func TestUseSearchPostOverride(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		useSearchPost  *bool
+		expectedMethod string
+		expectedPath   string
+	}{
+		{
+			name:           "No override uses GET",
+			method:         http.MethodGet,
+			expectedMethod: http.MethodGet,
+			expectedPath:   scim.BasePathUsers + "/",
+		},
+		{
+			name:           "No override uses POST",
+			method:         http.MethodPost,
+			expectedMethod: http.MethodPost,
+			expectedPath:   scim.BasePathUsers + "/" + scim.PostSearchPath,
+		},
+		{
+			name:           "Override GET to POST",
+			method:         http.MethodGet,
+			useSearchPost:  ptr.To(true),
+			expectedMethod: http.MethodPost,
+			expectedPath:   scim.BasePathUsers + "/" + scim.PostSearchPath,
+		},
+		{
+			name:           "Override POST to GET",
+			method:         http.MethodPost,
+			useSearchPost:  ptr.To(false),
+			expectedMethod: http.MethodGet,
+			expectedPath:   scim.BasePathUsers + "/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedMethod, r.Method)
+				assert.Equal(t, tt.expectedPath, r.URL.Path)
+
+				_, err := w.Write([]byte(ListUsersResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			_, err := getBasicClient().ListUsers(t.Context(), scim.RequestParams{
+				Host:          server.URL,
+				Method:        tt.method,
+				Filter:        scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "john"},
+				UseSearchPost: tt.useSearchPost,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMaxQueryLength(t *testing.T) {
+	const maxQueryLength = 100
+
+	shortFilter := scim.FilterComparison{Attribute: "id", Operator: scim.FilterOperatorEqual, Value: "1"}
+
+	expressions := make([]scim.FilterExpression, 10)
+	for i := range expressions {
+		expressions[i] = scim.FilterComparison{
+			Attribute: "id",
+			Operator:  scim.FilterOperatorEqual,
+			Value:     "d1a6888d-7fd5-4c3f-ae33-177b24aae627",
+		}
+	}
+
+	longFilter := scim.FilterLogicalGroupOr{Expressions: expressions}
+
+	tests := []struct {
+		name           string
+		filter         scim.FilterExpression
+		expectedMethod string
+		expectedPath   string
+	}{
+		{
+			name:           "Short filter stays in the query string",
+			filter:         shortFilter,
+			expectedMethod: http.MethodGet,
+			expectedPath:   scim.BasePathUsers + "/",
+		},
+		{
+			name:           "Long filter switches to POST",
+			filter:         longFilter,
+			expectedMethod: http.MethodPost,
+			expectedPath:   scim.BasePathUsers + "/" + scim.PostSearchPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedMethod, r.Method)
+				assert.Equal(t, tt.expectedPath, r.URL.Path)
+
+				if r.Method == http.MethodPost {
+					assert.Empty(t, r.URL.RawQuery)
+
+					var searchRequest scim.SearchRequest
+					assert.NoError(t, json.NewDecoder(r.Body).Decode(&searchRequest))
+					assert.Equal(t, tt.filter.ToString(), *searchRequest.Filter)
+				}
+
+				_, err := w.Write([]byte(ListUsersResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithMaxQueryLength(maxQueryLength),
+			)
+			assert.NoError(t, err)
+
+			_, err = client.ListUsers(t.Context(), scim.RequestParams{
+				Host:   server.URL,
+				Method: http.MethodGet,
+				Filter: tt.filter,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMinimalFilterEncoding(t *testing.T) {
+	filter := scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "john doe&co"}
+
+	tests := []struct {
+		name          string
+		opts          []scim.Option
+		count         *int
+		startIndex    *int
+		expectedQuery string
+	}{
+		{
+			name:          "Encoded filter",
+			expectedQuery: `filter=userName+eq+%22john+doe%26co%22`,
+		},
+		{
+			name:          "Minimally encoded filter",
+			opts:          []scim.Option{scim.WithMinimalFilterEncoding()},
+			expectedQuery: `filter=userName%20eq%20"john%20doe%26co"`,
+		},
+		{
+			name:          "Minimally encoded filter with other parameters",
+			opts:          []scim.Option{scim.WithMinimalFilterEncoding()},
+			count:         ptr.To(10),
+			expectedQuery: `count=10&filter=userName%20eq%20"john%20doe%26co"`,
+		},
+		{
+			name:          "Encoded filter between other parameters",
+			count:         ptr.To(10),
+			startIndex:    ptr.To(21),
+			expectedQuery: `count=10&filter=userName+eq+%22john+doe%26co%22&startIndex=21`,
+		},
+		{
+			name:          "Minimally encoded filter between other parameters",
+			opts:          []scim.Option{scim.WithMinimalFilterEncoding()},
+			count:         ptr.To(10),
+			startIndex:    ptr.To(21),
+			expectedQuery: `count=10&filter=userName%20eq%20"john%20doe%26co"&startIndex=21`,
+		},
+		{
+			name: "Minimally encoded filter between renamed parameters",
+			opts: []scim.Option{
+				scim.WithMinimalFilterEncoding(),
+				scim.WithPaginationParams(scim.PaginationParams{Count: "limit", StartIndex: "offset"}),
+			},
+			count:         ptr.To(10),
+			startIndex:    ptr.To(21),
+			expectedQuery: `filter=userName%20eq%20"john%20doe%26co"&limit=10&offset=21`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedQuery, r.URL.RawQuery)
+				assert.Equal(t, filter.ToString(), r.URL.Query().Get("filter"))
+
+				_, err := w.Write([]byte(ListUsersResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			_, err = client.ListUsers(t.Context(), scim.RequestParams{
+				Host:       server.URL,
+				Method:     http.MethodGet,
+				Filter:     filter,
+				Count:      tt.count,
+				StartIndex: tt.startIndex,
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAttributeProjection(t *testing.T) {
+	filter := scim.FilterComparison{Attribute: "displayName", Operator: scim.FilterOperatorEqual, Value: "KeyAdmin"}
+
+	tests := []struct {
+		name                       string
+		method                     string
+		getGroup                   bool
+		groupMemberAttribute       string
+		attributes                 []string
+		excludedAttributes         []string
+		expectedAttributes         string
+		expectedExcludedAttributes string
+		expectedBody               string
+	}{
+		{
+			name:                       "GET list excluding members",
+			method:                     http.MethodGet,
+			excludedAttributes:         []string{"members"},
+			expectedExcludedAttributes: "members",
+		},
+		{
+			name:               "GET list with attributes",
+			method:             http.MethodGet,
+			attributes:         []string{"id", "displayName"},
+			expectedAttributes: "id,displayName",
+		},
+		{
+			name:               "POST list excluding members",
+			method:             http.MethodPost,
+			excludedAttributes: []string{"members"},
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:SearchRequest"],` +
+				`"filter":"displayName eq \"KeyAdmin\"","excludedAttributes":["members"]}`,
+		},
+		{
+			name:                       "Get group excluding members",
+			getGroup:                   true,
+			excludedAttributes:         []string{"members"},
+			expectedExcludedAttributes: "members",
+		},
+		{
+			name:                 "Get group members",
+			getGroup:             true,
+			groupMemberAttribute: "members",
+			expectedAttributes:   "members",
+		},
+		{
+			name:                 "Get group members with attributes",
+			getGroup:             true,
+			groupMemberAttribute: "members",
+			attributes:           []string{"displayName"},
+			expectedAttributes:   "members,displayName",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedAttributes, r.URL.Query().Get("attributes"))
+				assert.Equal(t, tt.expectedExcludedAttributes, r.URL.Query().Get("excludedAttributes"))
+
+				if tt.expectedBody != "" {
+					body, err := io.ReadAll(r.Body)
+					assert.NoError(t, err)
+					assert.JSONEq(t, tt.expectedBody, string(body))
+				}
+
+				response := ListGroupsResponse
+				if tt.getGroup {
+					response = GetGroupResponse
+				}
+
+				_, err := w.Write([]byte(response))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client := getBasicClient()
+			params := scim.RequestParams{
+				Host:               server.URL,
+				Method:             tt.method,
+				Filter:             filter,
+				Attributes:         tt.attributes,
+				ExcludedAttributes: tt.excludedAttributes,
+			}
+
+			var err error
+			if tt.getGroup {
+				_, err = client.GetGroup(t.Context(), "123", tt.groupMemberAttribute, params)
+			} else {
+				_, err = client.ListGroups(t.Context(), params)
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNotFoundAsEmpty(t *testing.T) {
+	tests := []struct {
+		name            string
+		notFoundAsEmpty bool
+		call            func(client *scim.Client, params scim.RequestParams) (int, error)
+		expectedError   error
+	}{
+		{
+			name: "List users without option",
+			call: func(client *scim.Client, params scim.RequestParams) (int, error) {
+				users, err := client.ListUsers(t.Context(), params)
+				if err != nil {
+					return 0, err
+				}
+
+				return len(users.Resources), nil
+			},
+			expectedError: scim.ErrListUsers,
+		},
+		{
+			name:            "List users with option",
+			notFoundAsEmpty: true,
+			call: func(client *scim.Client, params scim.RequestParams) (int, error) {
+				users, err := client.ListUsers(t.Context(), params)
+				if err != nil {
+					return 0, err
+				}
+
+				return len(users.Resources), nil
+			},
+		},
+		{
+			name: "List groups without option",
+			call: func(client *scim.Client, params scim.RequestParams) (int, error) {
+				groups, err := client.ListGroups(t.Context(), params)
+				if err != nil {
+					return 0, err
+				}
+
+				return len(groups.Resources), nil
+			},
+			expectedError: scim.ErrListGroups,
+		},
+		{
+			name:            "List groups with option",
+			notFoundAsEmpty: true,
+			call: func(client *scim.Client, params scim.RequestParams) (int, error) {
+				groups, err := client.ListGroups(t.Context(), params)
+				if err != nil {
+					return 0, err
+				}
+
+				return len(groups.Resources), nil
+			},
+		},
+		{
+			name:            "Get user with option",
+			notFoundAsEmpty: true,
+			call: func(client *scim.Client, params scim.RequestParams) (int, error) {
+				_, err := client.GetUser(t.Context(), "123", params)
+				return 0, err
+			},
+			expectedError: scim.ErrGetUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, http.StatusNotFound, `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"]}`)
+			defer server.Close()
+
+			var opts []scim.Option
+			if tt.notFoundAsEmpty {
+				opts = append(opts, scim.WithNotFoundAsEmpty())
+			}
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				opts...,
+			)
+			assert.NoError(t, err)
+
+			count, err := tt.call(client, scim.RequestParams{
+				Host:   server.URL,
+				Method: http.MethodGet,
+				Filter: scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "none"},
+			})
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Zero(t, count)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.ErrorIs(t, err, httpclient.ErrUnexpectedStatusCode)
+			}
+		})
+	}
+}
+
+func TestGetUserProjection(t *testing.T) {
+	const projectedUserResponse = `{"id":"123","userName":"john",` +
+		`"emails":[{"value":"john@example.com","primary":true}]}`
+
+	tests := []struct {
+		name               string
+		attributes         []string
+		expectedAttributes string
+	}{
+		{name: "Full user"},
+		{
+			name:               "Projected user",
+			attributes:         []string{"id", "userName", "emails"},
+			expectedAttributes: "id,userName,emails",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedAttributes, r.URL.Query().Get("attributes"))
+
+				_, err := w.Write([]byte(projectedUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			user, err := getBasicClient().GetUser(t.Context(), "123", scim.RequestParams{
+				Host:       server.URL,
+				Attributes: tt.attributes,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, "123", user.ID)
+			assert.Equal(t, "john", user.UserName)
+			assert.Equal(t, []scim.MultiValuedAttribute{{Value: "john@example.com", Primary: true}}, user.Emails)
+		})
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              []scim.Option
+		headers           map[string]string
+		expectedUserAgent string
+	}{
+		{
+			name:              "Default User-Agent",
+			expectedUserAgent: scim.DefaultUserAgent,
+		},
+		{
+			name:              "Overridden User-Agent",
+			opts:              []scim.Option{scim.WithUserAgent("custom-agent/1.0")},
+			expectedUserAgent: "custom-agent/1.0",
+		},
+		{
+			name:              "User-Agent from request headers",
+			opts:              []scim.Option{scim.WithUserAgent("custom-agent/1.0")},
+			headers:           map[string]string{scim.HeaderUserAgent: "per-request/2.0"},
+			expectedUserAgent: "per-request/2.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedUserAgent, r.UserAgent())
+
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL, Headers: tt.headers})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestHTTP2(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []scim.Option
+		expectedProtocol string
+	}{
+		{
+			name:             "HTTP/2 enabled by default",
+			expectedProtocol: "HTTP/2.0",
+		},
+		{
+			name:             "HTTP/2 disabled",
+			opts:             []scim.Option{scim.WithHTTP2(false)},
+			expectedProtocol: "HTTP/1.1",
+		},
+		{
+			name:             "HTTP/2 offered by ALPN",
+			opts:             []scim.Option{scim.WithNextProtos("h2", "http/1.1")},
+			expectedProtocol: "HTTP/2.0",
+		},
+		{
+			name:             "HTTP/2 left out of ALPN",
+			opts:             []scim.Option{scim.WithNextProtos("http/1.1")},
+			expectedProtocol: "HTTP/1.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedProtocol, r.Proto)
+
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			server.EnableHTTP2 = true
+			server.StartTLS()
+
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			pool := x509.NewCertPool()
+			pool.AddCert(server.Certificate())
+			client.SetRootCAs(pool)
+
+			_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNextProtos(t *testing.T) {
+	tests := []struct {
+		name               string
+		opts               []scim.Option
+		expectedNextProtos []string
+	}{
+		{
+			name:               "Protocols set",
+			opts:               []scim.Option{scim.WithNextProtos("h2", "http/1.1")},
+			expectedNextProtos: []string{"h2", "http/1.1"},
+		},
+		{
+			name:               "HTTP/2 removed when disabled",
+			opts:               []scim.Option{scim.WithNextProtos("h2", "http/1.1"), scim.WithHTTP2(false)},
+			expectedNextProtos: []string{"http/1.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedNextProtos, client.TLSClientConfig().NextProtos)
+		})
+	}
+}
+
+func TestInsecureSkipVerify(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []scim.Option
+		expectError bool
+	}{
+		{
+			name:        "Verified by default",
+			expectError: true,
+		},
+		{
+			name: "Verification skipped",
+			opts: []scim.Option{scim.WithInsecureSkipVerify()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+			if tt.expectError {
+				var certErr *tls.CertificateVerificationError
+				assert.ErrorAs(t, err, &certErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSessionResumption(t *testing.T) {
+	cache := tls.NewLRUClientSessionCache(1)
+
+	tests := []struct {
+		name                  string
+		opts                  []scim.Option
+		expectedCache         tls.ClientSessionCache
+		expectTicketsDisabled bool
+		expectNoCache         bool
+	}{
+		{
+			name: "LRU cache by default",
+		},
+		{
+			name:          "Custom cache",
+			opts:          []scim.Option{scim.WithClientSessionCache(cache)},
+			expectedCache: cache,
+		},
+		{
+			name:                  "Session tickets disabled",
+			opts:                  []scim.Option{scim.WithClientSessionCache(cache), scim.WithSessionTicketsDisabled(true)},
+			expectTicketsDisabled: true,
+			expectNoCache:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			config := client.TLSClientConfig()
+			assert.NotNil(t, config)
+			assert.Equal(t, tt.expectTicketsDisabled, config.SessionTicketsDisabled)
+
+			switch {
+			case tt.expectNoCache:
+				assert.Nil(t, config.ClientSessionCache)
+			case tt.expectedCache != nil:
+				assert.Same(t, tt.expectedCache, config.ClientSessionCache)
+			default:
+				assert.NotNil(t, config.ClientSessionCache)
+			}
+		})
+	}
+}
+
+func TestListResourcesKey(t *testing.T) {
+	listUsers := func(client *scim.Client, params scim.RequestParams) (int, error) {
+		users, err := client.ListUsers(t.Context(), params)
+		if err != nil {
+			return 0, err
+		}
+
+		return len(users.Resources), nil
+	}
+
+	tests := []struct {
+		name          string
+		responseBody  string
+		opts          []scim.Option
+		call          func(client *scim.Client, params scim.RequestParams) (int, error)
+		expectedCount int
+	}{
+		{
+			name:          "Standard key",
+			responseBody:  ListUsersResponse,
+			call:          listUsers,
+			expectedCount: 1,
+		},
+		{
+			name:          "Lowercase key",
+			responseBody:  strings.Replace(ListUsersResponse, `"Resources"`, `"resources"`, 1),
+			call:          listUsers,
+			expectedCount: 1,
+		},
+		{
+			name:          "Lowercase key with configured key",
+			responseBody:  strings.Replace(ListUsersResponse, `"Resources"`, `"resources"`, 1),
+			opts:          []scim.Option{scim.WithListResourcesKey("resources")},
+			call:          listUsers,
+			expectedCount: 1,
+		},
+		{
+			name:          "Custom key without option",
+			responseBody:  strings.Replace(ListUsersResponse, `"Resources"`, `"data"`, 1),
+			call:          listUsers,
+			expectedCount: 0,
+		},
+		{
+			name:          "Custom key with option",
+			responseBody:  strings.Replace(ListUsersResponse, `"Resources"`, `"data"`, 1),
+			opts:          []scim.Option{scim.WithListResourcesKey("data")},
+			call:          listUsers,
+			expectedCount: 1,
+		},
+		{
+			name:          "Standard key with custom key option",
+			responseBody:  ListUsersResponse,
+			opts:          []scim.Option{scim.WithListResourcesKey("data")},
+			call:          listUsers,
+			expectedCount: 1,
+		},
+		{
+			name:         "Groups with custom key",
+			responseBody: strings.Replace(ListGroupsResponse, `"Resources"`, `"data"`, 1),
+			opts:         []scim.Option{scim.WithListResourcesKey("data")},
+			call: func(client *scim.Client, params scim.RequestParams) (int, error) {
+				groups, err := client.ListGroups(t.Context(), params)
+				if err != nil {
+					return 0, err
+				}
+
+				return len(groups.Resources), nil
+			},
+			expectedCount: 1,
+		},
+		{
+			name:         "Search with custom key",
+			responseBody: strings.Replace(ListGroupsResponse, `"Resources"`, `"data"`, 1),
+			opts:         []scim.Option{scim.WithListResourcesKey("data")},
+			call: func(client *scim.Client, params scim.RequestParams) (int, error) {
+				resources, err := client.Search(t.Context(), params)
+				if err != nil {
+					return 0, err
+				}
+
+				return len(resources.Groups()), nil
+			},
+			expectedCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, http.StatusOK, tt.responseBody)
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			count, err := tt.call(client, scim.RequestParams{Host: server.URL, Method: http.MethodGet})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCount, count)
+		})
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultHeaders  map[string]string
+		contextHeaders  map[string]string
+		requestHeaders  map[string]string
+		expectedHeaders map[string]string
+	}{
+		{
+			name:            "Static headers",
+			defaultHeaders:  map[string]string{"X-Api-Key": "key", "X-Tenant": "tenant1"},
+			expectedHeaders: map[string]string{"X-Api-Key": "key", "X-Tenant": "tenant1"},
+		},
+		{
+			name:            "Overridden by request headers",
+			defaultHeaders:  map[string]string{"X-Api-Key": "key", "X-Tenant": "tenant1"},
+			requestHeaders:  map[string]string{"X-Tenant": "tenant2"},
+			expectedHeaders: map[string]string{"X-Api-Key": "key", "X-Tenant": "tenant2"},
+		},
+		{
+			name:            "Request headers matched case-insensitively",
+			defaultHeaders:  map[string]string{"X-Tenant": "tenant1"},
+			requestHeaders:  map[string]string{"x-tenant": "tenant2"},
+			expectedHeaders: map[string]string{"X-Tenant": "tenant2"},
+		},
+		{
+			name:            "Client headers take precedence",
+			defaultHeaders:  map[string]string{"Accept": "application/json"},
+			expectedHeaders: map[string]string{"Accept": scim.ApplicationSCIMJson},
+		},
+		{
+			name:            "Context headers",
+			contextHeaders:  map[string]string{"Traceparent": "00-trace-span-01"},
+			expectedHeaders: map[string]string{"Traceparent": "00-trace-span-01"},
+		},
+		{
+			name:            "Context headers between request and default headers",
+			defaultHeaders:  map[string]string{"X-Api-Key": "key", "X-Tenant": "tenant1"},
+			contextHeaders:  map[string]string{"X-Api-Key": "context", "X-Tenant": "tenant2"},
+			requestHeaders:  map[string]string{"X-Tenant": "tenant3"},
+			expectedHeaders: map[string]string{"X-Api-Key": "context", "X-Tenant": "tenant3"},
+		},
+		{
+			name:            "No default headers",
+			requestHeaders:  map[string]string{"X-Tenant": "tenant2"},
+			expectedHeaders: map[string]string{"X-Api-Key": "", "X-Tenant": "tenant2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.expectedHeaders {
+					assert.Equal(t, value, r.Header.Get(key), key)
+				}
+
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithDefaultHeaders(tt.defaultHeaders),
+				scim.WithContextHeaders(func(context.Context) map[string]string {
+					return tt.contextHeaders
+				}),
+			)
+			assert.NoError(t, err)
+
+			_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL, Headers: tt.requestHeaders})
+			assert.NoError(t, err)
+		})
+	}
+}
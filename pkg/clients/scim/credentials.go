@@ -0,0 +1,69 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+)
+
+var ErrLoadCredentials = errors.New("failed to load credentials")
+
+// CredentialProvider supplies the Basic auth credentials for a request.
+// It is invoked for every request so rotated credentials are picked up
+// without recreating the client.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (clientID string, clientSecret string, err error)
+}
+
+// SourceRefCredentialProvider loads Basic auth credentials from their
+// source references, optionally caching them for a fixed duration.
+type SourceRefCredentialProvider struct {
+	basic    commoncfg.BasicAuth
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu           sync.Mutex
+	clientID     string
+	clientSecret string
+	expiresAt    time.Time
+}
+
+var _ CredentialProvider = (*SourceRefCredentialProvider)(nil)
+
+// NewSourceRefCredentialProvider creates a provider reading the Basic auth
+// source references. A cacheTTL of zero reloads the credentials on every call.
+func NewSourceRefCredentialProvider(basic commoncfg.BasicAuth, cacheTTL time.Duration) *SourceRefCredentialProvider {
+	return &SourceRefCredentialProvider{
+		basic:    basic,
+		cacheTTL: cacheTTL,
+		now:      time.Now,
+	}
+}
+
+func (p *SourceRefCredentialProvider) Credentials(_ context.Context) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cacheTTL > 0 && p.now().Before(p.expiresAt) {
+		return p.clientID, p.clientSecret, nil
+	}
+
+	clientID, err := commoncfg.LoadValueFromSourceRef(p.basic.Username)
+	if err != nil {
+		return "", "", ErrClientID
+	}
+
+	clientSecret, err := commoncfg.LoadValueFromSourceRef(p.basic.Password)
+	if err != nil {
+		return "", "", ErrClientSecret
+	}
+
+	p.clientID = string(clientID)
+	p.clientSecret = string(clientSecret)
+	p.expiresAt = p.now().Add(p.cacheTTL)
+
+	return p.clientID, p.clientSecret, nil
+}
@@ -0,0 +1,183 @@
+package scim_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+const (
+	envClientID     = "SCIM_TEST_CLIENT_ID"
+	envClientSecret = "SCIM_TEST_CLIENT_SECRET"
+)
+
+type rotatingProvider struct {
+	credentials [][2]string
+	calls       int
+}
+
+func (p *rotatingProvider) Credentials(_ context.Context) (string, string, error) {
+	creds := p.credentials[p.calls%len(p.credentials)]
+	p.calls++
+
+	return creds[0], creds[1], nil
+}
+
+func envBasicAuth() commoncfg.BasicAuth {
+	return commoncfg.BasicAuth{
+		Username: commoncfg.SourceRef{
+			Source: commoncfg.EnvSourceValue,
+			Env:    envClientID,
+		},
+		Password: commoncfg.SourceRef{
+			Source: commoncfg.EnvSourceValue,
+			Env:    envClientSecret,
+		},
+	}
+}
+
+func TestSourceRefCredentialProvider(t *testing.T) {
+	t.Run("Reloads rotated credentials without cache", func(t *testing.T) {
+		provider := scim.NewSourceRefCredentialProvider(envBasicAuth(), 0)
+
+		t.Setenv(envClientID, "id1")
+		t.Setenv(envClientSecret, "secret1")
+
+		clientID, clientSecret, err := provider.Credentials(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "id1", clientID)
+		assert.Equal(t, "secret1", clientSecret)
+
+		t.Setenv(envClientID, "id2")
+		t.Setenv(envClientSecret, "secret2")
+
+		clientID, clientSecret, err = provider.Credentials(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "id2", clientID)
+		assert.Equal(t, "secret2", clientSecret)
+	})
+
+	t.Run("Serves cached credentials until the TTL expires", func(t *testing.T) {
+		now := time.Now()
+		provider := scim.NewSourceRefCredentialProvider(envBasicAuth(), time.Minute)
+		provider.SetNow(func() time.Time { return now })
+
+		t.Setenv(envClientID, "id1")
+		t.Setenv(envClientSecret, "secret1")
+
+		clientID, _, err := provider.Credentials(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "id1", clientID)
+
+		t.Setenv(envClientID, "id2")
+
+		clientID, _, err = provider.Credentials(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "id1", clientID)
+
+		now = now.Add(2 * time.Minute)
+
+		clientID, _, err = provider.Credentials(t.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "id2", clientID)
+	})
+
+	t.Run("Fails when the client id cannot be loaded", func(t *testing.T) {
+		provider := scim.NewSourceRefCredentialProvider(envBasicAuth(), 0)
+
+		t.Setenv(envClientID, "")
+
+		_, _, err := provider.Credentials(t.Context())
+		assert.ErrorIs(t, err, scim.ErrClientID)
+	})
+}
+
+func TestClientUsesCredentialProviderPerRequest(t *testing.T) {
+	var authHeaders []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authHeaders = append(authHeaders, r.Header.Get(scim.HeaderAuthorization))
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	provider := &rotatingProvider{credentials: [][2]string{{"id1", "secret1"}, {"id2", "secret2"}}}
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithCredentialProvider(provider),
+	)
+	assert.NoError(t, err)
+
+	for range 2 {
+		_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{
+		"Basic " + base64.StdEncoding.EncodeToString([]byte("id1:secret1")),
+		"Basic " + base64.StdEncoding.EncodeToString([]byte("id2:secret2")),
+	}, authHeaders)
+}
+
+func TestBasicAuthHeaderPadding(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		expected string
+	}{
+		{name: "No padding", username: "user", password: "pass", expected: "Basic dXNlcjpwYXNz"},
+		{name: "One padding character", username: "user", password: "secret", expected: "Basic dXNlcjpzZWNyZXQ="},
+		{name: "Two padding characters", username: "user", password: "passw", expected: "Basic dXNlcjpwYXNzdw=="},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expected, r.Header.Get(scim.HeaderAuthorization))
+
+				// The standard library parses Basic credentials as RFC 7617 padded base64
+				username, password, ok := r.BasicAuth()
+				assert.True(t, ok)
+				assert.Equal(t, tt.username, username)
+				assert.Equal(t, tt.password, password)
+
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: tt.username},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: tt.password},
+					},
+				},
+				getLogger(),
+			)
+			assert.NoError(t, err)
+
+			_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,16 +1,74 @@
 package scim
 
+import (
+	"net/url"
+	"strings"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
 //nolint:tagliatelle
 type BaseResource struct {
 	ID         string   `json:"id"`
 	ExternalID string   `json:"externalId,omitempty"`
-	Meta       struct{} `json:"meta"`
+	Meta       Meta     `json:"meta"`
 	Schemas    []string `json:"schemas,omitempty"`
 }
 
+// ResolveID sets the ID of the resource from the last segment of its
+// meta.location URL if it is empty, as some servers return only the
+// location. Locations ending in an empty segment or in a collection,
+// e.g. .../Users/, name no resource and are ignored.
+func (r *BaseResource) ResolveID() {
+	if r.ID != "" || r.Meta.Location == "" {
+		return
+	}
+
+	location, err := url.Parse(r.Meta.Location)
+	if err != nil {
+		return
+	}
+
+	segments := strings.Split(strings.TrimSuffix(location.Path, "/"), "/")
+
+	id := segments[len(segments)-1]
+	if id == "" || isCollectionName(id) {
+		return
+	}
+
+	r.ID = id
+}
+
+// isCollectionName reports whether the path segment names the Users or
+// Groups collection rather than a resource in it.
+func isCollectionName(segment string) bool {
+	return strings.EqualFold("/"+segment, BasePathUsers) || strings.EqualFold("/"+segment, BasePathGroups)
+}
+
+// resolveID resolves the ID of a decoded user or group.
+func resolveID(resource any) {
+	if r, ok := resource.(interface{ ResolveID() }); ok {
+		r.ResolveID()
+	}
+}
+
+type Meta struct {
+	ResourceType string `json:"resourceType,omitempty"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+	Version      string `json:"version,omitempty"`
+
+	// Counts of multi-valued attributes some servers report without
+	// returning the attributes themselves
+	GroupsCount  *int `json:"groups.cnt,omitempty"`
+	MembersCount *int `json:"members.cnt,omitempty"`
+}
+
 type MultiValuedAttribute struct {
 	Primary bool   `json:"primary,omitempty"`
 	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
 	Value   string `json:"value"`
 }
 
@@ -24,6 +82,8 @@ type User struct {
 	Emails      []MultiValuedAttribute `json:"emails"`
 	Groups      []MultiValuedAttribute `json:"groups"`
 	UserType    string                 `json:"userType,omitempty"`
+
+	SAP *SAPUserExtension `json:"urn:ietf:params:scim:schemas:extension:sap:2.0:User,omitempty"`
 }
 
 type Group struct {
@@ -33,19 +93,77 @@ type Group struct {
 	Members     []MultiValuedAttribute `json:"members,omitempty"`
 }
 
+// GroupCount returns the number of groups of the user, as reported in
+// its meta if present, or else counted from the returned groups.
+func (u *User) GroupCount() int {
+	return ptr.Deref(u.Meta.GroupsCount, len(u.Groups))
+}
+
+// EmailAddresses returns all the email addresses of the user, the
+// primary one first and the others in the order returned, without
+// empty values or duplicates differing only in case.
+func (u *User) EmailAddresses() []string {
+	emails := make([]string, 0, len(u.Emails))
+	seen := make(map[string]struct{}, len(u.Emails))
+
+	add := func(email string) {
+		key := strings.ToLower(email)
+		if _, ok := seen[key]; ok || email == "" {
+			return
+		}
+
+		seen[key] = struct{}{}
+		emails = append(emails, email)
+	}
+
+	for _, email := range u.Emails {
+		if email.Primary {
+			add(email.Value)
+		}
+	}
+
+	for _, email := range u.Emails {
+		add(email.Value)
+	}
+
+	return emails
+}
+
+// MemberCount returns the number of members of the group, as reported
+// in its meta if present, or else counted from the returned members.
+func (g *Group) MemberCount() int {
+	return ptr.Deref(g.Meta.MembersCount, len(g.Members))
+}
+
+// ListMeta holds the schemas and paging attributes of a list response.
+type ListMeta struct {
+	Schemas      []string `json:"schemas,omitempty"`
+	TotalResults int      `json:"totalResults,omitempty"`
+	StartIndex   int      `json:"startIndex,omitempty"`
+	ItemsPerPage int      `json:"itemsPerPage,omitempty"`
+	NextCursor   string   `json:"nextCursor,omitempty"`
+}
+
 //nolint:tagliatelle
 type UserList struct {
+	ListMeta
+
 	Resources []User `json:"Resources"`
 }
 
 //nolint:tagliatelle
 type GroupList struct {
+	ListMeta
+
 	Resources []Group `json:"Resources"`
 }
 
 type SearchRequest struct {
-	Schemas []string `json:"schemas"`
-	Filter  *string  `json:"filter,omitempty"`
-	Count   *int     `json:"count,omitempty"`
-	Cursor  *string  `json:"cursor,omitempty"`
+	Schemas            []string `json:"schemas"`
+	Filter             *string  `json:"filter,omitempty"`
+	Count              *int     `json:"count,omitempty"`
+	StartIndex         *int     `json:"startIndex,omitempty"`
+	Cursor             *string  `json:"cursor,omitempty"`
+	Attributes         []string `json:"attributes,omitempty"`
+	ExcludedAttributes []string `json:"excludedAttributes,omitempty"`
 }
@@ -0,0 +1,176 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestGroupMemberCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{
+			name:     "Count from meta",
+			input:    `{"id":"1","meta":{"resourceType":"Group","members.cnt":42}}`,
+			expected: 42,
+		},
+		{
+			name:     "Count from members",
+			input:    GetGroupResponse,
+			expected: 1,
+		},
+		{
+			name:     "Meta count preferred over partial members",
+			input:    `{"id":"1","meta":{"members.cnt":3},"members":[{"value":"a"}]}`,
+			expected: 3,
+		},
+		{
+			name:     "No members",
+			input:    `{"id":"1"}`,
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var group scim.Group
+
+			assert.NoError(t, json.Unmarshal([]byte(tt.input), &group))
+			assert.Equal(t, tt.expected, group.MemberCount())
+		})
+	}
+}
+
+func TestGroupCountFromListResponse(t *testing.T) {
+	var users scim.UserList
+
+	assert.NoError(t, json.Unmarshal([]byte(ListUsersResponse), &users))
+	assert.Len(t, users.Resources, 1)
+
+	// The fixture reports "groups.cnt":0 in meta despite returning a group
+	assert.Equal(t, 0, users.Resources[0].GroupCount())
+
+	var groups scim.GroupList
+
+	assert.NoError(t, json.Unmarshal([]byte(ListGroupsResponse), &groups))
+	assert.Len(t, groups.Resources, 1)
+	assert.Equal(t, 1, groups.Resources[0].MemberCount())
+}
+
+func TestUserEmailAddresses(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "Primary first",
+			input: `{"id":"1","emails":[` +
+				`{"value":"home@example.com","type":"home"},` +
+				`{"value":"work@example.com","type":"work","primary":true},` +
+				`{"value":"other@example.com"}]}`,
+			expected: []string{"work@example.com", "home@example.com", "other@example.com"},
+		},
+		{
+			name: "Duplicates and empty values dropped",
+			input: `{"id":"1","emails":[` +
+				`{"value":"work@example.com","type":"work"},` +
+				`{"value":""},` +
+				`{"value":"Work@Example.com","type":"home"}]}`,
+			expected: []string{"work@example.com"},
+		},
+		{
+			name:     "Single email",
+			input:    GetUserResponse,
+			expected: []string{"cloud.analyst@example.com"},
+		},
+		{
+			name:     "No emails",
+			input:    `{"id":"1"}`,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user scim.User
+
+			assert.NoError(t, json.Unmarshal([]byte(tt.input), &user))
+			assert.Equal(t, tt.expected, user.EmailAddresses())
+		})
+	}
+}
+
+func TestResolveID(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		location   string
+		expectedID string
+	}{
+		{
+			name:       "ID kept",
+			id:         "123",
+			location:   "https://scim.example.com/Users/456",
+			expectedID: "123",
+		},
+		{
+			name:       "ID from location",
+			location:   "https://scim.example.com/scim/v2/Users/456",
+			expectedID: "456",
+		},
+		{
+			name:       "Trailing slash",
+			location:   "https://scim.example.com/Groups/456/",
+			expectedID: "456",
+		},
+		{
+			name:       "Escaped segment",
+			location:   "https://scim.example.com/Users/a%20b",
+			expectedID: "a b",
+		},
+		{
+			name:     "No location",
+			location: "",
+		},
+		{
+			name:     "Location without path",
+			location: "https://scim.example.com",
+		},
+		{
+			name:     "Invalid location",
+			location: "://invalid",
+		},
+		{
+			name:     "Users collection",
+			location: "https://scim.example.com/scim/v2/Users",
+		},
+		{
+			name:     "Groups collection with trailing slash",
+			location: "https://scim.example.com/scim/v2/Groups/",
+		},
+		{
+			name:     "Empty segment",
+			location: "https://scim.example.com/scim/v2/Users//",
+		},
+		{
+			name:     "Root path",
+			location: "https://scim.example.com/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := scim.BaseResource{ID: tt.id, Meta: scim.Meta{Location: tt.location}}
+			resource.ResolveID()
+
+			assert.Equal(t, tt.expectedID, resource.ID)
+		})
+	}
+}
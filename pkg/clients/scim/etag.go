@@ -0,0 +1,246 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfNoneMatch = "If-None-Match"
+	HeaderIfMatch     = "If-Match"
+
+	weakETagPrefix = "W/"
+)
+
+var ErrInvalidETag = errors.New("invalid ETag")
+
+// ETag is an entity tag as returned in the ETag header or meta.version.
+type ETag struct {
+	Opaque string // Tag without the quotes
+	Weak   bool
+}
+
+// ParseETag parses a strong ("xyz") or weak (W/"xyz") entity tag.
+// Unquoted tags, which some servers return in meta.version, are
+// accepted as if quoted.
+func ParseETag(value string) (ETag, error) {
+	value = strings.TrimSpace(value)
+
+	var etag ETag
+
+	if strings.HasPrefix(value, weakETagPrefix) {
+		etag.Weak = true
+		value = strings.TrimPrefix(value, weakETagPrefix)
+	}
+
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	}
+
+	if value == "" || strings.Contains(value, `"`) {
+		return ETag{}, errs.Wrapf(ErrInvalidETag, value)
+	}
+
+	etag.Opaque = value
+
+	return etag, nil
+}
+
+// String formats the entity tag for the ETag and If-Match headers.
+func (e ETag) String() string {
+	if e.Weak {
+		return weakETagPrefix + `"` + e.Opaque + `"`
+	}
+
+	return `"` + e.Opaque + `"`
+}
+
+// StrongEqual reports whether both tags are strong and their opaque
+// tags are equal, as required by If-Match (RFC 9110 section 8.8.3.2).
+func (e ETag) StrongEqual(other ETag) bool {
+	return !e.Weak && !other.Weak && e.Opaque == other.Opaque
+}
+
+// WeakEqual reports whether the opaque tags are equal regardless of
+// either tag being weak, as used by If-None-Match.
+func (e ETag) WeakEqual(other ETag) bool {
+	return e.Opaque == other.Opaque
+}
+
+// etagCache stores GET response bodies by URL, including the host,
+// so that they can be revalidated with If-None-Match and served again
+// when the server answers 304 Not Modified.
+type etagCache struct {
+	ttl    time.Duration
+	jitter float64 // Fraction of ttl by which expiry is randomly spread
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+type etagEntry struct {
+	etag      string
+	header    http.Header
+	body      []byte
+	expiresAt time.Time
+}
+
+// WithCacheTTLJitter randomly spreads the expiry of cache entries by up
+// to ±percent of their TTL, so that entries stored together, e.g. by a
+// bulk sync, do not all expire and get refreshed at once.
+func WithCacheTTLJitter(percent int) Option {
+	return func(c *Client) {
+		c.cacheTTLJitter = float64(percent) / 100
+	}
+}
+
+// WithETagCache enables caching GET responses carrying an ETag. Cached
+// entries are revalidated with If-None-Match on each request and
+// dropped ttl after being stored, or kept until replaced if ttl is zero.
+func WithETagCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.etags = &etagCache{
+			ttl:     ttl,
+			entries: make(map[string]etagEntry),
+		}
+	}
+}
+
+func (e *etagCache) get(key string) (etagEntry, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	entry, ok := e.entries[key]
+	if !ok {
+		return etagEntry{}, false
+	}
+
+	if !entry.expiresAt.IsZero() && !e.now().Before(entry.expiresAt) {
+		delete(e.entries, key)
+		return etagEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (e *etagCache) put(key string, entry etagEntry) {
+	if e.ttl > 0 {
+		entry.expiresAt = e.now().Add(e.jitteredTTL())
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.entries[key] = entry
+}
+
+// purgeExpiredEvery returns a background job dropping the expired
+// entries at each interval, as get only drops those requested again.
+func (e *etagCache) purgeExpiredEvery(interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				e.purgeExpired()
+			}
+		}
+	}
+}
+
+func (e *etagCache) purgeExpired() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	for key, entry := range e.entries {
+		if !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt) {
+			delete(e.entries, key)
+		}
+	}
+}
+
+// jitteredTTL returns the ttl randomly spread within ±jitter of it.
+func (e *etagCache) jitteredTTL() time.Duration {
+	if e.jitter <= 0 {
+		return e.ttl
+	}
+
+	spread := (2*rand.Float64() - 1) * e.jitter
+
+	return e.ttl + time.Duration(spread*float64(e.ttl))
+}
+
+// doCachedRequest executes a GET request through the ETag cache,
+// serving the cached body if the server reports it as not modified.
+func (c *Client) doCachedRequest(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+
+	cached, ok := c.etags.get(key)
+	if ok {
+		req.Header.Set(HeaderIfNoneMatch, cached.etag)
+	}
+
+	resp, err := c.doWithRetries(req)
+	if err != nil {
+		return resp, err
+	}
+
+	switch {
+	case ok && resp.StatusCode == http.StatusNotModified:
+		c.closeBody(resp, "not modified")
+
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        cached.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       resp.Request,
+		}, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get(HeaderETag) != "":
+		body, err := io.ReadAll(resp.Body)
+		c.closeBody(resp, "cached")
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.etags.put(key, etagEntry{
+			etag:   resp.Header.Get(HeaderETag),
+			header: resp.Header.Clone(),
+			body:   body,
+		})
+
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		return resp, nil
+	default:
+		return resp, nil
+	}
+}
+
+func (c *Client) closeBody(resp *http.Response, kind string) {
+	err := resp.Body.Close()
+	if err != nil {
+		c.logger.Error("failed to close "+kind+" response body", "error", err)
+	}
+}
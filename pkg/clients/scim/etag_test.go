@@ -0,0 +1,249 @@
+package scim_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func getETagCachingClient(t *testing.T, ttl time.Duration) *scim.Client {
+	t.Helper()
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithETagCache(ttl),
+	)
+	assert.NoError(t, err)
+
+	return client
+}
+
+func TestETagCache(t *testing.T) {
+	var (
+		requests    atomic.Int32
+		notModified atomic.Int32
+		version     atomic.Value
+	)
+
+	version.Store(`W/"1"`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		etag, _ := version.Load().(string)
+		if r.Header.Get(scim.HeaderIfNoneMatch) == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set(scim.HeaderETag, etag)
+
+		_, err := w.Write([]byte(GetGroupResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := getETagCachingClient(t, 0)
+	params := scim.RequestParams{Host: server.URL}
+
+	for range 3 {
+		group, err := client.GetGroup(t.Context(), "123", "", params)
+		assert.NoError(t, err)
+		assert.Equal(t, &ExpectedGroup, group)
+	}
+
+	assert.Equal(t, 3, int(requests.Load()))
+	assert.Equal(t, 2, int(notModified.Load()))
+
+	// A changed resource is served fresh and revalidated with its new ETag
+	version.Store(`W/"2"`)
+
+	for range 2 {
+		group, err := client.GetGroup(t.Context(), "123", "", params)
+		assert.NoError(t, err)
+		assert.Equal(t, &ExpectedGroup, group)
+	}
+
+	assert.Equal(t, 5, int(requests.Load()))
+	assert.Equal(t, 3, int(notModified.Load()))
+}
+
+func TestETagCacheTTL(t *testing.T) {
+	var revalidations atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(scim.HeaderIfNoneMatch) != "" {
+			revalidations.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		w.Header().Set(scim.HeaderETag, `"1"`)
+
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	const ttl = time.Minute
+
+	now := time.Now()
+	client := getETagCachingClient(t, ttl)
+	client.SetETagCacheNow(func() time.Time { return now })
+
+	params := scim.RequestParams{Host: server.URL}
+
+	_, err := client.GetUser(t.Context(), "123", params)
+	assert.NoError(t, err)
+
+	now = now.Add(ttl / 2)
+
+	_, err = client.GetUser(t.Context(), "123", params)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, int(revalidations.Load()))
+
+	// The expired entry is dropped and the resource fetched unconditionally
+	now = now.Add(ttl)
+
+	user, err := client.GetUser(t.Context(), "123", params)
+	assert.NoError(t, err)
+	assert.Equal(t, &ExpectedUser, user)
+	assert.Equal(t, 1, int(revalidations.Load()))
+}
+
+func TestCacheTTLJitter(t *testing.T) {
+	const (
+		ttl           = time.Hour
+		jitterPercent = 10
+		entries       = 100
+	)
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithETagCache(ttl),
+		scim.WithCacheTTLJitter(jitterPercent),
+	)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	client.SetETagCacheNow(func() time.Time { return now })
+
+	minExpiry := now.Add(ttl - ttl*jitterPercent/100)
+	maxExpiry := now.Add(ttl + ttl*jitterPercent/100)
+
+	expiries := make(map[time.Time]struct{})
+
+	for i := range entries {
+		expiresAt := client.PutETagCacheEntry(fmt.Sprintf("/Users/%d", i))
+		assert.False(t, expiresAt.Before(minExpiry), "expiry %s before %s", expiresAt, minExpiry)
+		assert.False(t, expiresAt.After(maxExpiry), "expiry %s after %s", expiresAt, maxExpiry)
+
+		expiries[expiresAt] = struct{}{}
+	}
+
+	// Expiries are spread rather than all equal
+	assert.Greater(t, len(expiries), 1)
+}
+
+func TestCacheTTLWithoutJitter(t *testing.T) {
+	const ttl = time.Hour
+
+	client := getETagCachingClient(t, ttl)
+
+	now := time.Now()
+	client.SetETagCacheNow(func() time.Time { return now })
+
+	assert.Equal(t, now.Add(ttl), client.PutETagCacheEntry("/Users/1"))
+}
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      scim.ETag
+		expectedError error
+	}{
+		{name: "Strong", value: `"abc"`, expected: scim.ETag{Opaque: "abc"}},
+		{name: "Weak", value: `W/"abc"`, expected: scim.ETag{Opaque: "abc", Weak: true}},
+		{name: "Unquoted", value: "abc", expected: scim.ETag{Opaque: "abc"}},
+		{name: "Weak unquoted", value: "W/abc", expected: scim.ETag{Opaque: "abc", Weak: true}},
+		{name: "Surrounding spaces", value: ` W/"abc" `, expected: scim.ETag{Opaque: "abc", Weak: true}},
+		{name: "Empty", value: "", expectedError: scim.ErrInvalidETag},
+		{name: "Empty quoted", value: `""`, expectedError: scim.ErrInvalidETag},
+		{name: "Inner quote", value: `"a"b"`, expectedError: scim.ErrInvalidETag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			etag, err := scim.ParseETag(tt.value)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, etag)
+		})
+	}
+}
+
+func TestETagComparison(t *testing.T) {
+	// Examples from RFC 9110 section 8.8.3.2
+	tests := []struct {
+		name           string
+		a, b           string
+		expectedStrong bool
+		expectedWeak   bool
+	}{
+		{name: "Weak and weak", a: `W/"1"`, b: `W/"1"`, expectedStrong: false, expectedWeak: true},
+		{name: "Weak and weak different", a: `W/"1"`, b: `W/"2"`, expectedStrong: false, expectedWeak: false},
+		{name: "Weak and strong", a: `W/"1"`, b: `"1"`, expectedStrong: false, expectedWeak: true},
+		{name: "Strong and strong", a: `"1"`, b: `"1"`, expectedStrong: true, expectedWeak: true},
+		{name: "Strong and unquoted", a: `"1"`, b: `1`, expectedStrong: true, expectedWeak: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := scim.ParseETag(tt.a)
+			assert.NoError(t, err)
+
+			b, err := scim.ParseETag(tt.b)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.expectedStrong, a.StrongEqual(b))
+			assert.Equal(t, tt.expectedStrong, b.StrongEqual(a))
+			assert.Equal(t, tt.expectedWeak, a.WeakEqual(b))
+			assert.Equal(t, tt.expectedWeak, b.WeakEqual(a))
+		})
+	}
+}
+
+func TestETagString(t *testing.T) {
+	assert.Equal(t, `"abc"`, scim.ETag{Opaque: "abc"}.String())
+	assert.Equal(t, `W/"abc"`, scim.ETag{Opaque: "abc", Weak: true}.String())
+}
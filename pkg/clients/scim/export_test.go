@@ -0,0 +1,70 @@
+package scim
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"time"
+)
+
+func (p *SourceRefCredentialProvider) SetNow(now func() time.Time) {
+	p.now = now
+}
+
+func (c *Client) SetRootCAs(pool *x509.CertPool) {
+	if c.transport.TLSClientConfig == nil {
+		c.transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	c.transport.TLSClientConfig.RootCAs = pool
+}
+
+// Patch sends arbitrary PATCH operations to the resource path.
+func (c *Client) Patch(ctx context.Context, resourcePath string, params RequestParams, ops ...PatchOperation) error {
+	resp, err := c.patch(ctx, resourcePath, params, ops...)
+	if err != nil {
+		return err
+	}
+
+	c.closeBody(resp, "Patch")
+
+	return nil
+}
+
+func (c *Client) SetETagCacheNow(now func() time.Time) {
+	c.etags.now = now
+}
+
+// PutETagCacheEntry stores an empty entry in the ETag cache, returning its expiry.
+func (c *Client) PutETagCacheEntry(key string) time.Time {
+	c.etags.put(key, etagEntry{})
+
+	return c.etags.entries[key].expiresAt
+}
+
+func (c *Client) TLSClientConfig() *tls.Config {
+	return c.transport.TLSClientConfig
+}
+
+// BackgroundDone returns a channel closed once the background work of the client has returned.
+func (c *Client) BackgroundDone() <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		c.background.Wait()
+		close(done)
+	}()
+
+	return done
+}
+
+func (c *Client) ETagCacheLen() int {
+	c.etags.mu.Lock()
+	defer c.etags.mu.Unlock()
+
+	return len(c.etags.entries)
+}
+
+func (c *Client) Backoff(attempt int) time.Duration {
+	return c.backoff(attempt)
+}
@@ -3,24 +3,33 @@ package scim
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
+const lastModifiedAttribute = "meta.lastModified"
+
 type FilterOperator string
 
 const (
 	FilterOperatorEqual      FilterOperator = "eq"
 	FilterOperatorGreater    FilterOperator = "gt"
+	FilterOperatorGreaterEq  FilterOperator = "ge"
+	FilterOperatorLess       FilterOperator = "lt"
+	FilterOperatorLessEq     FilterOperator = "le"
 	FilterOperatorEqualCI    FilterOperator = "eq_ci" // Case-insensitive
 	FilterOperatorNotEqual   FilterOperator = "ne"
 	FilterOperatorContains   FilterOperator = "co"
 	FilterOperatorStartsWith FilterOperator = "sw"
 	FilterOperatorEndsWith   FilterOperator = "ew"
+	FilterOperatorPresent    FilterOperator = "pr"
 )
 
 // FilterExpression is an interface for filter expressions in SCIM.
 // It can be a comparison or logical operation.
 type FilterExpression interface {
 	ToString() string
+	// Evaluate reports whether the resource matches the expression.
+	Evaluate(resource any) bool
 }
 
 // NullFilterExpression is a placeholder for an empty/nil filter expression.
@@ -30,6 +39,11 @@ func (f NullFilterExpression) ToString() string {
 	return ""
 }
 
+// Evaluate matches every resource as no filter is applied.
+func (f NullFilterExpression) Evaluate(_ any) bool {
+	return true
+}
+
 // FilterComparison represents a comparison filter expression.
 type FilterComparison struct {
 	Attribute string
@@ -38,21 +52,188 @@ type FilterComparison struct {
 }
 
 func (f FilterComparison) ToString() string {
+	if f.Operator == FilterOperatorPresent {
+		return fmt.Sprintf("%s %s", f.Attribute, f.Operator)
+	}
+
 	return fmt.Sprintf("%s %s \"%s\"", f.Attribute, f.Operator, f.Value)
 }
 
+// Evaluate compares the resolved attribute against the value. String
+// comparisons are case-insensitive, and a multi-valued attribute
+// matches if any of its values matches.
+func (f FilterComparison) Evaluate(resource any) bool {
+	value, ok := ResolveAttribute(resource, f.Attribute)
+	if f.Operator == FilterOperatorPresent {
+		return ok && isPresent(value)
+	}
+
+	if !ok {
+		return f.Operator == FilterOperatorNotEqual
+	}
+
+	matched := false
+
+	for _, item := range flattenValue(value) {
+		if item != nil && f.compare(strings.ToLower(fmt.Sprint(item))) {
+			matched = true
+			break
+		}
+	}
+
+	if f.Operator == FilterOperatorNotEqual {
+		return !matched
+	}
+
+	return matched
+}
+
+func (f FilterComparison) compare(actual string) bool {
+	expected := strings.ToLower(f.Value)
+
+	switch f.Operator {
+	case FilterOperatorEqual, FilterOperatorEqualCI, FilterOperatorNotEqual:
+		return actual == expected
+	case FilterOperatorContains:
+		return strings.Contains(actual, expected)
+	case FilterOperatorStartsWith:
+		return strings.HasPrefix(actual, expected)
+	case FilterOperatorEndsWith:
+		return strings.HasSuffix(actual, expected)
+	case FilterOperatorGreater:
+		return actual > expected
+	case FilterOperatorGreaterEq:
+		return actual >= expected
+	case FilterOperatorLess:
+		return actual < expected
+	case FilterOperatorLessEq:
+		return actual <= expected
+	default:
+		return false
+	}
+}
+
+// NewDatetimeFilter compares a dateTime attribute against t, formatted
+// as an RFC 3339 timestamp in UTC.
+func NewDatetimeFilter(attribute string, operator FilterOperator, t time.Time) FilterComparison {
+	return FilterComparison{
+		Attribute: attribute,
+		Operator:  operator,
+		Value:     t.UTC().Format(time.RFC3339),
+	}
+}
+
+// NewModifiedBetweenFilter matches resources last modified within
+// the inclusive window [since, until].
+func NewModifiedBetweenFilter(since, until time.Time) FilterLogicalGroupAnd {
+	return FilterLogicalGroupAnd{
+		Expressions: []FilterExpression{
+			NewDatetimeFilter(lastModifiedAttribute, FilterOperatorGreaterEq, since),
+			NewDatetimeFilter(lastModifiedAttribute, FilterOperatorLessEq, until),
+		},
+	}
+}
+
+// ModifiedSinceFilter matches resources last modified since the given
+// time up to now on the client clock, which the next incremental sync
+// can start from without missing or repeating changes.
+func (c *Client) ModifiedSinceFilter(since time.Time) FilterLogicalGroupAnd {
+	return NewModifiedBetweenFilter(since, c.now())
+}
+
+func isPresent(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// And combines the expressions into a logical AND group. Nil and
+// NullFilterExpression operands are dropped, no remaining operand
+// yields NullFilterExpression and a single one is returned as is.
+func And(exprs ...FilterExpression) FilterExpression {
+	operands := filterOperands(exprs)
+
+	switch len(operands) {
+	case 0:
+		return NullFilterExpression{}
+	case 1:
+		return operands[0]
+	default:
+		return FilterLogicalGroupAnd{Expressions: operands}
+	}
+}
+
+// Or combines the expressions into a logical OR group, collapsing
+// operands like And.
+func Or(exprs ...FilterExpression) FilterExpression {
+	operands := filterOperands(exprs)
+
+	switch len(operands) {
+	case 0:
+		return NullFilterExpression{}
+	case 1:
+		return operands[0]
+	default:
+		return FilterLogicalGroupOr{Expressions: operands}
+	}
+}
+
+// Not negates the expression. Negating no filter yields no filter
+// rather than a filter matching nothing.
+func Not(expr FilterExpression) FilterExpression {
+	if isNullFilter(expr) {
+		return NullFilterExpression{}
+	}
+
+	return FilterLogicalGroupNot{Expression: expr}
+}
+
+// filterOperands returns the expressions without nil and null ones.
+func filterOperands(exprs []FilterExpression) []FilterExpression {
+	operands := make([]FilterExpression, 0, len(exprs))
+
+	for _, expr := range exprs {
+		if !isNullFilter(expr) {
+			operands = append(operands, expr)
+		}
+	}
+
+	return operands
+}
+
+func isNullFilter(expr FilterExpression) bool {
+	switch expr.(type) {
+	case nil, NullFilterExpression, *NullFilterExpression:
+		return true
+	default:
+		return false
+	}
+}
+
 // FilterLogicalGroupAnd represents a logical AND group of filter expressions.
 type FilterLogicalGroupAnd struct {
 	Expressions []FilterExpression
 }
 
 func (f FilterLogicalGroupAnd) ToString() string {
-	exprStrings := make([]string, len(f.Expressions))
-	for i, expr := range f.Expressions {
-		exprStrings[i] = expr.ToString()
+	return joinFilters(f.Expressions, "and")
+}
+
+func (f FilterLogicalGroupAnd) Evaluate(resource any) bool {
+	for _, expr := range filterOperands(f.Expressions) {
+		if !expr.Evaluate(resource) {
+			return false
+		}
 	}
 
-	return fmt.Sprintf("(%s)", strings.Join(exprStrings, " and "))
+	return true
 }
 
 // FilterLogicalGroupOr represents a logical OR group of filter expressions.
@@ -61,12 +242,47 @@ type FilterLogicalGroupOr struct {
 }
 
 func (f FilterLogicalGroupOr) ToString() string {
-	exprStrings := make([]string, len(f.Expressions))
-	for i, expr := range f.Expressions {
-		exprStrings[i] = expr.ToString()
+	return joinFilters(f.Expressions, "or")
+}
+
+// Evaluate matches every resource if the group has no operands,
+// as its empty filter string applies no filter.
+func (f FilterLogicalGroupOr) Evaluate(resource any) bool {
+	operands := filterOperands(f.Expressions)
+	if len(operands) == 0 {
+		return true
+	}
+
+	for _, expr := range operands {
+		if expr.Evaluate(resource) {
+			return true
+		}
 	}
 
-	return fmt.Sprintf("(%s)", strings.Join(exprStrings, " or "))
+	return false
+}
+
+// joinFilters joins the operands with the logical operator in
+// parentheses. Null operands are skipped, a single remaining operand
+// is not parenthesized and no operand yields an empty filter.
+func joinFilters(exprs []FilterExpression, operator string) string {
+	exprStrings := make([]string, 0, len(exprs))
+
+	for _, expr := range filterOperands(exprs) {
+		str := expr.ToString()
+		if str != "" {
+			exprStrings = append(exprStrings, str)
+		}
+	}
+
+	switch len(exprStrings) {
+	case 0:
+		return ""
+	case 1:
+		return exprStrings[0]
+	default:
+		return "(" + strings.Join(exprStrings, " "+operator+" ") + ")"
+	}
 }
 
 // FilterLogicalGroupNot represents a logical NOT operation on a filter expression.
@@ -74,6 +290,174 @@ type FilterLogicalGroupNot struct {
 	Expression FilterExpression
 }
 
+// ToString parenthesizes the operand unless it already is, e.g. an and
+// group, as the SCIM filter grammar requires `not (name eq "x")`.
+// A null or empty operand renders as an empty filter, not `not ()`.
 func (f FilterLogicalGroupNot) ToString() string {
-	return "not " + f.Expression.ToString()
+	if isNullFilter(f.Expression) {
+		return ""
+	}
+
+	str := f.Expression.ToString()
+	if str == "" {
+		return ""
+	}
+
+	if isParenthesized(str) {
+		return "not " + str
+	}
+
+	return "not (" + str + ")"
+}
+
+// isParenthesized reports whether the filter is enclosed in a single
+// pair of parentheses, ignoring those within quoted values.
+func isParenthesized(filter string) bool {
+	if !strings.HasPrefix(filter, "(") || !strings.HasSuffix(filter, ")") {
+		return false
+	}
+
+	depth := 0
+	quoted := false
+	escaped := false
+
+	for i, r := range filter {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+			if depth == 0 && i < len(filter)-1 {
+				return false
+			}
+		}
+	}
+
+	return depth == 0
+}
+
+func (f FilterLogicalGroupNot) Evaluate(resource any) bool {
+	return !f.Expression.Evaluate(resource)
+}
+
+// FilterValuePath represents a filter on the values of a multi-valued
+// attribute, e.g. `emails[type eq "work"]`. Its string form also serves
+// as a PATCH path targeting the matching values.
+type FilterValuePath struct {
+	Attribute string
+	Filter    FilterExpression
+}
+
+func (f FilterValuePath) ToString() string {
+	filter, _ := renderMinimal(f.Filter)
+	return f.Attribute + "[" + filter + "]"
+}
+
+// Evaluate matches the resource if any value of the attribute matches
+// the filter.
+func (f FilterValuePath) Evaluate(resource any) bool {
+	value, ok := ResolveAttribute(resource, f.Attribute)
+	if !ok {
+		return false
+	}
+
+	for _, item := range flattenValue(value) {
+		if f.Filter.Evaluate(item) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Precedences of the filter operators, from the loosest to the tightest binding.
+const (
+	precedenceOr = iota + 1
+	precedenceAnd
+	precedenceNot
+	precedenceComparison
+)
+
+// MinimalParentheses renders its expression with parentheses only where
+// the precedence of not over and over or requires them, e.g.
+// `a eq "1" and b eq "2" or c pr` rather than `((a eq "1" and b eq "2") or c pr)`,
+// shortening filters sent in the query string. The operand of not is
+// always parenthesized as the SCIM filter grammar requires.
+type MinimalParentheses struct {
+	Expression FilterExpression
+}
+
+func (f MinimalParentheses) ToString() string {
+	str, _ := renderMinimal(f.Expression)
+	return str
+}
+
+func (f MinimalParentheses) Evaluate(resource any) bool {
+	if isNullFilter(f.Expression) {
+		return true
+	}
+
+	return f.Expression.Evaluate(resource)
+}
+
+// renderMinimal renders the expression with minimal parentheses,
+// returning the precedence of its outermost operator. Expressions of
+// unknown types are given the loosest precedence to stay correct.
+func renderMinimal(expr FilterExpression) (string, int) {
+	switch e := expr.(type) {
+	case nil, NullFilterExpression, *NullFilterExpression:
+		return "", precedenceComparison
+	case MinimalParentheses:
+		return renderMinimal(e.Expression)
+	case FilterComparison, FilterValuePath:
+		return e.ToString(), precedenceComparison
+	case FilterLogicalGroupAnd:
+		return renderMinimalGroup(e.Expressions, "and", precedenceAnd)
+	case FilterLogicalGroupOr:
+		return renderMinimalGroup(e.Expressions, "or", precedenceOr)
+	case FilterLogicalGroupNot:
+		operand, _ := renderMinimal(e.Expression)
+		return "not (" + operand + ")", precedenceNot
+	default:
+		return expr.ToString(), precedenceOr
+	}
+}
+
+// renderMinimalGroup joins the operands with the logical operator,
+// parenthesizing those binding looser than it. Operands binding as
+// tight or tighter need none as and and or are associative, and a
+// single operand is rendered as is.
+func renderMinimalGroup(exprs []FilterExpression, operator string, precedence int) (string, int) {
+	operands := make([]string, 0, len(exprs))
+	precedences := make([]int, 0, len(exprs))
+
+	for _, expr := range exprs {
+		str, exprPrecedence := renderMinimal(expr)
+		if str != "" {
+			operands = append(operands, str)
+			precedences = append(precedences, exprPrecedence)
+		}
+	}
+
+	switch len(operands) {
+	case 0:
+		return "", precedenceComparison
+	case 1:
+		return operands[0], precedences[0]
+	}
+
+	for i, operandPrecedence := range precedences {
+		if operandPrecedence < precedence {
+			operands[i] = "(" + operands[i] + ")"
+		}
+	}
+
+	return strings.Join(operands, " "+operator+" "), precedence
 }
@@ -1,8 +1,12 @@
 package scim_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
@@ -58,7 +62,86 @@ func TestFilterComparison(t *testing.T) {
 					Value:     "John",
 				},
 			},
-			expected: `not name eq "John"`,
+			expected: `not (name eq "John")`,
+		},
+		{
+			name: "Negate grouped expression",
+			input: scim.FilterLogicalGroupNot{
+				Expression: scim.And(
+					scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "John"},
+					scim.FilterComparison{Attribute: "group", Operator: scim.FilterOperatorEqual, Value: "CMK"},
+				),
+			},
+			expected: `not (name eq "John" and group eq "CMK")`,
+		},
+		{
+			name: "Negate expressions grouped separately",
+			input: scim.FilterLogicalGroupNot{
+				Expression: scim.MinimalParentheses{Expression: scim.And(
+					scim.Or(
+						scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "John"},
+						scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "Jane"},
+					),
+					scim.Or(
+						scim.FilterComparison{Attribute: "group", Operator: scim.FilterOperatorEqual, Value: "CMK"},
+						scim.FilterComparison{Attribute: "group", Operator: scim.FilterOperatorEqual, Value: "(KMS)"},
+					),
+				)},
+			},
+			expected: `not ((name eq "John" or name eq "Jane") and (group eq "CMK" or group eq "(KMS)"))`,
+		},
+		{
+			name: "Negate grouped expression with parentheses in values",
+			input: scim.FilterLogicalGroupNot{
+				Expression: scim.Or(
+					scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "a)"},
+					scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "(b"},
+				),
+			},
+			expected: `not (name eq "a)" or name eq "(b")`,
+		},
+		{
+			name: "Negate negation",
+			input: scim.FilterLogicalGroupNot{
+				Expression: scim.FilterLogicalGroupNot{
+					Expression: scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorPresent},
+				},
+			},
+			expected: `not (not (name pr))`,
+		},
+		{
+			name:     "Negate nil expression",
+			input:    scim.FilterLogicalGroupNot{},
+			expected: "",
+		},
+		{
+			name:     "Negate null expression",
+			input:    scim.FilterLogicalGroupNot{Expression: scim.NullFilterExpression{}},
+			expected: "",
+		},
+		{
+			name:     "Negate empty group",
+			input:    scim.FilterLogicalGroupNot{Expression: scim.FilterLogicalGroupAnd{}},
+			expected: "",
+		},
+		{
+			name: "Value path",
+			input: scim.FilterValuePath{
+				Attribute: "members",
+				Filter:    scim.FilterComparison{Attribute: "value", Operator: scim.FilterOperatorEqual, Value: "user1"},
+			},
+			expected: `members[value eq "user1"]`,
+		},
+		{
+			name: "Value path with grouped filter",
+			input: scim.FilterValuePath{
+				Attribute: "emails",
+				Filter: scim.And(
+					scim.FilterComparison{Attribute: "type", Operator: scim.FilterOperatorEqual, Value: "work"},
+					scim.FilterComparison{Attribute: "value", Operator: scim.FilterOperatorEndsWith, Value: "@example.com"},
+				),
+			},
+			expected: `emails[type eq "work" and value ew "@example.com"]`,
 		},
 		{
 			name: "And Single expression",
@@ -71,7 +154,7 @@ func TestFilterComparison(t *testing.T) {
 					},
 				},
 			},
-			expected: `(name eq "John")`,
+			expected: `name eq "John"`,
 		},
 		{
 			name: "And Multiple expressions",
@@ -102,7 +185,7 @@ func TestFilterComparison(t *testing.T) {
 					},
 				},
 			},
-			expected: `(name eq "John")`,
+			expected: `name eq "John"`,
 		},
 		{
 			name: "Or Multiple expressions",
@@ -149,6 +232,80 @@ func TestFilterComparison(t *testing.T) {
 			},
 			expected: `(name eq "John" and (group eq "CMK" or type eq "employee"))`,
 		},
+		{
+			name:     "And empty",
+			input:    scim.FilterLogicalGroupAnd{},
+			expected: "",
+		},
+		{
+			name:     "Or empty",
+			input:    scim.FilterLogicalGroupOr{Expressions: []scim.FilterExpression{}},
+			expected: "",
+		},
+		{
+			name: "And only null expressions",
+			input: scim.FilterLogicalGroupAnd{
+				Expressions: []scim.FilterExpression{scim.NullFilterExpression{}, nil},
+			},
+			expected: "",
+		},
+		{
+			name: "And with null expression",
+			input: scim.FilterLogicalGroupAnd{
+				Expressions: []scim.FilterExpression{
+					scim.NullFilterExpression{},
+					scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "John"},
+				},
+			},
+			expected: `name eq "John"`,
+		},
+		{
+			name: "Or with null expression",
+			input: scim.FilterLogicalGroupOr{
+				Expressions: []scim.FilterExpression{
+					scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "John"},
+					scim.NullFilterExpression{},
+					scim.FilterComparison{Attribute: "group", Operator: scim.FilterOperatorEqual, Value: "CMK"},
+				},
+			},
+			expected: `(name eq "John" or group eq "CMK")`,
+		},
+		{
+			name: "Nested empty group",
+			input: scim.FilterLogicalGroupAnd{
+				Expressions: []scim.FilterExpression{
+					scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "John"},
+					scim.FilterLogicalGroupOr{},
+				},
+			},
+			expected: `name eq "John"`,
+		},
+		{
+			name: "Greater or Equal operator",
+			input: scim.FilterComparison{
+				Attribute: "meta.lastModified",
+				Operator:  scim.FilterOperatorGreaterEq,
+				Value:     "2024-01-01T00:00:00Z",
+			},
+			expected: `meta.lastModified ge "2024-01-01T00:00:00Z"`,
+		},
+		{
+			name: "Less operator",
+			input: scim.FilterComparison{
+				Attribute: "meta.lastModified",
+				Operator:  scim.FilterOperatorLess,
+				Value:     "2024-01-01T00:00:00Z",
+			},
+			expected: `meta.lastModified lt "2024-01-01T00:00:00Z"`,
+		},
+		{
+			name: "Modified between",
+			input: scim.NewModifiedBetweenFilter(
+				time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 1, 31, 12, 0, 0, 0, time.FixedZone("CET", 3600)),
+			),
+			expected: `(meta.lastModified ge "2024-01-01T00:00:00Z" and meta.lastModified le "2024-01-31T11:00:00Z")`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -158,3 +315,367 @@ func TestFilterComparison(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterConstructors(t *testing.T) {
+	name := scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "John"}
+	group := scim.FilterComparison{Attribute: "group", Operator: scim.FilterOperatorEqual, Value: "CMK"}
+
+	tests := []struct {
+		name     string
+		input    scim.FilterExpression
+		expected scim.FilterExpression
+	}{
+		{name: "And empty", input: scim.And(), expected: scim.NullFilterExpression{}},
+		{name: "And only null", input: scim.And(scim.NullFilterExpression{}, nil), expected: scim.NullFilterExpression{}},
+		{name: "And single", input: scim.And(name), expected: name},
+		{name: "And single with null", input: scim.And(scim.NullFilterExpression{}, name), expected: name},
+		{
+			name:     "And multiple",
+			input:    scim.And(name, scim.NullFilterExpression{}, group),
+			expected: scim.FilterLogicalGroupAnd{Expressions: []scim.FilterExpression{name, group}},
+		},
+		{name: "Or empty", input: scim.Or(), expected: scim.NullFilterExpression{}},
+		{name: "Or single", input: scim.Or(name, nil), expected: name},
+		{
+			name:     "Or multiple",
+			input:    scim.Or(name, group),
+			expected: scim.FilterLogicalGroupOr{Expressions: []scim.FilterExpression{name, group}},
+		},
+		{name: "Not", input: scim.Not(name), expected: scim.FilterLogicalGroupNot{Expression: name}},
+		{name: "Not null", input: scim.Not(scim.NullFilterExpression{}), expected: scim.NullFilterExpression{}},
+		{name: "Not nil", input: scim.Not(nil), expected: scim.NullFilterExpression{}},
+		{
+			name:     "Nested",
+			input:    scim.And(name, scim.Or(group, scim.NullFilterExpression{})),
+			expected: scim.FilterLogicalGroupAnd{Expressions: []scim.FilterExpression{name, group}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.input)
+		})
+	}
+}
+
+func TestFilterConstructorsToString(t *testing.T) {
+	name := scim.FilterComparison{Attribute: "name", Operator: scim.FilterOperatorEqual, Value: "John"}
+	group := scim.FilterComparison{Attribute: "group", Operator: scim.FilterOperatorEqual, Value: "CMK"}
+
+	assert.Empty(t, scim.And().ToString())
+	assert.Equal(t, `name eq "John"`, scim.And(name).ToString())
+	assert.Equal(t, `(name eq "John" and group eq "CMK")`, scim.And(name, group).ToString())
+	assert.Equal(t, `(name eq "John" or group eq "CMK")`, scim.Or(name, nil, group).ToString())
+}
+
+func TestMinimalParentheses(t *testing.T) {
+	a := scim.FilterComparison{Attribute: "a", Operator: scim.FilterOperatorEqual, Value: "1"}
+	b := scim.FilterComparison{Attribute: "b", Operator: scim.FilterOperatorEqual, Value: "2"}
+	c := scim.FilterComparison{Attribute: "c", Operator: scim.FilterOperatorPresent}
+	d := scim.FilterComparison{Attribute: "d", Operator: scim.FilterOperatorEqual, Value: "4"}
+
+	tests := []struct {
+		name     string
+		input    scim.FilterExpression
+		expected string
+	}{
+		{name: "Comparison", input: a, expected: `a eq "1"`},
+		{name: "Null", input: scim.NullFilterExpression{}, expected: ""},
+		{name: "And", input: scim.And(a, b), expected: `a eq "1" and b eq "2"`},
+		{name: "Nested and", input: scim.And(scim.And(a, b), c), expected: `a eq "1" and b eq "2" and c pr`},
+		{name: "And in or", input: scim.Or(scim.And(a, b), scim.And(c, d)), expected: `a eq "1" and b eq "2" or c pr and d eq "4"`},
+		{name: "Or in and", input: scim.And(scim.Or(a, b), c), expected: `(a eq "1" or b eq "2") and c pr`},
+		{name: "Nested or", input: scim.Or(a, scim.Or(b, c)), expected: `a eq "1" or b eq "2" or c pr`},
+		{name: "Not comparison", input: scim.Not(a), expected: `not (a eq "1")`},
+		{name: "Not in and", input: scim.And(scim.Not(a), b), expected: `not (a eq "1") and b eq "2"`},
+		{name: "Not of or", input: scim.Not(scim.Or(a, b)), expected: `not (a eq "1" or b eq "2")`},
+		{
+			name:     "Deeply nested",
+			input:    scim.And(scim.Or(scim.And(a, scim.Not(scim.And(b, c))), d), scim.And(a, b)),
+			expected: `(a eq "1" and not (b eq "2" and c pr) or d eq "4") and a eq "1" and b eq "2"`,
+		},
+		{
+			name: "Null operands",
+			input: scim.FilterLogicalGroupAnd{Expressions: []scim.FilterExpression{
+				scim.FilterLogicalGroupOr{Expressions: []scim.FilterExpression{a, scim.NullFilterExpression{}}},
+				nil,
+				b,
+			}},
+			expected: `a eq "1" and b eq "2"`,
+		},
+		{
+			name:     "Single operand keeps its precedence",
+			input:    scim.FilterLogicalGroupAnd{Expressions: []scim.FilterExpression{scim.Or(a, b)}},
+			expected: `a eq "1" or b eq "2"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			minimal := scim.MinimalParentheses{Expression: tt.input}
+			assert.Equal(t, tt.expected, minimal.ToString())
+		})
+	}
+}
+
+func TestMinimalFilterParentheses(t *testing.T) {
+	filter := scim.Or(
+		scim.And(
+			scim.FilterComparison{Attribute: "displayName", Operator: scim.FilterOperatorEqual, Value: "A"},
+			scim.FilterComparison{Attribute: "members", Operator: scim.FilterOperatorPresent},
+		),
+		scim.FilterComparison{Attribute: "displayName", Operator: scim.FilterOperatorEqual, Value: "B"},
+	)
+
+	tests := []struct {
+		name           string
+		opts           []scim.Option
+		expectedFilter string
+	}{
+		{
+			name:           "Parenthesized by default",
+			expectedFilter: `((displayName eq "A" and members pr) or displayName eq "B")`,
+		},
+		{
+			name:           "Minimal parentheses",
+			opts:           []scim.Option{scim.WithMinimalFilterParentheses()},
+			expectedFilter: `displayName eq "A" and members pr or displayName eq "B"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedFilter, r.URL.Query().Get("filter"))
+
+				_, err := w.Write([]byte(`{"Resources":[]}`))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			_, err = client.ListGroups(t.Context(), scim.RequestParams{Host: server.URL, Filter: filter})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFilterEvaluate(t *testing.T) {
+	user := scim.User{
+		BaseResource: scim.BaseResource{ID: "d1a6888d-7fd5-4c3f-ae33-177b24aae627"},
+		UserName:     "John",
+		DisplayName:  "John Doe",
+		UserType:     "employee",
+		Emails: []scim.MultiValuedAttribute{
+			{Value: "john.home@example.com", Type: "home"},
+			{Value: "john@example.com", Type: "work"},
+		},
+		Groups: []scim.MultiValuedAttribute{{Value: "1", Display: "CMK"}},
+	}
+
+	tests := []struct {
+		name     string
+		input    scim.FilterExpression
+		expected bool
+	}{
+		{
+			name:     "Null expression",
+			input:    scim.NullFilterExpression{},
+			expected: true,
+		},
+		{
+			name:     "Equal operator",
+			input:    scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "john"},
+			expected: true,
+		},
+		{
+			name:     "Not Equal operator",
+			input:    scim.FilterComparison{Attribute: "userType", Operator: scim.FilterOperatorNotEqual, Value: "employee"},
+			expected: false,
+		},
+		{
+			name:     "Not Equal operator on missing attribute",
+			input:    scim.FilterComparison{Attribute: "title", Operator: scim.FilterOperatorNotEqual, Value: "CEO"},
+			expected: true,
+		},
+		{
+			name:     "Contains operator",
+			input:    scim.FilterComparison{Attribute: "displayName", Operator: scim.FilterOperatorContains, Value: "n D"},
+			expected: true,
+		},
+		{
+			name:     "Starts With operator",
+			input:    scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorStartsWith, Value: "KMS"},
+			expected: false,
+		},
+		{
+			name:     "Ends With operator",
+			input:    scim.FilterComparison{Attribute: "displayName", Operator: scim.FilterOperatorEndsWith, Value: "doe"},
+			expected: true,
+		},
+		{
+			name:     "Present operator",
+			input:    scim.FilterComparison{Attribute: "emails", Operator: scim.FilterOperatorPresent},
+			expected: true,
+		},
+		{
+			name:     "Present operator on missing attribute",
+			input:    scim.FilterComparison{Attribute: "externalId", Operator: scim.FilterOperatorPresent},
+			expected: false,
+		},
+		{
+			name:     "Multi-valued attribute",
+			input:    scim.FilterComparison{Attribute: "emails.value", Operator: scim.FilterOperatorEqual, Value: "john@example.com"},
+			expected: true,
+		},
+		{
+			name: "Negate expression",
+			input: scim.FilterLogicalGroupNot{
+				Expression: scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "John"},
+			},
+			expected: false,
+		},
+		{
+			name: "And Multiple expressions",
+			input: scim.FilterLogicalGroupAnd{Expressions: []scim.FilterExpression{
+				scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "John"},
+				scim.FilterComparison{Attribute: "groups.display", Operator: scim.FilterOperatorEqual, Value: "CMK"},
+			}},
+			expected: true,
+		},
+		{
+			name: "Or Multiple expressions",
+			input: scim.FilterLogicalGroupOr{Expressions: []scim.FilterExpression{
+				scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "Jane"},
+				scim.FilterComparison{Attribute: "groups.display", Operator: scim.FilterOperatorEqual, Value: "KMS"},
+			}},
+			expected: false,
+		},
+		{
+			name: "Combination expression",
+			input: scim.FilterLogicalGroupAnd{Expressions: []scim.FilterExpression{
+				scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "John"},
+				scim.FilterLogicalGroupOr{Expressions: []scim.FilterExpression{
+					scim.FilterComparison{Attribute: "groups.display", Operator: scim.FilterOperatorEqual, Value: "KMS"},
+					scim.FilterComparison{Attribute: "userType", Operator: scim.FilterOperatorEqual, Value: "employee"},
+				}},
+			}},
+			expected: true,
+		},
+		{
+			name: "Value path",
+			input: scim.FilterValuePath{Attribute: "emails", Filter: scim.And(
+				scim.FilterComparison{Attribute: "type", Operator: scim.FilterOperatorEqual, Value: "work"},
+				scim.FilterComparison{Attribute: "value", Operator: scim.FilterOperatorEndsWith, Value: "@example.com"},
+			)},
+			expected: true,
+		},
+		{
+			name: "Value path matching different values",
+			input: scim.FilterValuePath{Attribute: "emails", Filter: scim.And(
+				scim.FilterComparison{Attribute: "type", Operator: scim.FilterOperatorEqual, Value: "home"},
+				scim.FilterComparison{Attribute: "value", Operator: scim.FilterOperatorEqual, Value: "john@example.com"},
+			)},
+			expected: false,
+		},
+		{
+			name:     "Value path on missing attribute",
+			input:    scim.FilterValuePath{Attribute: "members", Filter: scim.FilterComparison{Attribute: "value", Operator: scim.FilterOperatorPresent}},
+			expected: false,
+		},
+		{
+			name:     "Empty Or group",
+			input:    scim.FilterLogicalGroupOr{},
+			expected: true,
+		},
+		{
+			name: "Or with null expression",
+			input: scim.FilterLogicalGroupOr{Expressions: []scim.FilterExpression{
+				scim.NullFilterExpression{},
+				scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "Jane"},
+			}},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.input.Evaluate(user))
+		})
+	}
+}
+
+func TestModifiedBetweenFilterEvaluate(t *testing.T) {
+	resource := map[string]any{
+		"meta": map[string]any{"lastModified": "2024-01-15T10:00:00Z"},
+	}
+
+	tests := []struct {
+		name     string
+		since    time.Time
+		until    time.Time
+		expected bool
+	}{
+		{
+			name:     "Within window",
+			since:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			until:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "Window bounds are inclusive",
+			since:    time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			until:    time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "Outside window",
+			since:    time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			until:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := scim.NewModifiedBetweenFilter(tt.since, tt.until)
+			assert.Equal(t, tt.expected, filter.Evaluate(resource))
+		})
+	}
+}
+
+func TestModifiedSinceFilter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithClock(func() time.Time { return now }),
+	)
+	assert.NoError(t, err)
+
+	since := time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t,
+		`(meta.lastModified ge "2024-04-30T00:00:00Z" and meta.lastModified le "2024-05-01T10:00:00Z")`,
+		client.ModifiedSinceFilter(since).ToString(),
+	)
+}
@@ -1,16 +1,16 @@
 package scim
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
-	"io"
+	"maps"
 	"net/url"
+	"slices"
 	"strconv"
-
-	"github.com/openkcm/common-sdk/pkg/pointers"
+	"strings"
 
 	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
 )
 
 var (
@@ -18,40 +18,107 @@ var (
 	ErrMarshallFail = errors.New("failed to marshal search request")
 )
 
-func buildBodyFromParams(filter FilterExpression, count *int, cursor *string) (io.Reader, error) {
+func buildBodyFromParams(params RequestParams, pagination PaginationParams) ([]byte, error) {
+	filter := params.Filter
+
 	searchRequest := SearchRequest{
-		Schemas: []string{SearchRequestSchema},
-		Count:   count,
-		Cursor:  cursor,
+		Schemas:            []string{SearchRequestSchema},
+		Count:              params.Count,
+		StartIndex:         params.StartIndex,
+		Cursor:             params.Cursor,
+		Attributes:         params.Attributes,
+		ExcludedAttributes: params.ExcludedAttributes,
 	}
 
 	if filter == nil || (filter == NullFilterExpression{}) {
 		return nil, ErrNoFilter
 	}
 
-	searchRequest.Filter = pointers.To(filter.ToString())
+	searchRequest.Filter = ptr.To(filter.ToString())
 
 	jsonBody, err := json.Marshal(searchRequest)
 	if err != nil {
 		return nil, errs.Wrap(ErrMarshallFail, err)
 	}
 
-	return bytes.NewReader(jsonBody), nil
+	jsonBody, err = pagination.renameBody(jsonBody)
+	if err != nil {
+		return nil, errs.Wrap(ErrMarshallFail, err)
+	}
+
+	return jsonBody, nil
 }
 
-func buildQueryStringFromParams(filter FilterExpression, cursor *string, count *int) string {
+// minimalFilterEscaper escapes only the characters that would break the
+// query string structure, leaving quotes and other filter syntax literal.
+var minimalFilterEscaper = strings.NewReplacer(
+	"%", "%25",
+	" ", "%20",
+	"&", "%26",
+	"#", "%23",
+	"+", "%2B",
+)
+
+// projectionQuery returns the query parameters selecting the
+// attributes to return, if any.
+func projectionQuery(attributes, excludedAttributes []string) url.Values {
 	query := url.Values{}
-	if cursor != nil {
-		query.Add("cursor", *cursor)
+	if len(attributes) > 0 {
+		query.Add("attributes", strings.Join(attributes, ","))
+	}
+
+	if len(excludedAttributes) > 0 {
+		query.Add("excludedAttributes", strings.Join(excludedAttributes, ","))
+	}
+
+	return query
+}
+
+func buildQueryStringFromParams(params RequestParams, pagination PaginationParams, minimalEncoding bool) string {
+	filter := params.Filter
+
+	query := projectionQuery(params.Attributes, params.ExcludedAttributes)
+	if params.Cursor != nil {
+		query.Add(pagination.Cursor, *params.Cursor)
+	}
+
+	if params.Count != nil {
+		query.Add(pagination.Count, strconv.Itoa(*params.Count))
 	}
 
-	if count != nil {
-		query.Add("count", strconv.Itoa(*count))
+	if params.StartIndex != nil {
+		query.Add(pagination.StartIndex, strconv.Itoa(*params.StartIndex))
 	}
 
-	if (filter != nil) && (filter != NullFilterExpression{}) {
-		query.Add("filter", (filter).ToString())
+	if (filter == nil) || (filter == NullFilterExpression{}) {
+		return query.Encode()
+	}
+
+	query.Add("filter", filter.ToString())
+
+	if !minimalEncoding {
+		return query.Encode()
+	}
+
+	return encodeMinimalFilterQuery(query)
+}
+
+// encodeMinimalFilterQuery encodes the query sorted by key as
+// url.Values.Encode does, but escaping the filter with
+// minimalFilterEscaper, so both encodings order parameters alike.
+func encodeMinimalFilterQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		for _, value := range query[key] {
+			escaped := url.QueryEscape(value)
+			if key == "filter" {
+				escaped = minimalFilterEscaper.Replace(value)
+			}
+
+			params = append(params, url.QueryEscape(key)+"="+escaped)
+		}
 	}
 
-	return query.Encode()
+	return strings.Join(params, "&")
 }
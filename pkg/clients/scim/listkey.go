@@ -0,0 +1,51 @@
+package scim
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// listResourcesKey is the SCIM ListResponse attribute holding the resources.
+const listResourcesKey = "Resources"
+
+// WithListResourcesKey decodes the resources of list responses from the
+// given attribute instead of Resources, for non-conformant servers
+// returning them e.g. as data. Keys differing from Resources only in
+// case, such as resources, are decoded without this option.
+func WithListResourcesKey(key string) Option {
+	return func(c *Client) {
+		c.listResourcesKey = key
+	}
+}
+
+// renameListResourcesKey rewrites a successful list response body so
+// that the resources under the configured key decode as Resources.
+func (c *Client) renameListResourcesKey(resp *http.Response) error {
+	if c.listResourcesKey == "" || c.listResourcesKey == listResourcesKey || resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+
+	err := json.NewDecoder(resp.Body).Decode(&fields)
+	if err != nil {
+		return err
+	}
+
+	resources, ok := fields[c.listResourcesKey]
+	if ok {
+		delete(fields, c.listResourcesKey)
+		fields[listResourcesKey] = resources
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package scim_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestWithLogger(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             []scim.Option
+		failures         int
+		requests         int
+		expectedMessages []string
+	}{
+		{
+			name:     "Retries",
+			opts:     []scim.Option{scim.WithRetries(3, time.Millisecond)},
+			failures: 2,
+			requests: 1,
+			expectedMessages: []string{
+				`"@level":"debug","@message":"Retrying SCIM request"`,
+				`"attempt":0`,
+				`"attempt":1`,
+				`"backoff":2000000`,
+				`"status":503`,
+			},
+		},
+		{
+			name:     "Retries abandoned",
+			opts:     []scim.Option{scim.WithRetries(1, time.Millisecond)},
+			failures: 10,
+			requests: 1,
+			expectedMessages: []string{
+				`"@level":"debug","@message":"Retrying SCIM request"`,
+				`"@level":"warn","@message":"Giving up retrying SCIM request"`,
+			},
+		},
+		{
+			name:     "Circuit breaker",
+			opts:     []scim.Option{scim.WithCircuitBreaker(1, time.Hour)},
+			failures: 10,
+			requests: 2,
+			expectedMessages: []string{
+				`"@level":"warn","@message":"SCIM circuit breaker opened"`,
+				`"@level":"debug","@message":"SCIM request rejected by open circuit breaker"`,
+			},
+		},
+		{
+			name:     "Rate limit",
+			opts:     []scim.Option{scim.WithRateLimit(1000, 1)},
+			requests: 2,
+			expectedMessages: []string{
+				`"@level":"debug","@message":"Waiting for SCIM rate limit"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if int(requests.Add(1)) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			var out bytes.Buffer
+
+			logger := hclog.New(&hclog.LoggerOptions{Output: &out, Level: hclog.Debug, JSONFormat: true})
+
+			// The logger passed to NewClient is overridden by the option
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				nil,
+				append(tt.opts, scim.WithLogger(logger))...,
+			)
+			assert.NoError(t, err)
+
+			for range tt.requests {
+				_, _ = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+			}
+
+			for _, message := range tt.expectedMessages {
+				assert.Contains(t, out.String(), message)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+// ListMethod is the HTTP method used to list SCIM resources: GET with
+// the parameters in the query string or POST to the /.search endpoint.
+type ListMethod string
+
+const (
+	ListMethodGet  ListMethod = http.MethodGet
+	ListMethodPost ListMethod = http.MethodPost
+)
+
+var ErrInvalidListMethod = errors.New("invalid list method")
+
+// ParseListMethod parses a case-insensitive list method, returning
+// ErrInvalidListMethod if it is neither GET nor POST.
+func ParseListMethod(method string) (ListMethod, error) {
+	switch listMethod := ListMethod(strings.ToUpper(strings.TrimSpace(method))); listMethod {
+	case ListMethodGet, ListMethodPost:
+		return listMethod, nil
+	default:
+		return "", errs.Wrapf(ErrInvalidListMethod, method)
+	}
+}
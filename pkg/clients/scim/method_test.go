@@ -0,0 +1,39 @@
+package scim_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestParseListMethod(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      scim.ListMethod
+		expectedError error
+	}{
+		{name: "GET", input: "GET", expected: scim.ListMethodGet},
+		{name: "POST", input: "POST", expected: scim.ListMethodPost},
+		{name: "Lowercase", input: "post", expected: scim.ListMethodPost},
+		{name: "Surrounding spaces", input: " GET ", expected: scim.ListMethodGet},
+		{name: "Unknown method", input: "Search", expectedError: scim.ErrInvalidListMethod},
+		{name: "Unsupported HTTP method", input: "PUT", expectedError: scim.ErrInvalidListMethod},
+		{name: "Empty", input: "", expectedError: scim.ErrInvalidListMethod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, err := scim.ParseListMethod(tt.input)
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, method)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
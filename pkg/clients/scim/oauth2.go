@@ -0,0 +1,212 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+// tokenExpiryDelta is how long before its expiry a token is refreshed,
+// so that it does not expire while a request is in flight.
+const tokenExpiryDelta = 30 * time.Second
+
+var (
+	ErrTokenURL              = errors.New("failed to load the OAuth2 token URL")
+	ErrUnsupportedAuthMethod = errors.New("unsupported OAuth2 client authentication method")
+	ErrFetchToken            = errors.New("failed to fetch OAuth2 token")
+)
+
+// TokenCache caches OAuth2 access tokens by token URL and client ID,
+// so that clients authenticating against the same IdP with the same
+// client, e.g. one client per tenant, share a token until it expires.
+type TokenCache struct {
+	mu      sync.Mutex
+	entries map[tokenCacheKey]*tokenCacheEntry
+}
+
+type tokenCacheKey struct {
+	tokenURL string
+	clientID string
+	scope    string
+}
+
+// tokenCacheEntry serializes the fetches of its token, so that
+// concurrent requests needing a new token fetch it once.
+type tokenCacheEntry struct {
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// sharedTokenCache is the cache used by clients without WithTokenCache.
+var sharedTokenCache = NewTokenCache()
+
+func NewTokenCache() *TokenCache {
+	return &TokenCache{entries: make(map[tokenCacheKey]*tokenCacheEntry)}
+}
+
+// WithTokenCache overrides the process-wide cache sharing OAuth2
+// access tokens between clients.
+func WithTokenCache(cache *TokenCache) Option {
+	return func(c *Client) {
+		c.tokenCache = cache
+	}
+}
+
+// WithOAuth2Scopes requests access tokens limited to the scopes,
+// for IdPs granting none or too broad ones by default.
+func WithOAuth2Scopes(scopes ...string) Option {
+	return func(c *Client) {
+		c.oauth2Scopes = scopes
+	}
+}
+
+func (t *TokenCache) entry(key tokenCacheKey) *tokenCacheEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &tokenCacheEntry{}
+		t.entries[key] = entry
+	}
+
+	return entry
+}
+
+// oauth2Credentials fetches access tokens with the client credentials
+// grant, authenticating with the client secret in the request body
+// or in a Basic auth header.
+type oauth2Credentials struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	authMethod   commoncfg.OAuth2ClientAuthMethod
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"` //nolint:tagliatelle
+	ExpiresIn   int    `json:"expires_in"`   //nolint:tagliatelle
+}
+
+// loadOAuth2Credentials loads the OAuth2 client credentials upfront so
+// that misconfiguration fails fast.
+func loadOAuth2Credentials(cfg commoncfg.OAuth2) (*oauth2Credentials, error) {
+	authMethod := cfg.Credentials.AuthMethod
+	if authMethod == "" {
+		authMethod = commoncfg.OAuth2ClientSecretPost
+	}
+
+	if authMethod != commoncfg.OAuth2ClientSecretPost && authMethod != commoncfg.OAuth2ClientSecretBasic {
+		return nil, errs.Wrapf(ErrUnsupportedAuthMethod, string(authMethod))
+	}
+
+	if cfg.URL == nil || cfg.Credentials.ClientSecret == nil {
+		return nil, errs.Wrapf(ErrAuthNotImplemented, "OAuth2 requires a token URL and a client secret")
+	}
+
+	tokenURL, err := commoncfg.LoadValueFromSourceRef(*cfg.URL)
+	if err != nil {
+		return nil, errs.Wrap(ErrTokenURL, err)
+	}
+
+	clientID, err := commoncfg.LoadValueFromSourceRef(cfg.Credentials.ClientID)
+	if err != nil {
+		return nil, errs.Wrap(ErrClientID, err)
+	}
+
+	clientSecret, err := commoncfg.LoadValueFromSourceRef(*cfg.Credentials.ClientSecret)
+	if err != nil {
+		return nil, errs.Wrap(ErrClientSecret, err)
+	}
+
+	return &oauth2Credentials{
+		tokenURL:     string(tokenURL),
+		clientID:     string(clientID),
+		clientSecret: string(clientSecret),
+		authMethod:   authMethod,
+	}, nil
+}
+
+// accessToken returns the cached access token of the client
+// credentials, fetching a new one if there is none or it expires soon.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	entry := c.tokenCache.entry(tokenCacheKey{
+		tokenURL: c.oauth2.tokenURL,
+		clientID: c.oauth2.clientID,
+		scope:    strings.Join(c.oauth2Scopes, " "),
+	})
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.accessToken != "" && c.now().Before(entry.expiresAt) {
+		return entry.accessToken, nil
+	}
+
+	token, err := c.fetchToken(ctx)
+	if err != nil {
+		return "", errs.Wrap(ErrFetchToken, err)
+	}
+
+	// Tokens without a lifetime are used for this request only
+	entry.accessToken = ""
+	if token.ExpiresIn > 0 {
+		entry.accessToken = token.AccessToken
+		entry.expiresAt = c.now().Add(time.Duration(token.ExpiresIn)*time.Second - tokenExpiryDelta)
+	}
+
+	return token.AccessToken, nil
+}
+
+func (c *Client) fetchToken(ctx context.Context) (*tokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.oauth2Scopes) > 0 {
+		form.Set("scope", strings.Join(c.oauth2Scopes, " "))
+	}
+
+	if c.oauth2.authMethod == commoncfg.OAuth2ClientSecretPost {
+		form.Set("client_id", c.oauth2.clientID)
+		form.Set("client_secret", c.oauth2.clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.oauth2.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if c.oauth2.authMethod == commoncfg.OAuth2ClientSecretBasic {
+		req.SetBasicAuth(url.QueryEscape(c.oauth2.clientID), url.QueryEscape(c.oauth2.clientSecret))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+
+	defer c.closeBody(resp, "token")
+
+	token, err := httpclient.DecodeResponse[tokenResponse](ctx, "OAuth2", resp, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.AccessToken == "" {
+		return nil, errors.New("no access token in response")
+	}
+
+	return token, nil
+}
@@ -0,0 +1,286 @@
+package scim_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func oauth2SecretRef(tokenURL, clientID string, authMethod commoncfg.OAuth2ClientAuthMethod) commoncfg.SecretRef {
+	return commoncfg.SecretRef{
+		Type: commoncfg.OAuth2SecretType,
+		OAuth2: commoncfg.OAuth2{
+			URL: &commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: tokenURL},
+			Credentials: commoncfg.OAuth2Credentials{
+				ClientID:     commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: clientID},
+				ClientSecret: &commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "secret"},
+				AuthMethod:   authMethod,
+			},
+		},
+	}
+}
+
+// tokenServer issues numbered tokens valid for expiresIn seconds,
+// counting the fetches per client ID.
+func tokenServer(t *testing.T, expiresIn int, fetches *sync.Map) *httptest.Server {
+	t.Helper()
+
+	var issued atomic.Int32
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+
+		clientID, secret, ok := r.BasicAuth()
+		if !ok {
+			clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+
+		assert.Equal(t, "secret", secret)
+
+		count, _ := fetches.LoadOrStore(clientID, new(atomic.Int32))
+		count.(*atomic.Int32).Add(1)
+
+		_, err := fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`,
+			issued.Add(1), expiresIn)
+		assert.NoError(t, err)
+	}))
+}
+
+func fetchCount(fetches *sync.Map, clientID string) int {
+	count, ok := fetches.Load(clientID)
+	if !ok {
+		return 0
+	}
+
+	return int(count.(*atomic.Int32).Load())
+}
+
+func TestOAuth2SharedTokenCache(t *testing.T) {
+	var fetches sync.Map
+
+	tokens := tokenServer(t, 3600, &fetches)
+	defer tokens.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token-1", r.Header.Get(scim.HeaderAuthorization))
+
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	cache := scim.NewTokenCache()
+
+	var wg sync.WaitGroup
+
+	for range 3 {
+		client, err := scim.NewClient(
+			oauth2SecretRef(tokens.URL, "client1", commoncfg.OAuth2ClientSecretPost),
+			getLogger(),
+			scim.WithTokenCache(cache),
+		)
+		assert.NoError(t, err)
+
+		for range 2 {
+			wg.Go(func() {
+				_, err := client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+				assert.NoError(t, err)
+			})
+		}
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 1, fetchCount(&fetches, "client1"))
+}
+
+func TestOAuth2TokenCacheKeys(t *testing.T) {
+	var fetches sync.Map
+
+	tokens := tokenServer(t, 3600, &fetches)
+	defer tokens.Close()
+
+	otherTokens := tokenServer(t, 3600, &fetches)
+	defer otherTokens.Close()
+
+	server := getServer(t, http.StatusOK, GetUserResponse)
+	defer server.Close()
+
+	cache := scim.NewTokenCache()
+
+	for _, secretRef := range []commoncfg.SecretRef{
+		oauth2SecretRef(tokens.URL, "client1", commoncfg.OAuth2ClientSecretPost),
+		oauth2SecretRef(tokens.URL, "client2", commoncfg.OAuth2ClientSecretBasic),
+		oauth2SecretRef(otherTokens.URL, "client1", commoncfg.OAuth2ClientSecretPost),
+		oauth2SecretRef(tokens.URL, "client1", commoncfg.OAuth2ClientSecretPost),
+	} {
+		client, err := scim.NewClient(secretRef, getLogger(), scim.WithTokenCache(cache))
+		assert.NoError(t, err)
+
+		_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, fetchCount(&fetches, "client1"))
+	assert.Equal(t, 1, fetchCount(&fetches, "client2"))
+}
+
+func TestOAuth2Scopes(t *testing.T) {
+	var scopes []string
+
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+
+		scopes = append(scopes, r.PostForm.Get("scope"))
+
+		_, err := w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+		assert.NoError(t, err)
+	}))
+	defer tokens.Close()
+
+	server := getServer(t, http.StatusOK, GetUserResponse)
+	defer server.Close()
+
+	cache := scim.NewTokenCache()
+
+	for _, opts := range [][]scim.Option{
+		{scim.WithOAuth2Scopes("scim.read", "scim.write")},
+		{scim.WithOAuth2Scopes("scim.read")},
+		{},
+		{scim.WithOAuth2Scopes("scim.read")},
+	} {
+		client, err := scim.NewClient(
+			oauth2SecretRef(tokens.URL, "client1", commoncfg.OAuth2ClientSecretPost),
+			getLogger(),
+			append(opts, scim.WithTokenCache(cache))...,
+		)
+		assert.NoError(t, err)
+
+		_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+		assert.NoError(t, err)
+	}
+
+	// Tokens are cached per scope
+	assert.Equal(t, []string{"scim.read scim.write", "scim.read", ""}, scopes)
+}
+
+func TestOAuth2TokenExpiry(t *testing.T) {
+	tests := []struct {
+		name            string
+		expiresIn       int
+		elapsed         time.Duration
+		expectedFetches int
+	}{
+		{name: "Valid", expiresIn: 3600, elapsed: 30 * time.Minute, expectedFetches: 1},
+		{name: "Expiring soon", expiresIn: 3600, elapsed: 3590 * time.Second, expectedFetches: 2},
+		{name: "Expired", expiresIn: 3600, elapsed: 2 * time.Hour, expectedFetches: 2},
+		{name: "Without lifetime", expiresIn: 0, elapsed: 0, expectedFetches: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetches sync.Map
+
+			tokens := tokenServer(t, tt.expiresIn, &fetches)
+			defer tokens.Close()
+
+			server := getServer(t, http.StatusOK, GetUserResponse)
+			defer server.Close()
+
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			client, err := scim.NewClient(
+				oauth2SecretRef(tokens.URL, "client1", commoncfg.OAuth2ClientSecretPost),
+				getLogger(),
+				scim.WithTokenCache(scim.NewTokenCache()),
+				scim.WithClock(func() time.Time { return now }),
+			)
+			assert.NoError(t, err)
+
+			_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+			assert.NoError(t, err)
+
+			now = now.Add(tt.elapsed)
+
+			_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.expectedFetches, fetchCount(&fetches, "client1"))
+		})
+	}
+}
+
+func TestOAuth2TokenFetchFailure(t *testing.T) {
+	tokens := getServer(t, http.StatusUnauthorized, `{"error":"invalid_client"}`)
+	defer tokens.Close()
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := scim.NewClient(
+		oauth2SecretRef(tokens.URL, "client1", commoncfg.OAuth2ClientSecretPost),
+		getLogger(),
+		scim.WithTokenCache(scim.NewTokenCache()),
+	)
+	assert.NoError(t, err)
+
+	_, err = client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+	assert.ErrorIs(t, err, scim.ErrFetchToken)
+	assert.Zero(t, requests.Load())
+}
+
+func TestOAuth2Configuration(t *testing.T) {
+	tests := []struct {
+		name          string
+		secretRef     commoncfg.SecretRef
+		expectedError error
+	}{
+		{
+			name:      "Default auth method",
+			secretRef: oauth2SecretRef("https://idp.example.com/token", "client1", ""),
+		},
+		{
+			name:          "Unsupported auth method",
+			secretRef:     oauth2SecretRef("https://idp.example.com/token", "client1", commoncfg.OAuth2PrivateKeyJWT),
+			expectedError: scim.ErrUnsupportedAuthMethod,
+		},
+		{
+			name: "Missing token URL",
+			secretRef: commoncfg.SecretRef{
+				Type: commoncfg.OAuth2SecretType,
+				OAuth2: commoncfg.OAuth2{Credentials: commoncfg.OAuth2Credentials{
+					ClientID:     commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "client1"},
+					ClientSecret: &commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "secret"},
+				}},
+			},
+			expectedError: scim.ErrAuthNotImplemented,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := scim.NewClient(tt.secretRef, getLogger())
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package scim
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type tenantKey struct{}
+
+// RequestInfo describes a completed HTTP request attempt.
+type RequestInfo struct {
+	// Tenant is the identifier carried by the request context, if any
+	Tenant     string
+	Method     string
+	URL        string
+	Attempt    int
+	StatusCode int // Zero if the request failed without a response
+	Duration   time.Duration
+	Err        error
+}
+
+// Observer is called after each HTTP request attempt, e.g. for logging
+// or metrics. It runs synchronously and should return quickly.
+type Observer func(ctx context.Context, info RequestInfo)
+
+// WithObserver registers an observer of the request attempts.
+func WithObserver(observer Observer) Option {
+	return func(c *Client) {
+		c.observer = observer
+	}
+}
+
+// ContextWithTenant returns a context whose requests are attributed to the
+// tenant, tying the requests of an RPC fan-out back to its originator.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by the context, if any.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok
+}
+
+func (c *Client) observe(req *http.Request, attempt int, start time.Time, resp *http.Response, err error) {
+	if c.observer == nil {
+		return
+	}
+
+	tenant, _ := TenantFromContext(req.Context())
+
+	info := RequestInfo{
+		Tenant:   tenant,
+		Method:   req.Method,
+		URL:      req.URL.Redacted(),
+		Attempt:  attempt,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+
+	c.observer(req.Context(), info)
+}
@@ -0,0 +1,76 @@
+package scim_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestObserver(t *testing.T) {
+	tests := []struct {
+		name           string
+		tenant         string
+		responseStatus int
+		expectedTenant string
+	}{
+		{name: "Tenant in context", tenant: "tenant1", responseStatus: http.StatusOK, expectedTenant: "tenant1"},
+		{name: "No tenant in context", responseStatus: http.StatusOK},
+		{name: "Failed request", tenant: "tenant2", responseStatus: http.StatusNotFound, expectedTenant: "tenant2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, tt.responseStatus, GetUserResponse)
+			defer server.Close()
+
+			var (
+				mu    sync.Mutex
+				infos []scim.RequestInfo
+			)
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithObserver(func(ctx context.Context, info scim.RequestInfo) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					infos = append(infos, info)
+
+					tenant, _ := scim.TenantFromContext(ctx)
+					assert.Equal(t, info.Tenant, tenant)
+				}),
+			)
+			assert.NoError(t, err)
+
+			ctx := t.Context()
+			if tt.tenant != "" {
+				ctx = scim.ContextWithTenant(ctx, tt.tenant)
+			}
+
+			_, _ = client.GetUser(ctx, "123", scim.RequestParams{Host: server.URL})
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			assert.Len(t, infos, 1)
+			assert.Equal(t, tt.expectedTenant, infos[0].Tenant)
+			assert.Equal(t, http.MethodGet, infos[0].Method)
+			assert.Equal(t, server.URL+"/Users/123", infos[0].URL)
+			assert.Equal(t, tt.responseStatus, infos[0].StatusCode)
+			assert.NoError(t, infos[0].Err)
+		})
+	}
+}
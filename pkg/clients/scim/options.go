@@ -0,0 +1,156 @@
+package scim
+
+import (
+	"context"
+	"crypto/tls"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// Option configures optional Client behaviour.
+type Option func(*Client)
+
+// WithCredentialProvider overrides the provider supplying Basic auth
+// credentials for each request.
+func WithCredentialProvider(provider CredentialProvider) Option {
+	return func(c *Client) {
+		c.credentials = provider
+	}
+}
+
+// WithMaxQueryLength switches GET list requests whose query string
+// exceeds maxLength characters to POST /.search with the parameters
+// in the body.
+func WithMaxQueryLength(maxLength int) Option {
+	return func(c *Client) {
+		c.maxQueryLength = maxLength
+	}
+}
+
+// WithMaxRequestBodySize rejects write requests whose body exceeds
+// maxSize bytes with ErrRequestTooLarge before sending them.
+func WithMaxRequestBodySize(maxSize int) Option {
+	return func(c *Client) {
+		c.maxRequestBodySize = maxSize
+	}
+}
+
+// WithMinimalFilterEncoding sends the filter of GET list requests with
+// only spaces and query delimiters percent-encoded, keeping quotes and
+// other filter syntax literal, e.g. filter=userName%20eq%20"john".
+// Some SCIM servers fail to decode a fully percent-encoded filter and
+// reject or mis-evaluate it; enable this only for such servers, or use
+// POST /.search which sends the filter in the body instead.
+func WithMinimalFilterEncoding() Option {
+	return func(c *Client) {
+		c.minimalFilterEncoding = true
+	}
+}
+
+// WithUserAgent overrides the DefaultUserAgent sent with each request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithDefaultHeaders sets static headers sent with every request, e.g.
+// an API key or tenant header required by a gateway. Headers passed
+// with a request override them, while the Content-Type, Accept and
+// Authorization headers set by the client always take precedence.
+func WithDefaultHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		c.defaultHeaders = maps.Clone(headers)
+	}
+}
+
+// WithContextHeaders sets headers derived from the request context, e.g.
+// trace headers of the incoming call, sent with every request. Headers
+// passed with a request override them, and they override the default
+// headers.
+func WithContextHeaders(headers func(ctx context.Context) map[string]string) Option {
+	return func(c *Client) {
+		c.contextHeaders = headers
+	}
+}
+
+// WithLogger sets the logger of the client, overriding the one passed to
+// NewClient. Retries, rate limit waits and requests rejected by an open
+// circuit are logged at debug level, and abandoned retries and circuit
+// breaker trips at warning level.
+func WithLogger(logger hclog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2 over TLS, which is enabled by default.
+func WithHTTP2(enabled bool) Option {
+	return func(c *Client) {
+		c.http2 = enabled
+	}
+}
+
+// WithMinimalFilterParentheses renders list and search filters with
+// parentheses only where operator precedence requires them, see
+// MinimalParentheses, for servers with tight URL length limits.
+func WithMinimalFilterParentheses() Option {
+	return func(c *Client) {
+		c.minimalFilterParens = true
+	}
+}
+
+// WithNextProtos sets the protocols offered for ALPN negotiation in
+// order of preference, e.g. "h2" and "http/1.1". HTTP/2 is disabled
+// if they do not include "h2".
+func WithNextProtos(protos ...string) Option {
+	return func(c *Client) {
+		c.nextProtos = slices.Clone(protos)
+	}
+}
+
+// WithInsecureSkipVerify disables verification of the server TLS
+// certificate, e.g. for a throwaway dev instance with a self-signed one.
+// It exposes the connection to man-in-the-middle attacks and must only
+// be used for testing.
+func WithInsecureSkipVerify() Option {
+	return func(c *Client) {
+		c.insecureSkipVerify = true
+	}
+}
+
+// WithClientSessionCache sets the cache of TLS sessions resumed on
+// reconnection, an LRU one being used by default.
+func WithClientSessionCache(cache tls.ClientSessionCache) Option {
+	return func(c *Client) {
+		c.sessionCache = cache
+	}
+}
+
+// WithSessionTicketsDisabled disables or enables TLS session resumption,
+// which saves full handshakes, e.g. for mTLS, and is enabled by default.
+func WithSessionTicketsDisabled(disabled bool) Option {
+	return func(c *Client) {
+		c.sessionTicketsDisabled = disabled
+	}
+}
+
+// WithNotFoundAsEmpty treats a 404 Not Found response to a list or
+// search request as an empty result, for servers answering filters
+// matching nothing that way instead of with an empty ListResponse.
+func WithNotFoundAsEmpty() Option {
+	return func(c *Client) {
+		c.notFoundAsEmpty = true
+	}
+}
+
+// WithClock overrides the clock the client uses for datetime filters,
+// cache expiry and circuit breaker cooldowns, e.g. with a fixed time in tests.
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
@@ -0,0 +1,193 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+// PaginationStrategy selects how list requests page through results.
+type PaginationStrategy string
+
+const (
+	// PaginationCursor follows the nextCursor of each page.
+	PaginationCursor PaginationStrategy = "cursor"
+	// PaginationIndex follows startIndex and itemsPerPage as defined by core SCIM.
+	PaginationIndex PaginationStrategy = "index"
+
+	firstStartIndex = 1
+)
+
+var ErrInvalidPaginationStrategy = errors.New("invalid pagination strategy")
+
+// ParsePaginationStrategy parses a pagination strategy case-insensitively.
+func ParsePaginationStrategy(value string) (PaginationStrategy, error) {
+	switch strategy := PaginationStrategy(strings.ToLower(value)); strategy {
+	case PaginationCursor, PaginationIndex:
+		return strategy, nil
+	default:
+		return "", errs.Wrapf(ErrInvalidPaginationStrategy, value)
+	}
+}
+
+// WithPaginationStrategy selects how ListAllUsers and ListAllGroups
+// page through results, PaginationCursor by default.
+func WithPaginationStrategy(strategy PaginationStrategy) Option {
+	return func(c *Client) {
+		c.paginationStrategy = strategy
+	}
+}
+
+// PaginationParams names the pagination parameters of list requests,
+// in the query string as well as in the POST /.search body.
+type PaginationParams struct {
+	Count      string
+	StartIndex string
+	Cursor     string
+}
+
+// DefaultPaginationParams are the parameter names defined by SCIM.
+var DefaultPaginationParams = PaginationParams{
+	Count:      "count",
+	StartIndex: "startIndex",
+	Cursor:     "cursor",
+}
+
+// WithPaginationParams overrides the pagination parameter names for
+// servers not using the SCIM ones, e.g. limit instead of count.
+// Empty names keep their SCIM default.
+func WithPaginationParams(names PaginationParams) Option {
+	return func(c *Client) {
+		if names.Count != "" {
+			c.pagination.Count = names.Count
+		}
+
+		if names.StartIndex != "" {
+			c.pagination.StartIndex = names.StartIndex
+		}
+
+		if names.Cursor != "" {
+			c.pagination.Cursor = names.Cursor
+		}
+	}
+}
+
+// renameBody renames the pagination attributes of a marshalled
+// search request body from the SCIM names to the configured ones.
+func (p PaginationParams) renameBody(body []byte) ([]byte, error) {
+	if p == DefaultPaginationParams {
+		return body, nil
+	}
+
+	var fields map[string]json.RawMessage
+
+	err := json.Unmarshal(body, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	renames := map[string]string{
+		DefaultPaginationParams.Count:      p.Count,
+		DefaultPaginationParams.StartIndex: p.StartIndex,
+		DefaultPaginationParams.Cursor:     p.Cursor,
+	}
+
+	for from, to := range renames {
+		value, ok := fields[from]
+		if !ok || from == to {
+			continue
+		}
+
+		delete(fields, from)
+		fields[to] = value
+	}
+
+	return json.Marshal(fields)
+}
+
+// ListAllUsers lists the users of all pages, starting at the page
+// selected by the params and paging with the configured strategy.
+func (c *Client) ListAllUsers(ctx context.Context, params RequestParams) ([]User, error) {
+	return listAll(ctx, c, params, func(ctx context.Context, params RequestParams) ([]User, ListMeta, error) {
+		users, err := c.ListUsers(ctx, params)
+		if err != nil {
+			return nil, ListMeta{}, err
+		}
+
+		return users.Resources, users.ListMeta, nil
+	})
+}
+
+// ListAllGroups lists the groups of all pages, starting at the page
+// selected by the params and paging with the configured strategy.
+func (c *Client) ListAllGroups(ctx context.Context, params RequestParams) ([]Group, error) {
+	return listAll(ctx, c, params, func(ctx context.Context, params RequestParams) ([]Group, ListMeta, error) {
+		groups, err := c.ListGroups(ctx, params)
+		if err != nil {
+			return nil, ListMeta{}, err
+		}
+
+		return groups.Resources, groups.ListMeta, nil
+	})
+}
+
+// listAll requests pages until the last one, which is the first
+// without resources, past totalResults or without a next cursor,
+// or until MaxResults resources are listed.
+func listAll[T any](
+	ctx context.Context,
+	c *Client,
+	params RequestParams,
+	list func(ctx context.Context, params RequestParams) ([]T, ListMeta, error),
+) ([]T, error) {
+	var all []T
+
+	if c.paginationStrategy == PaginationIndex && params.StartIndex == nil {
+		params.StartIndex = ptr.To(firstStartIndex)
+	}
+
+	for {
+		resources, meta, err := list(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, resources...)
+
+		if params.MaxResults > 0 && len(all) >= params.MaxResults {
+			return all[:params.MaxResults], nil
+		}
+
+		if len(resources) == 0 {
+			return all, nil
+		}
+
+		switch c.paginationStrategy {
+		case PaginationIndex:
+			// itemsPerPage is the number of resources on this page,
+			// counted from them if the server omits it
+			itemsPerPage := meta.ItemsPerPage
+			if itemsPerPage <= 0 {
+				itemsPerPage = len(resources)
+			}
+
+			next := ptr.Deref(params.StartIndex, firstStartIndex) + itemsPerPage
+			if meta.TotalResults > 0 && next > meta.TotalResults {
+				return all, nil
+			}
+
+			params.StartIndex = ptr.To(next)
+		default:
+			// A server repeating the cursor would return the same page forever
+			if meta.NextCursor == "" || ptr.Equal(params.Cursor, &meta.NextCursor) {
+				return all, nil
+			}
+
+			params.Cursor = ptr.To(meta.NextCursor)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+func TestPaginationParams(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []scim.Option
+		method        string
+		expectedQuery map[string]string
+		expectedBody  map[string]any
+		absent        []string
+	}{
+		{
+			name:          "Default query",
+			method:        http.MethodGet,
+			expectedQuery: map[string]string{"count": "10", "cursor": "abc"},
+		},
+		{
+			name:          "Custom query",
+			opts:          []scim.Option{scim.WithPaginationParams(scim.PaginationParams{Count: "limit", Cursor: "next"})},
+			method:        http.MethodGet,
+			expectedQuery: map[string]string{"limit": "10", "next": "abc"},
+			absent:        []string{"count", "cursor"},
+		},
+		{
+			name:          "Partially custom query",
+			opts:          []scim.Option{scim.WithPaginationParams(scim.PaginationParams{Count: "limit"})},
+			method:        http.MethodGet,
+			expectedQuery: map[string]string{"limit": "10", "cursor": "abc"},
+			absent:        []string{"count"},
+		},
+		{
+			name:         "Default body",
+			method:       http.MethodPost,
+			expectedBody: map[string]any{"count": float64(10), "cursor": "abc"},
+		},
+		{
+			name:         "Custom body",
+			opts:         []scim.Option{scim.WithPaginationParams(scim.PaginationParams{Count: "limit", Cursor: "next"})},
+			method:       http.MethodPost,
+			expectedBody: map[string]any{"limit": float64(10), "next": "abc"},
+			absent:       []string{"count", "cursor"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.expectedQuery {
+					assert.Equal(t, value, r.URL.Query().Get(key), key)
+				}
+
+				for _, key := range tt.absent {
+					assert.NotContains(t, r.URL.Query(), key)
+				}
+
+				if tt.expectedBody != nil {
+					data, err := io.ReadAll(r.Body)
+					assert.NoError(t, err)
+
+					var body map[string]any
+
+					assert.NoError(t, json.Unmarshal(data, &body))
+
+					for key, value := range tt.expectedBody {
+						assert.Equal(t, value, body[key], key)
+					}
+
+					for _, key := range tt.absent {
+						assert.NotContains(t, body, key)
+					}
+				}
+
+				_, err := w.Write([]byte(`{"Resources":[]}`))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				tt.opts...,
+			)
+			assert.NoError(t, err)
+
+			_, err = client.ListUsers(t.Context(), scim.RequestParams{
+				Host:   server.URL,
+				Method: tt.method,
+				Filter: scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "john"},
+				Count:  ptr.To(10),
+				Cursor: ptr.To("abc"),
+			})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// pagedUsersServer serves totalUsers users in pages of at most
+// pageSize, by startIndex or by cursor, recording the requested pages.
+func pagedUsersServer(t *testing.T, totalUsers, pageSize int, requests *[]string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		*requests = append(*requests, query.Encode())
+
+		start := 1
+		if value := query.Get("startIndex"); value != "" {
+			start, _ = strconv.Atoi(value)
+		}
+
+		if value := query.Get("cursor"); value != "" {
+			start, _ = strconv.Atoi(strings.TrimPrefix(value, "page-"))
+		}
+
+		end := min(start+pageSize, totalUsers+1)
+
+		users := make([]string, 0, pageSize)
+		for i := start; i < end; i++ {
+			users = append(users, fmt.Sprintf(`{"id":"user%d","userName":"user%d"}`, i, i))
+		}
+
+		nextCursor := ""
+		if end <= totalUsers {
+			nextCursor = fmt.Sprintf(`,"nextCursor":"page-%d"`, end)
+		}
+
+		_, err := fmt.Fprintf(w, `{"Resources":[%s],"totalResults":%d,"startIndex":%d,"itemsPerPage":%d%s}`,
+			strings.Join(users, ","), totalUsers, start, len(users), nextCursor)
+		assert.NoError(t, err)
+	}))
+}
+
+func TestListAllUsers(t *testing.T) {
+	tests := []struct {
+		name             string
+		strategy         scim.PaginationStrategy
+		totalUsers       int
+		params           scim.RequestParams
+		expectedUsers    int
+		expectedRequests []string
+	}{
+		{
+			name:             "Index multiple pages",
+			strategy:         scim.PaginationIndex,
+			totalUsers:       5,
+			params:           scim.RequestParams{Count: ptr.To(2)},
+			expectedUsers:    5,
+			expectedRequests: []string{"count=2&startIndex=1", "count=2&startIndex=3", "count=2&startIndex=5"},
+		},
+		{
+			name:             "Index exact pages",
+			strategy:         scim.PaginationIndex,
+			totalUsers:       4,
+			params:           scim.RequestParams{Count: ptr.To(2)},
+			expectedUsers:    4,
+			expectedRequests: []string{"count=2&startIndex=1", "count=2&startIndex=3"},
+		},
+		{
+			name:             "Index from start index",
+			strategy:         scim.PaginationIndex,
+			totalUsers:       5,
+			params:           scim.RequestParams{Count: ptr.To(2), StartIndex: ptr.To(4)},
+			expectedUsers:    2,
+			expectedRequests: []string{"count=2&startIndex=4"},
+		},
+		{
+			name:             "Index empty",
+			strategy:         scim.PaginationIndex,
+			totalUsers:       0,
+			params:           scim.RequestParams{Count: ptr.To(2)},
+			expectedUsers:    0,
+			expectedRequests: []string{"count=2&startIndex=1"},
+		},
+		{
+			name:             "Cursor multiple pages",
+			strategy:         scim.PaginationCursor,
+			totalUsers:       5,
+			params:           scim.RequestParams{Count: ptr.To(2)},
+			expectedUsers:    5,
+			expectedRequests: []string{"count=2", "count=2&cursor=page-3", "count=2&cursor=page-5"},
+		},
+		{
+			name:             "Index bounded",
+			strategy:         scim.PaginationIndex,
+			totalUsers:       5,
+			params:           scim.RequestParams{Count: ptr.To(2), MaxResults: 3},
+			expectedUsers:    3,
+			expectedRequests: []string{"count=2&startIndex=1", "count=2&startIndex=3"},
+		},
+		{
+			name:             "Cursor bounded",
+			strategy:         scim.PaginationCursor,
+			totalUsers:       5,
+			params:           scim.RequestParams{Count: ptr.To(2), MaxResults: 2},
+			expectedUsers:    2,
+			expectedRequests: []string{"count=2"},
+		},
+		{
+			name:             "Default strategy",
+			totalUsers:       3,
+			params:           scim.RequestParams{Count: ptr.To(2)},
+			expectedUsers:    3,
+			expectedRequests: []string{"count=2", "count=2&cursor=page-3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+
+			server := pagedUsersServer(t, tt.totalUsers, 2, &requests)
+			defer server.Close()
+
+			var opts []scim.Option
+			if tt.strategy != "" {
+				opts = append(opts, scim.WithPaginationStrategy(tt.strategy))
+			}
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				opts...,
+			)
+			assert.NoError(t, err)
+
+			params := tt.params
+			params.Host = server.URL
+			params.Method = http.MethodGet
+
+			users, err := client.ListAllUsers(t.Context(), params)
+			assert.NoError(t, err)
+			assert.Len(t, users, tt.expectedUsers)
+			assert.Equal(t, tt.expectedRequests, requests)
+		})
+	}
+}
+
+func TestParsePaginationStrategy(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      scim.PaginationStrategy
+		expectedError error
+	}{
+		{value: "cursor", expected: scim.PaginationCursor},
+		{value: "Index", expected: scim.PaginationIndex},
+		{value: "offset", expectedError: scim.ErrInvalidPaginationStrategy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			strategy, err := scim.ParsePaginationStrategy(tt.value)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, strategy)
+		})
+	}
+}
+
+func TestListAllUsersRepeatedCursor(t *testing.T) {
+	var requests []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query().Encode())
+
+		_, err := w.Write([]byte(`{"Resources":[{"id":"user1","userName":"user1"}],"nextCursor":"page-2"}`))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client := getBasicClient()
+
+	users, err := client.ListAllUsers(t.Context(), scim.RequestParams{
+		Host:   server.URL,
+		Method: http.MethodGet,
+		Count:  ptr.To(1),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, []string{"count=1", "count=1&cursor=page-2"}, requests)
+}
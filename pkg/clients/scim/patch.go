@@ -0,0 +1,212 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+const (
+	PatchOpSchema = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+
+	membersPath = "members"
+)
+
+var (
+	ErrReplaceGroupMembers = errors.New("error replacing SCIM group members")
+	ErrRemoveGroupMember   = errors.New("error removing SCIM group member")
+	ErrRequestTooLarge     = errors.New("SCIM request body too large")
+)
+
+// PatchOperation is a single operation of a SCIM PATCH request.
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+//nolint:tagliatelle
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// memberValue is the value shape servers expect for group members.
+type memberValue struct {
+	Value string `json:"value"`
+}
+
+// ReplaceGroupMembers sets the members of the group to exactly the given
+// user IDs with a single PATCH replace operation on the members path.
+// An empty list removes all members.
+func (c *Client) ReplaceGroupMembers(
+	ctx context.Context,
+	groupID string,
+	memberIDs []string,
+	params RequestParams,
+) error {
+	err := c.replaceGroupMembers(ctx, groupID, memberIDs, params)
+	c.audit(ctx, "ReplaceGroupMembers", ResourceTypeGroup, groupID, err)
+
+	return err
+}
+
+func (c *Client) replaceGroupMembers(
+	ctx context.Context,
+	groupID string,
+	memberIDs []string,
+	params RequestParams,
+) error {
+	members := make([]memberValue, len(memberIDs))
+	for i, id := range memberIDs {
+		members[i] = memberValue{Value: id}
+	}
+
+	resp, err := c.patch(ctx, BasePathGroups+"/"+groupID, params, PatchOperation{
+		Op:    PatchOpReplace,
+		Path:  membersPath,
+		Value: members,
+	})
+	if err != nil {
+		return errs.Wrap(ErrReplaceGroupMembers, err)
+	}
+
+	defer c.closeBody(resp, "ReplaceGroupMembers")
+
+	err = checkPatchResponse(resp)
+	if err != nil {
+		return errs.Wrap(ErrReplaceGroupMembers, err)
+	}
+
+	return nil
+}
+
+// RemoveGroupMember removes the user with the given ID from the members
+// of the group with a PATCH remove operation on the value-filtered path
+// `members[value eq "<id>"]`, as servers may not support removing
+// members by index or by value.
+func (c *Client) RemoveGroupMember(
+	ctx context.Context,
+	groupID string,
+	memberID string,
+	params RequestParams,
+) error {
+	err := c.removeGroupMember(ctx, groupID, memberID, params)
+	c.audit(ctx, "RemoveGroupMember", ResourceTypeGroup, groupID, err)
+
+	return err
+}
+
+func (c *Client) removeGroupMember(
+	ctx context.Context,
+	groupID string,
+	memberID string,
+	params RequestParams,
+) error {
+	resp, err := c.patch(ctx, BasePathGroups+"/"+groupID, params, PatchOperation{
+		Op:   PatchOpRemove,
+		Path: MemberValuePath(memberID),
+	})
+	if err != nil {
+		return errs.Wrap(ErrRemoveGroupMember, err)
+	}
+
+	defer c.closeBody(resp, "RemoveGroupMember")
+
+	err = checkPatchResponse(resp)
+	if err != nil {
+		return errs.Wrap(ErrRemoveGroupMember, err)
+	}
+
+	return nil
+}
+
+// MemberValuePath returns the PATCH path of the group member with the
+// given ID, `members[value eq "<id>"]`.
+func MemberValuePath(memberID string) string {
+	return FilterValuePath{
+		Attribute: membersPath,
+		Filter:    FilterComparison{Attribute: "value", Operator: FilterOperatorEqual, Value: memberID},
+	}.ToString()
+}
+
+// checkPatchResponse fails unless the server returned the updated
+// resource or no content, as servers do either.
+func checkPatchResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("invalid response from SCIM: %w",
+			&httpclient.StatusCodeError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	return nil
+}
+
+// patch sends a PATCH request with the operations to the resource path.
+func (c *Client) patch(
+	ctx context.Context,
+	resourcePath string,
+	params RequestParams,
+	operations ...PatchOperation,
+) (*http.Response, error) {
+	body, err := json.Marshal(PatchRequest{
+		Schemas:    []string{PatchOpSchema},
+		Operations: operations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	err = c.checkBodySize(body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := conditionalHeaders(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.baseCreateAndExecuteHTTPRequest(
+		ctx, params.Host, http.MethodPatch, resourcePath, nil, body, headers,
+	)
+}
+
+// checkBodySize fails with ErrRequestTooLarge if the body exceeds
+// the configured maximum request body size.
+func (c *Client) checkBodySize(body []byte) error {
+	if c.maxRequestBodySize > 0 && len(body) > c.maxRequestBodySize {
+		return errs.Wrapf(ErrRequestTooLarge, fmt.Sprintf("%d bytes exceed limit of %d", len(body), c.maxRequestBodySize))
+	}
+
+	return nil
+}
+
+// conditionalHeaders returns the request headers with If-Match set to the
+// parsed IfMatch version, so that weak and unquoted versions are sent in
+// the form servers compare against.
+func conditionalHeaders(params RequestParams) (map[string]string, error) {
+	if params.IfMatch == "" {
+		return params.Headers, nil
+	}
+
+	etag, err := ParseETag(params.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(params.Headers)+1)
+	maps.Copy(headers, params.Headers)
+	headers[HeaderIfMatch] = etag.String()
+
+	return headers, nil
+}
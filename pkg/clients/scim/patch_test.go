@@ -0,0 +1,275 @@
+package scim_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+func TestReplaceGroupMembers(t *testing.T) {
+	tests := []struct {
+		name           string
+		memberIDs      []string
+		responseStatus int
+		expectedBody   string
+		expectedError  error
+	}{
+		{
+			name:           "Replace members",
+			memberIDs:      []string{"user1", "user2"},
+			responseStatus: http.StatusOK,
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],` +
+				`"Operations":[{"op":"replace","path":"members","value":[{"value":"user1"},{"value":"user2"}]}]}`,
+		},
+		{
+			name:           "Remove all members",
+			memberIDs:      nil,
+			responseStatus: http.StatusNoContent,
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],` +
+				`"Operations":[{"op":"replace","path":"members","value":[]}]}`,
+		},
+		{
+			name:           "Group not found",
+			memberIDs:      []string{"user1"},
+			responseStatus: http.StatusNotFound,
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],` +
+				`"Operations":[{"op":"replace","path":"members","value":[{"value":"user1"}]}]}`,
+			expectedError: httpclient.ErrUnexpectedStatusCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPatch, r.Method)
+				assert.Equal(t, "/Groups/group1", r.URL.Path)
+				assert.Equal(t, scim.ApplicationSCIMJson, r.Header.Get("Content-Type"))
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.expectedBody, string(body))
+
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer server.Close()
+
+			client := getBasicClient()
+
+			err := client.ReplaceGroupMembers(t.Context(), "group1", tt.memberIDs, scim.RequestParams{Host: server.URL})
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, scim.ErrReplaceGroupMembers)
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestRemoveGroupMember(t *testing.T) {
+	tests := []struct {
+		name           string
+		memberID       string
+		responseStatus int
+		expectedBody   string
+		expectedError  error
+	}{
+		{
+			name:           "Remove member",
+			memberID:       "user1",
+			responseStatus: http.StatusNoContent,
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],` +
+				`"Operations":[{"op":"remove","path":"members[value eq \"user1\"]"}]}`,
+		},
+		{
+			name:           "Remove member returning group",
+			memberID:       "user2",
+			responseStatus: http.StatusOK,
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],` +
+				`"Operations":[{"op":"remove","path":"members[value eq \"user2\"]"}]}`,
+		},
+		{
+			name:           "Group not found",
+			memberID:       "user1",
+			responseStatus: http.StatusNotFound,
+			expectedBody: `{"schemas":["urn:ietf:params:scim:api:messages:2.0:PatchOp"],` +
+				`"Operations":[{"op":"remove","path":"members[value eq \"user1\"]"}]}`,
+			expectedError: httpclient.ErrUnexpectedStatusCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPatch, r.Method)
+				assert.Equal(t, "/Groups/group1", r.URL.Path)
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, tt.expectedBody, string(body))
+
+				w.WriteHeader(tt.responseStatus)
+			}))
+			defer server.Close()
+
+			client := getBasicClient()
+
+			err := client.RemoveGroupMember(t.Context(), "group1", tt.memberID, scim.RequestParams{Host: server.URL})
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, scim.ErrRemoveGroupMember)
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestMaxRequestBodySize(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxSize       int
+		call          func(client *scim.Client, params scim.RequestParams) error
+		expectedError error
+	}{
+		{
+			name:    "Unlimited",
+			maxSize: 0,
+			call: func(client *scim.Client, params scim.RequestParams) error {
+				return client.ReplaceGroupMembers(t.Context(), "group1", manyMembers(1000), params)
+			},
+		},
+		{
+			name:    "Within limit",
+			maxSize: 1024,
+			call: func(client *scim.Client, params scim.RequestParams) error {
+				return client.ReplaceGroupMembers(t.Context(), "group1", manyMembers(2), params)
+			},
+		},
+		{
+			name:    "Oversized member replace",
+			maxSize: 1024,
+			call: func(client *scim.Client, params scim.RequestParams) error {
+				return client.ReplaceGroupMembers(t.Context(), "group1", manyMembers(1000), params)
+			},
+			expectedError: scim.ErrRequestTooLarge,
+		},
+		{
+			name:    "Oversized operation value",
+			maxSize: 1024,
+			call: func(client *scim.Client, params scim.RequestParams) error {
+				return client.Patch(t.Context(), "/Groups/group1", params, scim.PatchOperation{
+					Op:    scim.PatchOpReplace,
+					Path:  "displayName",
+					Value: strings.Repeat("a", 2048),
+				})
+			},
+			expectedError: scim.ErrRequestTooLarge,
+		},
+		{
+			name:    "Oversized operation count",
+			maxSize: 1024,
+			call: func(client *scim.Client, params scim.RequestParams) error {
+				ops := make([]scim.PatchOperation, 100)
+				for i := range ops {
+					ops[i] = scim.PatchOperation{Op: scim.PatchOpRemove, Path: "members"}
+				}
+
+				return client.Patch(t.Context(), "/Groups/group1", params, ops...)
+			},
+			expectedError: scim.ErrRequestTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests++
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithMaxRequestBodySize(tt.maxSize),
+			)
+			assert.NoError(t, err)
+
+			err = tt.call(client, scim.RequestParams{Host: server.URL})
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, requests)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Zero(t, requests)
+			}
+		})
+	}
+}
+
+func manyMembers(count int) []string {
+	ids := make([]string, count)
+	for i := range ids {
+		ids[i] = "user" + strconv.Itoa(i)
+	}
+
+	return ids
+}
+
+func TestPatchIfMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         string
+		expectedIfMatch string
+		expectedError   error
+	}{
+		{name: "Unset", ifMatch: "", expectedIfMatch: ""},
+		{name: "Strong", ifMatch: `"1"`, expectedIfMatch: `"1"`},
+		{name: "Weak", ifMatch: `W/"1"`, expectedIfMatch: `W/"1"`},
+		{name: "Unquoted version", ifMatch: "1", expectedIfMatch: `"1"`},
+		{name: "Invalid", ifMatch: `"`, expectedError: scim.ErrInvalidETag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedIfMatch, r.Header.Get(scim.HeaderIfMatch))
+				assert.Equal(t, "value", r.Header.Get("X-Custom"))
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			client := getBasicClient()
+
+			err := client.ReplaceGroupMembers(t.Context(), "group1", []string{"user1"}, scim.RequestParams{
+				Host:    server.URL,
+				Headers: map[string]string{"X-Custom": "value"},
+				IfMatch: tt.ifMatch,
+			})
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+// FilterSupport is the outcome of a filter support probe.
+type FilterSupport string
+
+const (
+	FilterSupportUnknown FilterSupport = "unknown"
+	FilterSupported      FilterSupport = "supported"
+	FilterNotSupported   FilterSupport = "notSupported"
+)
+
+var ErrProbeFilterSupport = errors.New("error probing SCIM filter support")
+
+// probeFilter is the filter probed if none is given, which any server
+// supporting filters matches.
+var probeFilter = FilterComparison{Attribute: "id", Operator: FilterOperatorPresent}
+
+// ProbeFilterSupport tells whether the server supports listing users
+// with the filter of the params, `id pr` if none, and with their
+// method, e.g. POST /.search. It lists no resources, asking for a count
+// of zero. Servers rejecting the filter with a 400 status, or the
+// method with a 404, 405 or 501 one, do not support it; any other
+// failure returns FilterSupportUnknown with an error.
+func (c *Client) ProbeFilterSupport(ctx context.Context, params RequestParams) (FilterSupport, error) {
+	if isNullFilter(params.Filter) {
+		params.Filter = probeFilter
+	}
+
+	params.Count = ptr.To(0)
+	params.Cursor = nil
+	params.StartIndex = nil
+	params.Attributes = []string{"id"}
+	params.ExcludedAttributes = nil
+
+	resp, err := c.createAndExecuteHTTPRequest(ctx, params, BasePathUsers)
+	if err != nil {
+		return FilterSupportUnknown, errs.Wrap(ErrProbeFilterSupport, err)
+	}
+
+	defer c.closeBody(resp, "ProbeFilterSupport")
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return FilterSupported, nil
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		c.logger.Debug("SCIM filter not supported",
+			"status", resp.StatusCode, "scimType", readScimType(resp))
+
+		return FilterNotSupported, nil
+	default:
+		return FilterSupportUnknown, errs.Wrap(ErrProbeFilterSupport, fmt.Errorf("invalid response from SCIM: %w",
+			&httpclient.StatusCodeError{StatusCode: resp.StatusCode, Status: resp.Status}))
+	}
+}
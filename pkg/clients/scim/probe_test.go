@@ -0,0 +1,118 @@
+package scim_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+func TestProbeFilterSupport(t *testing.T) {
+	groupFilter := scim.FilterComparison{Attribute: "groups.value", Operator: scim.FilterOperatorEqual, Value: "group1"}
+
+	tests := []struct {
+		name            string
+		params          scim.RequestParams
+		responseStatus  int
+		responseBody    string
+		expectedMethod  string
+		expectedPath    string
+		expectedFilter  string
+		expectedSupport scim.FilterSupport
+		expectedError   error
+	}{
+		{
+			name:            "Filter supported",
+			params:          scim.RequestParams{Filter: groupFilter},
+			responseStatus:  http.StatusOK,
+			responseBody:    `{"schemas":["urn:ietf:params:scim:api:messages:2.0:ListResponse"],"totalResults":3,"Resources":[]}`,
+			expectedMethod:  http.MethodGet,
+			expectedPath:    "/Users/",
+			expectedFilter:  `groups.value eq "group1"`,
+			expectedSupport: scim.FilterSupported,
+		},
+		{
+			name:            "Default filter supported",
+			responseStatus:  http.StatusOK,
+			responseBody:    `{"totalResults":3,"Resources":[]}`,
+			expectedMethod:  http.MethodGet,
+			expectedPath:    "/Users/",
+			expectedFilter:  `id pr`,
+			expectedSupport: scim.FilterSupported,
+		},
+		{
+			name:            "Filter rejected",
+			params:          scim.RequestParams{Filter: groupFilter},
+			responseStatus:  http.StatusBadRequest,
+			responseBody:    `{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"scimType":"invalidFilter","status":"400"}`,
+			expectedMethod:  http.MethodGet,
+			expectedPath:    "/Users/",
+			expectedFilter:  `groups.value eq "group1"`,
+			expectedSupport: scim.FilterNotSupported,
+		},
+		{
+			name:            "Search endpoint not found",
+			params:          scim.RequestParams{Filter: groupFilter, UseSearchPost: ptr.To(true)},
+			responseStatus:  http.StatusNotFound,
+			expectedMethod:  http.MethodPost,
+			expectedPath:    "/Users/.search",
+			expectedSupport: scim.FilterNotSupported,
+		},
+		{
+			name:            "Search method not allowed",
+			params:          scim.RequestParams{Filter: groupFilter, UseSearchPost: ptr.To(true)},
+			responseStatus:  http.StatusMethodNotAllowed,
+			expectedMethod:  http.MethodPost,
+			expectedPath:    "/Users/.search",
+			expectedSupport: scim.FilterNotSupported,
+		},
+		{
+			name:            "Unauthorized",
+			params:          scim.RequestParams{Filter: groupFilter},
+			responseStatus:  http.StatusUnauthorized,
+			expectedMethod:  http.MethodGet,
+			expectedPath:    "/Users/",
+			expectedFilter:  `groups.value eq "group1"`,
+			expectedSupport: scim.FilterSupportUnknown,
+			expectedError:   httpclient.ErrUnexpectedStatusCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedMethod, r.Method)
+				assert.Equal(t, tt.expectedPath, r.URL.Path)
+
+				if tt.expectedMethod == http.MethodGet {
+					assert.Equal(t, tt.expectedFilter, r.URL.Query().Get("filter"))
+					assert.Equal(t, "0", r.URL.Query().Get("count"))
+				}
+
+				w.WriteHeader(tt.responseStatus)
+				_, _ = w.Write([]byte(tt.responseBody))
+			}))
+			defer server.Close()
+
+			client := getBasicClient()
+
+			params := tt.params
+			params.Host = server.URL
+
+			support, err := client.ProbeFilterSupport(t.Context(), params)
+			assert.Equal(t, tt.expectedSupport, support)
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, scim.ErrProbeFilterSupport)
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package scim
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit paces outgoing requests to at most rps requests per second
+// with bursts of up to burst requests. Requests block until allowed or
+// until their context is done.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+	}
+}
+
+func (c *Client) waitRateLimit(req *http.Request) error {
+	if c.limiter == nil {
+		return nil
+	}
+
+	if c.limiter.Tokens() < 1 {
+		c.logger.Debug("Waiting for SCIM rate limit", "method", req.Method, "url", req.URL.Redacted())
+	}
+
+	return c.limiter.Wait(req.Context())
+}
@@ -0,0 +1,70 @@
+package scim_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func getRateLimitedClient(t *testing.T, rps float64, burst int) *scim.Client {
+	t.Helper()
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithRateLimit(rps, burst),
+	)
+	assert.NoError(t, err)
+
+	return client
+}
+
+func TestRateLimit(t *testing.T) {
+	server := getServer(t, http.StatusOK, GetUserResponse)
+	defer server.Close()
+
+	const (
+		rps      = 20
+		requests = 5
+	)
+
+	client := getRateLimitedClient(t, rps, 1)
+
+	start := time.Now()
+
+	for range requests {
+		_, err := client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+		assert.NoError(t, err)
+	}
+
+	// The first request uses the burst, each further one waits 1/rps
+	assert.GreaterOrEqual(t, time.Since(start), (requests-1)*time.Second/rps)
+}
+
+func TestRateLimitRespectsContext(t *testing.T) {
+	server := getServer(t, http.StatusOK, GetUserResponse)
+	defer server.Close()
+
+	client := getRateLimitedClient(t, 0.1, 1)
+
+	_, err := client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = client.GetUser(ctx, "123", scim.RequestParams{Host: server.URL})
+	assert.ErrorIs(t, err, scim.ErrGetUser)
+}
@@ -0,0 +1,74 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+var ErrDo = errors.New("error sending SCIM request")
+
+type rawBodyKey struct{}
+
+// ContextWithRawBody returns a context whose requests store the undecoded
+// body of their final response in body, e.g. to debug schema mismatches.
+// With several requests made with the context, body holds the last one.
+func ContextWithRawBody(ctx context.Context, body *[]byte) context.Context {
+	return context.WithValue(ctx, rawBodyKey{}, body)
+}
+
+// captureRawBody stores the response body for a context made with
+// ContextWithRawBody, leaving it readable for decoding.
+func captureRawBody(ctx context.Context, resp *http.Response) error {
+	target, ok := ctx.Value(rawBodyKey{}).(*[]byte)
+	if !ok || target == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+
+	closeErr := resp.Body.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	*target = body
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return nil
+}
+
+// Do sends a request with the method to the path, e.g. /Users/123, on
+// the host of the params, with their headers and attribute projection,
+// and the body, if not nil, as SCIM JSON. Unlike the other methods, it
+// returns the response undecoded whatever its status, leaving its
+// headers, e.g. ETag or rate limits, and its body, which may be
+// streamed, to the caller, who must close the body.
+func (c *Client) Do(
+	ctx context.Context,
+	method string,
+	path string,
+	body []byte,
+	params RequestParams,
+) (*http.Response, error) {
+	var queryString *string
+	if query := projectionQuery(params.Attributes, params.ExcludedAttributes); len(query) > 0 {
+		queryString = ptr.String(query.Encode())
+	}
+
+	resp, err := c.baseCreateAndExecuteHTTPRequest(ctx, params.Host, method, path, queryString, body, params.Headers)
+	if err != nil {
+		return nil, errs.Wrap(ErrDo, err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,110 @@
+package scim_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestContextWithRawBody(t *testing.T) {
+	server := getServer(t, http.StatusOK, GetUserResponse)
+	defer server.Close()
+
+	client := getBasicClient()
+
+	var body []byte
+
+	user, err := client.GetUser(scim.ContextWithRawBody(t.Context(), &body), "123", scim.RequestParams{Host: server.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, "cloudanalyst", user.UserName)
+	assert.JSONEq(t, GetUserResponse, string(body))
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           []byte
+		params         scim.RequestParams
+		responseStatus int
+		expectedQuery  string
+		expectedBody   string
+	}{
+		{
+			name:           "Get resource",
+			method:         http.MethodGet,
+			path:           "/Users/123",
+			params:         scim.RequestParams{Attributes: []string{"userName"}},
+			responseStatus: http.StatusOK,
+			expectedQuery:  "attributes=userName",
+		},
+		{
+			name:           "Patch resource",
+			method:         http.MethodPatch,
+			path:           "/Users/123",
+			body:           []byte(`{"Operations":[]}`),
+			responseStatus: http.StatusOK,
+			expectedBody:   `{"Operations":[]}`,
+		},
+		{
+			name:           "Error status",
+			method:         http.MethodGet,
+			path:           "/Users/456",
+			responseStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.method, r.Method)
+				assert.Equal(t, tt.path, r.URL.Path)
+				assert.Equal(t, tt.expectedQuery, r.URL.RawQuery)
+				assert.Equal(t, "value", r.Header.Get("X-Custom"))
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedBody, string(body))
+
+				w.Header().Set("ETag", `W/"1"`)
+				w.Header().Set("X-RateLimit-Remaining", "42")
+				w.WriteHeader(tt.responseStatus)
+				_, _ = w.Write([]byte(GetUserResponse))
+			}))
+			defer server.Close()
+
+			client := getBasicClient()
+
+			params := tt.params
+			params.Host = server.URL
+			params.Headers = map[string]string{"X-Custom": "value"}
+
+			resp, err := client.Do(t.Context(), tt.method, tt.path, tt.body, params)
+			assert.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.responseStatus, resp.StatusCode)
+			assert.Equal(t, `W/"1"`, resp.Header.Get("ETag"))
+			assert.Equal(t, "42", resp.Header.Get("X-RateLimit-Remaining"))
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.JSONEq(t, GetUserResponse, string(body))
+		})
+	}
+}
+
+func TestDoRequestError(t *testing.T) {
+	client := getBasicClient()
+
+	resp, err := client.Do(t.Context(), http.MethodGet, "/Users/123", nil, scim.RequestParams{Host: "http://127.0.0.1:0"})
+	assert.ErrorIs(t, err, scim.ErrDo)
+	assert.Nil(t, resp)
+}
@@ -0,0 +1,59 @@
+package scim
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+// RedirectPolicy decides which redirects the client follows.
+type RedirectPolicy string
+
+const (
+	// RedirectNone follows no redirect, returning the redirect response.
+	RedirectNone RedirectPolicy = "none"
+	// RedirectSameHost follows redirects to the host and scheme of the
+	// original request only.
+	RedirectSameHost RedirectPolicy = "sameHost"
+
+	maxRedirects = 10
+)
+
+var ErrInvalidRedirectPolicy = errors.New("invalid redirect policy")
+
+// ParseRedirectPolicy parses a case-insensitive redirect policy.
+func ParseRedirectPolicy(policy string) (RedirectPolicy, error) {
+	switch {
+	case strings.EqualFold(policy, string(RedirectNone)):
+		return RedirectNone, nil
+	case strings.EqualFold(policy, string(RedirectSameHost)):
+		return RedirectSameHost, nil
+	default:
+		return "", errs.Wrapf(ErrInvalidRedirectPolicy, policy)
+	}
+}
+
+// WithRedirectPolicy sets the redirects the client follows, RedirectNone
+// by default. Redirects not followed are returned as responses, failing
+// the request with their unexpected status code.
+func WithRedirectPolicy(policy RedirectPolicy) Option {
+	return func(c *Client) {
+		c.redirectPolicy = policy
+	}
+}
+
+// checkRedirect implements http.Client.CheckRedirect for the policy.
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if c.redirectPolicy != RedirectSameHost || len(via) >= maxRedirects {
+		return http.ErrUseLastResponse
+	}
+
+	original := via[0].URL
+	if req.URL.Scheme != original.Scheme || req.URL.Host != original.Host {
+		return http.ErrUseLastResponse
+	}
+
+	return nil
+}
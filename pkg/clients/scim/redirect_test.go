@@ -0,0 +1,120 @@
+package scim_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+func TestRedirectPolicy(t *testing.T) {
+	var otherHostRequests atomic.Int32
+
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		otherHostRequests.Add(1)
+
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer otherHost.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/Users/same":
+			http.Redirect(w, r, "/Users/target", http.StatusFound)
+		case "/Users/cross":
+			http.Redirect(w, r, otherHost.URL+"/Users/target", http.StatusFound)
+		case "/Users/loop":
+			http.Redirect(w, r, "/Users/loop", http.StatusFound)
+		default:
+			_, err := w.Write([]byte(GetUserResponse))
+			assert.NoError(t, err)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		policy      *scim.RedirectPolicy
+		userID      string
+		expectError bool
+	}{
+		{name: "Default same host", userID: "same", expectError: true},
+		{name: "None same host", policy: ptr.To(scim.RedirectNone), userID: "same", expectError: true},
+		{name: "None cross host", policy: ptr.To(scim.RedirectNone), userID: "cross", expectError: true},
+		{name: "Same host policy same host", policy: ptr.To(scim.RedirectSameHost), userID: "same"},
+		{name: "Same host policy cross host", policy: ptr.To(scim.RedirectSameHost), userID: "cross", expectError: true},
+		{name: "Same host policy loop", policy: ptr.To(scim.RedirectSameHost), userID: "loop", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []scim.Option
+			if tt.policy != nil {
+				opts = append(opts, scim.WithRedirectPolicy(*tt.policy))
+			}
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				opts...,
+			)
+			assert.NoError(t, err)
+
+			user, err := client.GetUser(t.Context(), tt.userID, scim.RequestParams{Host: server.URL})
+			if !tt.expectError {
+				assert.NoError(t, err)
+				assert.Equal(t, &ExpectedUser, user)
+
+				return
+			}
+
+			var statusErr *httpclient.StatusCodeError
+
+			assert.ErrorIs(t, err, scim.ErrGetUser)
+			assert.True(t, errors.As(err, &statusErr))
+			assert.Equal(t, http.StatusFound, statusErr.StatusCode)
+			assert.Zero(t, otherHostRequests.Load())
+		})
+	}
+}
+
+func TestParseRedirectPolicy(t *testing.T) {
+	tests := []struct {
+		value         string
+		expected      scim.RedirectPolicy
+		expectedError error
+	}{
+		{value: "none", expected: scim.RedirectNone},
+		{value: "sameHost", expected: scim.RedirectSameHost},
+		{value: "SAMEHOST", expected: scim.RedirectSameHost},
+		{value: "all", expectedError: scim.ErrInvalidRedirectPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			policy, err := scim.ParseRedirectPolicy(tt.value)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package scim
+
+import (
+	"context"
+	"errors"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+var (
+	ErrGroupNotFound  = errors.New("SCIM group not found")
+	ErrAmbiguousGroup = errors.New("several SCIM groups match")
+)
+
+// ResolveGroupID returns the ID of the group with the display name,
+// listing groups with only their ID projected. It fails with
+// ErrGroupNotFound if no group matches and ErrAmbiguousGroup if
+// several do.
+func (c *Client) ResolveGroupID(ctx context.Context, displayName string, params RequestParams) (string, error) {
+	params.Filter = FilterComparison{Attribute: "displayName", Operator: FilterOperatorEqual, Value: displayName}
+	params.Attributes = []string{"id"}
+	params.ExcludedAttributes = nil
+
+	// Two groups are enough to tell an ambiguous name
+	params.Count = ptr.To(2)
+
+	groups, err := c.ListGroups(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case len(groups.Resources) == 0:
+		return "", errs.Wrapf(ErrGroupNotFound, displayName)
+	case len(groups.Resources) > 1 || groups.TotalResults > 1:
+		return "", errs.Wrapf(ErrAmbiguousGroup, displayName)
+	default:
+		return groups.Resources[0].ID, nil
+	}
+}
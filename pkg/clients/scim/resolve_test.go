@@ -0,0 +1,67 @@
+package scim_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestResolveGroupID(t *testing.T) {
+	tests := []struct {
+		name          string
+		responseBody  string
+		expectedID    string
+		expectedError error
+	}{
+		{
+			name:         "Found",
+			responseBody: `{"totalResults":1,"Resources":[{"id":"group1"}]}`,
+			expectedID:   "group1",
+		},
+		{
+			name:          "Not found",
+			responseBody:  `{"totalResults":0,"Resources":[]}`,
+			expectedError: scim.ErrGroupNotFound,
+		},
+		{
+			name:          "Ambiguous",
+			responseBody:  `{"totalResults":2,"Resources":[{"id":"group1"},{"id":"group2"}]}`,
+			expectedError: scim.ErrAmbiguousGroup,
+		},
+		{
+			name:          "Ambiguous beyond the page",
+			responseBody:  `{"totalResults":3,"itemsPerPage":1,"Resources":[{"id":"group1"}]}`,
+			expectedError: scim.ErrAmbiguousGroup,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/Groups/", r.URL.Path)
+				assert.Equal(t, `displayName eq "KeyAdmin"`, r.URL.Query().Get("filter"))
+				assert.Equal(t, "id", r.URL.Query().Get("attributes"))
+				assert.Equal(t, "2", r.URL.Query().Get("count"))
+
+				_, err := w.Write([]byte(tt.responseBody))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			id, err := getBasicClient().ResolveGroupID(t.Context(), "KeyAdmin", scim.RequestParams{Host: server.URL})
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.ErrorContains(t, err, "KeyAdmin")
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedID, id)
+		})
+	}
+}
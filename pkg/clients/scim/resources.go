@@ -0,0 +1,161 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+const (
+	ResourceTypeUser  = "User"
+	ResourceTypeGroup = "Group"
+)
+
+var (
+	ErrSearch              = errors.New("error searching SCIM resources")
+	ErrUnknownResourceType = errors.New("unknown SCIM resource type")
+)
+
+// ResourceList is a list response whose resources may be of mixed types,
+// as returned by a search across resource types. Each resource is
+// decoded into a *User or *Group based on its meta.resourceType,
+// falling back to its declared core schema.
+//
+//nolint:tagliatelle
+type ResourceList struct {
+	Schemas   []string `json:"schemas,omitempty"`
+	Resources []any    `json:"Resources"`
+}
+
+func (l *ResourceList) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Schemas   []string          `json:"schemas"`
+		Resources []json.RawMessage `json:"Resources"` //nolint:tagliatelle
+	}
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	l.Schemas = raw.Schemas
+	l.Resources = make([]any, len(raw.Resources))
+
+	for i, resource := range raw.Resources {
+		l.Resources[i], err = decodeResource(resource)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Users returns the users of the list.
+func (l *ResourceList) Users() []*User {
+	return resourcesOfType[*User](l.Resources)
+}
+
+// Groups returns the groups of the list.
+func (l *ResourceList) Groups() []*Group {
+	return resourcesOfType[*Group](l.Resources)
+}
+
+// Search searches SCIM resources of any type from the server root,
+// using POST /.search or GET with the parameters in the query string.
+func (c *Client) Search(ctx context.Context, params RequestParams) (*ResourceList, error) {
+	resp, err := c.createAndExecuteHTTPRequest(ctx, params, "")
+	if err != nil {
+		return nil, errs.Wrap(ErrSearch, err)
+	}
+
+	defer c.closeBody(resp, "Search")
+
+	if c.isEmptyListResponse(resp) {
+		return &ResourceList{}, nil
+	}
+
+	err = c.renameListResourcesKey(resp)
+	if err != nil {
+		return nil, errs.Wrap(ErrSearch, err)
+	}
+
+	err = c.normalizeResponseCase(resp)
+	if err != nil {
+		return nil, errs.Wrap(ErrSearch, err)
+	}
+
+	resources, err := httpclient.DecodeResponse[ResourceList](ctx, "SCIM", resp, http.StatusOK, c.decodeOptions()...)
+	if err != nil {
+		return nil, errs.Wrap(ErrSearch, err)
+	}
+
+	err = c.validateListSchema(resources.Schemas)
+	if err != nil {
+		return nil, errs.Wrap(ErrSearch, err)
+	}
+
+	return resources, nil
+}
+
+func decodeResource(data json.RawMessage) (any, error) {
+	var base BaseResource
+
+	err := json.Unmarshal(data, &base)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resourceType(base) {
+	case ResourceTypeUser:
+		return decodeAs[User](data)
+	case ResourceTypeGroup:
+		return decodeAs[Group](data)
+	default:
+		return nil, errs.Wrapf(ErrUnknownResourceType, base.Meta.ResourceType)
+	}
+}
+
+func resourceType(base BaseResource) string {
+	if base.Meta.ResourceType != "" {
+		return base.Meta.ResourceType
+	}
+
+	switch base.CoreSchema() {
+	case UserSchema:
+		return ResourceTypeUser
+	case GroupSchema:
+		return ResourceTypeGroup
+	default:
+		return ""
+	}
+}
+
+func decodeAs[T User | Group](data json.RawMessage) (*T, error) {
+	var resource T
+
+	err := json.Unmarshal(data, &resource)
+	if err != nil {
+		return nil, err
+	}
+
+	resolveID(&resource)
+
+	return &resource, nil
+}
+
+func resourcesOfType[T any](resources []any) []T {
+	var typed []T
+
+	for _, resource := range resources {
+		if r, ok := resource.(T); ok {
+			typed = append(typed, r)
+		}
+	}
+
+	return typed
+}
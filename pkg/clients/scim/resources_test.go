@@ -0,0 +1,121 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+const MixedListResponse = `{"Resources":[` + GetUserResponse + `,` + GetGroupResponse + `],` +
+	`"schemas":["urn:ietf:params:scim:api:messages:2.0:ListResponse"],` +
+	`"totalResults":2,"itemsPerPage":2,"startIndex":1}`
+
+func TestResourceListDecode(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      []any
+		expectedError error
+	}{
+		{
+			name:     "Mixed user and group",
+			input:    MixedListResponse,
+			expected: []any{&ExpectedUser, &ExpectedGroup},
+		},
+		{
+			name: "Resource type from schemas",
+			input: `{"Resources":[{"id":"1","schemas":["urn:ietf:params:scim:schemas:core:2.0:Group"],` +
+				`"displayName":"KeyAdmin"}]}`,
+			expected: []any{&scim.Group{
+				BaseResource: scim.BaseResource{ID: "1", Schemas: []string{scim.GroupSchema}},
+				DisplayName:  "KeyAdmin",
+			}},
+		},
+		{
+			name:          "Unknown resource type",
+			input:         `{"Resources":[{"id":"1","meta":{"resourceType":"Device"}}]}`,
+			expectedError: scim.ErrUnknownResourceType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list scim.ResourceList
+
+			err := json.Unmarshal([]byte(tt.input), &list)
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, list.Resources)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	server := getServer(t, http.StatusOK, MixedListResponse)
+	defer server.Close()
+
+	list, err := getBasicClient().Search(t.Context(), scim.RequestParams{
+		Host:   server.URL,
+		Method: http.MethodPost,
+		Filter: scim.FilterComparison{Attribute: "displayName", Operator: scim.FilterOperatorEqual, Value: "KeyAdmin"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*scim.User{&ExpectedUser}, list.Users())
+	assert.Equal(t, []*scim.Group{&ExpectedGroup}, list.Groups())
+}
+
+func TestSearchStrictListSchema(t *testing.T) {
+	tests := []struct {
+		name          string
+		responseBody  string
+		expectedError error
+	}{
+		{
+			name:         "Mixed list",
+			responseBody: MixedListResponse,
+		},
+		{
+			name:          "Single user body",
+			responseBody:  GetUserResponse,
+			expectedError: scim.ErrNotListResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, http.StatusOK, tt.responseBody)
+			defer server.Close()
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithStrictSchemaValidation(),
+			)
+			assert.NoError(t, err)
+
+			_, err = client.Search(t.Context(), scim.RequestParams{Host: server.URL, Method: http.MethodGet})
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, scim.ErrSearch)
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package scim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type retryBudgetKey struct{}
+
+// RetryBudget bounds the total number of retries shared by all requests
+// carrying it in their context, e.g. the fan-out of a single RPC.
+type RetryBudget struct {
+	remaining atomic.Int64
+}
+
+// NewRetryBudget creates a budget allowing up to size retries in total.
+func NewRetryBudget(size int) *RetryBudget {
+	b := &RetryBudget{}
+	b.remaining.Store(int64(size))
+
+	return b
+}
+
+// TryAcquire consumes a retry from the budget, reporting false if it is exhausted.
+func (b *RetryBudget) TryAcquire() bool {
+	for {
+		remaining := b.remaining.Load()
+		if remaining <= 0 {
+			return false
+		}
+
+		if b.remaining.CompareAndSwap(remaining, remaining-1) {
+			return true
+		}
+	}
+}
+
+// Remaining returns the number of retries left in the budget.
+func (b *RetryBudget) Remaining() int {
+	return int(b.remaining.Load())
+}
+
+// ContextWithRetryBudget returns a context whose requests share the given retry budget.
+func ContextWithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// RetryBudgetFromContext returns the retry budget carried by the context, if any.
+func RetryBudgetFromContext(ctx context.Context) (*RetryBudget, bool) {
+	budget, ok := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget, ok && budget != nil
+}
+
+// WithRetries enables retrying requests failing with a transport error,
+// a 5xx or a 429 status, unless their SCIM error type is one retrying
+// cannot fix, up to maxRetries times, with an exponential
+// backoff starting at backoff and capped at 30 seconds. Only idempotent
+// requests and searches are retried, and not once their context is
+// done. Retries are additionally bounded by any RetryBudget carried by
+// the request context.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// scimTypeTooMany is the error type of a filter yielding too many
+// results with a 400 status, which some servers also use for throttling
+// with a 429 one.
+const scimTypeTooMany = "tooMany"
+
+// terminalScimTypes are the error types (RFC 7644 section 3.12) of
+// requests failing the same way when retried, whatever the status.
+var terminalScimTypes = map[string]struct{}{
+	"invalidFilter": {},
+	"uniqueness":    {},
+	"mutability":    {},
+	"invalidSyntax": {},
+	"invalidPath":   {},
+	"noTarget":      {},
+	"invalidValue":  {},
+	"invalidVers":   {},
+	"sensitive":     {},
+}
+
+// isIdempotent reports whether the request may be sent again without
+// further effect: requests with an idempotent method (RFC 9110 section
+// 9.2.2) and searches with POST /.search. Other writes, e.g. creating a
+// user, are not retried as a failed attempt may still have been applied.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return strings.HasSuffix(req.URL.Path, "/"+PostSearchPath)
+	default:
+		return false
+	}
+}
+
+// isRetryable reports whether the request failed with a transport error,
+// a 5xx or a 429 status, unless the SCIM error type of the response marks
+// the failure as terminal. The response body is left readable.
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < http.StatusInternalServerError {
+		return false
+	}
+
+	return isRetryableScimType(resp.StatusCode, readScimType(resp))
+}
+
+// isRetryableScimType classifies the SCIM error type of a response
+// with the given status, an empty one being retryable.
+func isRetryableScimType(statusCode int, scimType string) bool {
+	if scimType == scimTypeTooMany {
+		return statusCode == http.StatusTooManyRequests
+	}
+
+	_, terminal := terminalScimTypes[scimType]
+
+	return !terminal
+}
+
+// readScimType returns the scimType of the SCIM error in the response
+// body, replacing the body so that it can be read again.
+func readScimType(resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+
+	closeErr := resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err != nil || closeErr != nil {
+		return ""
+	}
+
+	var scimErr struct {
+		ScimType string `json:"scimType"`
+	}
+
+	err = json.Unmarshal(body, &scimErr)
+	if err != nil {
+		return ""
+	}
+
+	return scimErr.ScimType
+}
+
+// acquireRetry reports whether another attempt may be made after the given one.
+func (c *Client) acquireRetry(ctx context.Context, attempt int) bool {
+	if attempt >= c.maxRetries {
+		return false
+	}
+
+	budget, ok := RetryBudgetFromContext(ctx)
+	if ok && !budget.TryAcquire() {
+		return false
+	}
+
+	return true
+}
+
+// waitBackoff sleeps for the exponential backoff of the given attempt or until ctx is done.
+func (c *Client) waitBackoff(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(c.backoff(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// maxRetryBackoff caps the exponential backoff between retries.
+const maxRetryBackoff = 30 * time.Second
+
+// backoff returns the exponential backoff after the given attempt,
+// capped at maxRetryBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := min(c.retryBackoff, maxRetryBackoff)
+
+	for range attempt {
+		if backoff >= maxRetryBackoff/2 {
+			return maxRetryBackoff
+		}
+
+		backoff *= 2
+	}
+
+	return backoff
+}
@@ -0,0 +1,353 @@
+package scim_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func getRetryingClient(t *testing.T, maxRetries int) *scim.Client {
+	t.Helper()
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithRetries(maxRetries, time.Millisecond),
+	)
+	assert.NoError(t, err)
+
+	return client
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		maxRetries       int
+		expectedRequests int
+		expectError      bool
+	}{
+		{
+			name:             "Retries disabled",
+			failures:         1,
+			maxRetries:       0,
+			expectedRequests: 1,
+			expectError:      true,
+		},
+		{
+			name:             "Succeeds after retry",
+			failures:         2,
+			maxRetries:       3,
+			expectedRequests: 3,
+			expectError:      false,
+		},
+		{
+			name:             "Gives up after max retries",
+			failures:         10,
+			maxRetries:       3,
+			expectedRequests: 4,
+			expectError:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if int(requests.Add(1)) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+
+				_, err := w.Write([]byte(GetUserResponse))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			user, err := getRetryingClient(t, tt.maxRetries).GetUser(
+				t.Context(), "123", scim.RequestParams{Host: server.URL},
+			)
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, scim.ErrGetUser)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, &ExpectedUser, user)
+			}
+
+			assert.Equal(t, tt.expectedRequests, int(requests.Load()))
+		})
+	}
+}
+
+func TestRetryScimType(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           int
+		scimType         string
+		expectedRequests int
+	}{
+		{
+			name:             "Invalid filter not retried",
+			status:           http.StatusBadRequest,
+			scimType:         "invalidFilter",
+			expectedRequests: 1,
+		},
+		{
+			name:             "Invalid filter with server error not retried",
+			status:           http.StatusInternalServerError,
+			scimType:         "invalidFilter",
+			expectedRequests: 1,
+		},
+		{
+			name:             "Mutability not retried",
+			status:           http.StatusServiceUnavailable,
+			scimType:         "mutability",
+			expectedRequests: 1,
+		},
+		{
+			name:             "Too many results not retried",
+			status:           http.StatusBadRequest,
+			scimType:         "tooMany",
+			expectedRequests: 1,
+		},
+		{
+			name:             "Rate limit retried",
+			status:           http.StatusTooManyRequests,
+			scimType:         "tooMany",
+			expectedRequests: 3,
+		},
+		{
+			name:             "Server error without type retried",
+			status:           http.StatusServiceUnavailable,
+			expectedRequests: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+
+				w.WriteHeader(tt.status)
+				_, err := fmt.Fprintf(w,
+					`{"schemas":["urn:ietf:params:scim:api:messages:2.0:Error"],"status":"%d","scimType":%q}`,
+					tt.status, tt.scimType)
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			var rawBody []byte
+
+			ctx := scim.ContextWithRawBody(t.Context(), &rawBody)
+
+			_, err := getRetryingClient(t, 2).GetUser(ctx, "123", scim.RequestParams{Host: server.URL})
+			assert.ErrorIs(t, err, scim.ErrGetUser)
+			assert.Equal(t, tt.expectedRequests, int(requests.Load()))
+
+			// The error body stays readable after its classification
+			assert.Contains(t, string(rawBody), tt.scimType)
+		})
+	}
+}
+
+func TestRetriedSearchResendsBody(t *testing.T) {
+	var (
+		requests atomic.Int32
+		bodies   []string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/Users/.search", r.URL.Path)
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		bodies = append(bodies, string(body))
+
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, err = w.Write([]byte(ListUsersResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	users, err := getRetryingClient(t, 3).ListUsers(t.Context(), scim.RequestParams{
+		Host:   server.URL,
+		Method: http.MethodPost,
+		Filter: scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "john"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, users.Resources, 1)
+
+	assert.Len(t, bodies, 3)
+	assert.NotEmpty(t, bodies[0])
+
+	for _, body := range bodies {
+		assert.Equal(t, bodies[0], body)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	const (
+		calls      = 10
+		budgetSize = 3
+	)
+
+	client := getRetryingClient(t, 5)
+	budget := scim.NewRetryBudget(budgetSize)
+	ctx := scim.ContextWithRetryBudget(t.Context(), budget)
+
+	for range calls {
+		_, err := client.GetUser(ctx, "123", scim.RequestParams{Host: server.URL})
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, calls+budgetSize, int(requests.Load()))
+	assert.Equal(t, 0, budget.Remaining())
+}
+
+func TestRetryMethods(t *testing.T) {
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		expectedRequests int
+	}{
+		{name: "GET retried", method: http.MethodGet, path: "/Users/123", expectedRequests: 3},
+		{name: "PUT retried", method: http.MethodPut, path: "/Users/123", expectedRequests: 3},
+		{name: "DELETE retried", method: http.MethodDelete, path: "/Users/123", expectedRequests: 3},
+		{name: "Search retried", method: http.MethodPost, path: "/Users/.search", expectedRequests: 3},
+		{name: "Create not retried", method: http.MethodPost, path: "/Users", expectedRequests: 1},
+		{name: "PATCH not retried", method: http.MethodPatch, path: "/Groups/123", expectedRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			resp, err := getRetryingClient(t, 2).Do(t.Context(), tt.method, tt.path, []byte(`{}`), scim.RequestParams{Host: server.URL})
+			assert.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+			assert.Equal(t, tt.expectedRequests, int(requests.Load()))
+		})
+	}
+}
+
+func TestNoRetryOnceContextDone(t *testing.T) {
+	var requests atomic.Int32
+
+	unblock := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			<-unblock
+		}
+
+		_, err := w.Write([]byte(GetUserResponse))
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+	defer close(unblock)
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		getLogger(),
+		scim.WithRetries(3, time.Millisecond),
+		scim.WithCircuitBreaker(1, time.Hour),
+	)
+	assert.NoError(t, err)
+
+	params := scim.RequestParams{Host: server.URL}
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = client.GetUser(ctx, "123", params)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, int(requests.Load()))
+
+	// The abandoned request neither used retries nor tripped the breaker
+	user, err := client.GetUser(t.Context(), "123", params)
+	assert.NoError(t, err)
+	assert.Equal(t, &ExpectedUser, user)
+}
+
+func TestRetryBackoffCap(t *testing.T) {
+	tests := []struct {
+		name     string
+		backoff  time.Duration
+		attempt  int
+		expected time.Duration
+	}{
+		{name: "First attempt", backoff: time.Second, attempt: 0, expected: time.Second},
+		{name: "Exponential", backoff: time.Second, attempt: 3, expected: 8 * time.Second},
+		{name: "Capped", backoff: time.Second, attempt: 10, expected: 30 * time.Second},
+		{name: "No overflow", backoff: time.Second, attempt: 100, expected: 30 * time.Second},
+		{name: "Initial backoff capped", backoff: time.Hour, attempt: 0, expected: 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				scim.WithRetries(1, tt.backoff),
+			)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.expected, client.Backoff(tt.attempt))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package scim
+
+const SAPUserSchema = "urn:ietf:params:scim:schemas:extension:sap:2.0:User"
+
+// LifecycleStatus is the state of a user account relevant for
+// provisioning and authorization decisions.
+type LifecycleStatus string
+
+const (
+	// LifecycleActive is an account that can be used.
+	LifecycleActive LifecycleStatus = "active"
+	// LifecycleInactive is a deactivated account.
+	LifecycleInactive LifecycleStatus = "inactive"
+	// LifecycleLocked is an account whose password is locked or disabled.
+	LifecycleLocked LifecycleStatus = "locked"
+	// LifecycleInitial is an account whose initial password was never changed.
+	LifecycleInitial LifecycleStatus = "initial"
+)
+
+// SAP user extension statuses
+const (
+	sapStatusInactive = "inactive"
+
+	sapPasswordStatusInitial  = "initial"
+	sapPasswordStatusLocked   = "locked"
+	sapPasswordStatusDisabled = "disabled"
+)
+
+// SAPUserExtension holds the lifecycle fields of the SAP user extension.
+type SAPUserExtension struct {
+	Status          string             `json:"status,omitempty"`
+	PasswordDetails SAPPasswordDetails `json:"passwordDetails"`
+}
+
+type SAPPasswordDetails struct {
+	Status              string `json:"status,omitempty"`
+	FailedLoginAttempts int    `json:"failedLoginAttempts"`
+}
+
+// LifecycleStatus derives the account state from the core active flag
+// and, if present, the SAP extension status and password status.
+func (u *User) LifecycleStatus() LifecycleStatus {
+	if !u.Active || (u.SAP != nil && u.SAP.Status == sapStatusInactive) {
+		return LifecycleInactive
+	}
+
+	if u.SAP == nil {
+		return LifecycleActive
+	}
+
+	switch u.SAP.PasswordDetails.Status {
+	case sapPasswordStatusLocked, sapPasswordStatusDisabled:
+		return LifecycleLocked
+	case sapPasswordStatusInitial:
+		return LifecycleInitial
+	default:
+		return LifecycleActive
+	}
+}
@@ -0,0 +1,79 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestSAPUserExtension(t *testing.T) {
+	var user scim.User
+
+	err := json.Unmarshal([]byte(GetUserResponse), &user)
+	assert.NoError(t, err)
+
+	assert.Equal(t, &scim.SAPUserExtension{
+		Status: "active",
+		PasswordDetails: scim.SAPPasswordDetails{
+			Status:              "initial",
+			FailedLoginAttempts: 0,
+		},
+	}, user.SAP)
+	assert.Equal(t, scim.LifecycleInitial, user.LifecycleStatus())
+}
+
+func TestLifecycleStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected scim.LifecycleStatus
+	}{
+		{
+			name:     "Active without extension",
+			input:    `{"id":"1","active":true}`,
+			expected: scim.LifecycleActive,
+		},
+		{
+			name:     "Inactive without extension",
+			input:    `{"id":"1","active":false}`,
+			expected: scim.LifecycleInactive,
+		},
+		{
+			name: "Active with enabled password",
+			input: `{"id":"1","active":true,"urn:ietf:params:scim:schemas:extension:sap:2.0:User":` +
+				`{"status":"active","passwordDetails":{"status":"enabled","failedLoginAttempts":1}}}`,
+			expected: scim.LifecycleActive,
+		},
+		{
+			name: "Initial password",
+			input: `{"id":"1","active":true,"urn:ietf:params:scim:schemas:extension:sap:2.0:User":` +
+				`{"status":"active","passwordDetails":{"status":"initial"}}}`,
+			expected: scim.LifecycleInitial,
+		},
+		{
+			name: "Locked password",
+			input: `{"id":"1","active":true,"urn:ietf:params:scim:schemas:extension:sap:2.0:User":` +
+				`{"status":"active","passwordDetails":{"status":"locked","failedLoginAttempts":5}}}`,
+			expected: scim.LifecycleLocked,
+		},
+		{
+			name: "Inactive extension status",
+			input: `{"id":"1","active":true,"urn:ietf:params:scim:schemas:extension:sap:2.0:User":` +
+				`{"status":"inactive","passwordDetails":{"status":"enabled"}}}`,
+			expected: scim.LifecycleInactive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var user scim.User
+
+			err := json.Unmarshal([]byte(tt.input), &user)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, user.LifecycleStatus())
+		})
+	}
+}
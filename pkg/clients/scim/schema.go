@@ -0,0 +1,96 @@
+package scim
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+const (
+	UserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	GroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+
+	EnterpriseUserSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+
+	ListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+)
+
+var (
+	ErrUnexpectedSchema = errors.New("unexpected SCIM resource schema")
+	ErrNotListResponse  = errors.New("SCIM response is not a ListResponse")
+)
+
+// HasSchema reports whether the resource declares the schema, comparing
+// URNs case-insensitively.
+func (r *BaseResource) HasSchema(urn string) bool {
+	return containsSchema(r.Schemas, urn)
+}
+
+func containsSchema(schemas []string, urn string) bool {
+	return slices.ContainsFunc(schemas, func(schema string) bool {
+		return strings.EqualFold(schema, urn)
+	})
+}
+
+// CoreSchema returns the core User or Group schema declared by the
+// resource, or an empty string if it declares neither.
+func (r *BaseResource) CoreSchema() string {
+	for _, schema := range []string{UserSchema, GroupSchema} {
+		if r.HasSchema(schema) {
+			return schema
+		}
+	}
+
+	return ""
+}
+
+// WithStrictSchemaValidation makes the client reject resources whose
+// schemas do not include the expected core User or Group schema, and
+// list responses not declaring the ListResponse schema.
+func WithStrictSchemaValidation() Option {
+	return func(c *Client) {
+		c.strictSchemas = true
+	}
+}
+
+// WithStrictDecoding makes decoding fail on response fields unknown to
+// the resource types. Meant for tests and CI to catch schema drift,
+// as servers commonly return attributes the client does not model.
+func WithStrictDecoding() Option {
+	return func(c *Client) {
+		c.strictDecoding = true
+	}
+}
+
+func (c *Client) decodeOptions() []httpclient.DecodeOption {
+	if !c.strictDecoding {
+		return nil
+	}
+
+	return []httpclient.DecodeOption{httpclient.DisallowUnknownFields()}
+}
+
+// validateSchema checks that the schemas include the expected one,
+// if strict schema validation is enabled, comparing URNs
+// case-insensitively as HasSchema does.
+func (c *Client) validateSchema(schemas []string, expected string) error {
+	if !c.strictSchemas || containsSchema(schemas, expected) {
+		return nil
+	}
+
+	return errs.Wrapf(ErrUnexpectedSchema, "expected "+expected)
+}
+
+// validateListSchema checks that the schemas declare a ListResponse,
+// if strict schema validation is enabled. This catches single resource
+// bodies, which would otherwise decode as an empty list.
+func (c *Client) validateListSchema(schemas []string) error {
+	if !c.strictSchemas || containsSchema(schemas, ListResponseSchema) {
+		return nil
+	}
+
+	return ErrNotListResponse
+}
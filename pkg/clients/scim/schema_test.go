@@ -0,0 +1,298 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestResourceSchemas(t *testing.T) {
+	tests := []struct {
+		name               string
+		responseBody       string
+		expectedCoreSchema string
+		expectedSchemas    map[string]bool
+	}{
+		{
+			name:               "Sample user",
+			responseBody:       GetUserResponse,
+			expectedCoreSchema: scim.UserSchema,
+			expectedSchemas: map[string]bool{
+				scim.UserSchema:                     true,
+				scim.SAPUserSchema:                  true,
+				strings.ToUpper(scim.SAPUserSchema): true,
+				scim.GroupSchema:                    false,
+				scim.EnterpriseUserSchema:           false,
+				"urn:sap:cloud:scim:schemas:extension:custom:2.0:Group": false,
+			},
+		},
+		{
+			name:               "Sample group",
+			responseBody:       GetGroupResponse,
+			expectedCoreSchema: scim.GroupSchema,
+			expectedSchemas: map[string]bool{
+				scim.GroupSchema: true,
+				"urn:sap:cloud:scim:schemas:extension:custom:2.0:Group": true,
+				scim.UserSchema:    false,
+				scim.SAPUserSchema: false,
+			},
+		},
+		{
+			name:               "No core schema",
+			responseBody:       `{"schemas":["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"]}`,
+			expectedCoreSchema: "",
+			expectedSchemas: map[string]bool{
+				scim.EnterpriseUserSchema: true,
+				scim.UserSchema:           false,
+			},
+		},
+		{
+			name:               "No schemas",
+			responseBody:       `{}`,
+			expectedCoreSchema: "",
+			expectedSchemas: map[string]bool{
+				scim.UserSchema: false,
+				"":              false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resource scim.BaseResource
+
+			assert.NoError(t, json.Unmarshal([]byte(tt.responseBody), &resource))
+			assert.Equal(t, tt.expectedCoreSchema, resource.CoreSchema())
+
+			for urn, expected := range tt.expectedSchemas {
+				assert.Equal(t, expected, resource.HasSchema(urn), urn)
+			}
+		})
+	}
+}
+
+func TestStrictSchemaValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		strict        bool
+		responseBody  string
+		getGroup      bool
+		expectedError error
+	}{
+		{
+			name:         "Group body as user without strict mode",
+			strict:       false,
+			responseBody: GetGroupResponse,
+		},
+		{
+			name:          "Group body as user in strict mode",
+			strict:        true,
+			responseBody:  GetGroupResponse,
+			expectedError: scim.ErrUnexpectedSchema,
+		},
+		{
+			name:         "User body as user in strict mode",
+			strict:       true,
+			responseBody: GetUserResponse,
+		},
+		{
+			name:          "User body as group in strict mode",
+			strict:        true,
+			responseBody:  GetUserResponse,
+			getGroup:      true,
+			expectedError: scim.ErrUnexpectedSchema,
+		},
+		{
+			name:         "Group body as group in strict mode",
+			strict:       true,
+			responseBody: GetGroupResponse,
+			getGroup:     true,
+		},
+		{
+			name:         "User body with upper case schema in strict mode",
+			strict:       true,
+			responseBody: `{"schemas":["` + strings.ToUpper(scim.UserSchema) + `"],"id":"123","userName":"john"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, http.StatusOK, tt.responseBody)
+			defer server.Close()
+
+			var opts []scim.Option
+			if tt.strict {
+				opts = append(opts, scim.WithStrictSchemaValidation())
+			}
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				opts...,
+			)
+			assert.NoError(t, err)
+
+			params := scim.RequestParams{Host: server.URL}
+
+			if tt.getGroup {
+				_, err = client.GetGroup(t.Context(), "123", "", params)
+			} else {
+				_, err = client.GetUser(t.Context(), "123", params)
+			}
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestStrictDecoding(t *testing.T) {
+	const responseBody = `{"id": "123", "userName": "john", "nickName": "johnny"}`
+
+	tests := []struct {
+		name        string
+		strict      bool
+		expectError bool
+	}{
+		{name: "Lenient ignores unknown field", strict: false},
+		{name: "Strict rejects unknown field", strict: true, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, http.StatusOK, responseBody)
+			defer server.Close()
+
+			var opts []scim.Option
+			if tt.strict {
+				opts = append(opts, scim.WithStrictDecoding())
+			}
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				opts...,
+			)
+			assert.NoError(t, err)
+
+			user, err := client.GetUser(t.Context(), "123", scim.RequestParams{Host: server.URL})
+			if tt.expectError {
+				assert.ErrorIs(t, err, scim.ErrGetUser)
+				assert.ErrorContains(t, err, `unknown field "nickName"`)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "john", user.UserName)
+			}
+		})
+	}
+}
+
+func TestStrictListSchemaValidation(t *testing.T) {
+	tests := []struct {
+		name          string
+		strict        bool
+		responseBody  string
+		listGroups    bool
+		expectedError error
+	}{
+		{
+			name:         "Single user body without strict mode",
+			strict:       false,
+			responseBody: GetUserResponse,
+		},
+		{
+			name:          "Single user body in strict mode",
+			strict:        true,
+			responseBody:  GetUserResponse,
+			expectedError: scim.ErrNotListResponse,
+		},
+		{
+			name:         "User list in strict mode",
+			strict:       true,
+			responseBody: ListUsersResponse,
+		},
+		{
+			name:          "Single group body in strict mode",
+			strict:        true,
+			responseBody:  GetGroupResponse,
+			listGroups:    true,
+			expectedError: scim.ErrNotListResponse,
+		},
+		{
+			name:         "Group list in strict mode",
+			strict:       true,
+			responseBody: ListGroupsResponse,
+			listGroups:   true,
+		},
+		{
+			name:         "User list with upper case schema in strict mode",
+			strict:       true,
+			responseBody: `{"schemas":["` + strings.ToUpper(scim.ListResponseSchema) + `"],"totalResults":0,"Resources":[]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := getServer(t, http.StatusOK, tt.responseBody)
+			defer server.Close()
+
+			var opts []scim.Option
+			if tt.strict {
+				opts = append(opts, scim.WithStrictSchemaValidation())
+			}
+
+			client, err := scim.NewClient(
+				commoncfg.SecretRef{
+					Type: commoncfg.BasicSecretType,
+					Basic: commoncfg.BasicAuth{
+						Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+						Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+					},
+				},
+				getLogger(),
+				opts...,
+			)
+			assert.NoError(t, err)
+
+			params := scim.RequestParams{Host: server.URL}
+
+			if tt.listGroups {
+				_, err = client.ListGroups(t.Context(), params)
+			} else {
+				var users *scim.UserList
+
+				users, err = client.ListUsers(t.Context(), params)
+				if !tt.strict {
+					assert.Empty(t, users.Resources)
+				}
+			}
+
+			if tt.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+// Package scimtest provides an in-memory SCIM server for tests and manual tooling.
+package scimtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+const (
+	ListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	ScimTypeInvalidFilter = "invalidFilter"
+	ScimTypeInvalidCursor = "invalidCursor"
+)
+
+// comparisonPattern matches a single, optionally parenthesized, SCIM comparison.
+var comparisonPattern = regexp.MustCompile(`^\(?\s*([\w.:\-]+)\s+(?:(eq|ne|co|sw|ew|gt|ge|lt|le)\s+"([^"]*)"|(pr))\s*\)?$`)
+
+// Server is an in-memory SCIM server seeded with users and groups.
+// It supports GET by id, listing via GET and POST /.search with
+// simple comparison filters and cursor pagination, creating with POST,
+// replacing with PUT and deleting, and answers 404 for unknown resources.
+// It records the requests it receives, and hooks may answer requests
+// in its place, e.g. to inject failures.
+type Server struct {
+	*httptest.Server
+
+	mu     sync.RWMutex
+	users  []scim.User
+	groups []scim.Group
+	lastID int
+
+	requestsMu sync.Mutex
+	requests   []Request
+	hooks      []Hook
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Hook runs before the server handles each request, and answers the
+// request in its place if it returns true. Hooks may run concurrently.
+type Hook func(w http.ResponseWriter, r *http.Request) bool
+
+// NewServer starts a new in-memory SCIM server. Callers must Close it.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// AddUsers seeds the server with the given users.
+func (s *Server) AddUsers(users ...scim.User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users = append(s.users, users...)
+}
+
+// AddGroups seeds the server with the given groups.
+func (s *Server) AddGroups(groups ...scim.Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups = append(s.groups, groups...)
+}
+
+// OnRequest adds a hook run before the server handles each request.
+func (s *Server) OnRequest(hook Hook) {
+	s.requestsMu.Lock()
+	defer s.requestsMu.Unlock()
+
+	s.hooks = append(s.hooks, hook)
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.requestsMu.Lock()
+	defer s.requestsMu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// record records the request, buffering its body so that it can still
+// be read, and returns the hooks to run.
+func (s *Server) record(r *http.Request) []Hook {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.requestsMu.Lock()
+	defer s.requestsMu.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+
+	return slices.Clone(s.hooks)
+}
+
+type listResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	Resources    []any    `json:"Resources"` //nolint:tagliatelle
+	NextCursor   string   `json:"nextCursor,omitempty"`
+}
+
+// page selects the resources of a list response. The cursor is the
+// offset of the first resource, and all resources are returned
+// from it if count is not positive.
+type page struct {
+	cursor string
+	count  int
+}
+
+type errorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	for _, hook := range s.record(r) {
+		if hook(w, r) {
+			return
+		}
+	}
+
+	if isWrite(r) {
+		s.handleWrite(w, r)
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var resources []any
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, scim.BasePathUsers):
+		for _, user := range s.users {
+			resources = append(resources, user)
+		}
+
+		s.serveResources(w, r, strings.TrimPrefix(r.URL.Path, scim.BasePathUsers), resources)
+	case strings.HasPrefix(r.URL.Path, scim.BasePathGroups):
+		for _, group := range s.groups {
+			resources = append(resources, group)
+		}
+
+		s.serveResources(w, r, strings.TrimPrefix(r.URL.Path, scim.BasePathGroups), resources)
+	default:
+		writeError(w, http.StatusNotFound, "", "unknown resource type")
+	}
+}
+
+func (s *Server) serveResources(w http.ResponseWriter, r *http.Request, subPath string, resources []any) {
+	subPath = strings.TrimPrefix(subPath, "/")
+
+	switch {
+	case subPath == "" && r.Method == http.MethodGet:
+		query := r.URL.Query()
+		count, _ := strconv.Atoi(query.Get("count"))
+
+		s.serveList(w, query.Get("filter"), page{cursor: query.Get("cursor"), count: count}, resources)
+	case subPath == scim.PostSearchPath && r.Method == http.MethodPost:
+		var search scim.SearchRequest
+
+		err := json.NewDecoder(r.Body).Decode(&search)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		filter := ""
+		if search.Filter != nil {
+			filter = *search.Filter
+		}
+
+		listPage := page{}
+		if search.Cursor != nil {
+			listPage.cursor = *search.Cursor
+		}
+
+		if search.Count != nil {
+			listPage.count = *search.Count
+		}
+
+		s.serveList(w, filter, listPage, resources)
+	case r.Method == http.MethodGet:
+		for _, resource := range resources {
+			if resourceID(resource) == subPath {
+				writeJSON(w, http.StatusOK, resource)
+				return
+			}
+		}
+
+		writeError(w, http.StatusNotFound, "", "resource "+subPath+" not found")
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "", "method not allowed")
+	}
+}
+
+// isWrite reports whether the request modifies a resource.
+func isWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return !strings.HasSuffix(r.URL.Path, "/"+scim.PostSearchPath)
+	default:
+		return false
+	}
+}
+
+func (s *Server) handleWrite(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, scim.BasePathUsers):
+		serveWrite(s, w, r, strings.TrimPrefix(r.URL.Path, scim.BasePathUsers), &s.users)
+	case strings.HasPrefix(r.URL.Path, scim.BasePathGroups):
+		serveWrite(s, w, r, strings.TrimPrefix(r.URL.Path, scim.BasePathGroups), &s.groups)
+	default:
+		writeError(w, http.StatusNotFound, "", "unknown resource type")
+	}
+}
+
+// serveWrite creates, replaces or deletes a resource. Created resources
+// are assigned sequential IDs; the caller must hold the write lock.
+func serveWrite[T scim.User | scim.Group](
+	s *Server,
+	w http.ResponseWriter,
+	r *http.Request,
+	subPath string,
+	resources *[]T,
+) {
+	subPath = strings.TrimPrefix(subPath, "/")
+
+	index := slices.IndexFunc(*resources, func(resource T) bool {
+		return subPath != "" && resourceID(resource) == subPath
+	})
+
+	switch {
+	case subPath == "" && r.Method == http.MethodPost:
+		resource, ok := decodeResource[T](w, r)
+		if !ok {
+			return
+		}
+
+		s.lastID++
+		baseResource(&resource).ID = strconv.Itoa(s.lastID)
+		*resources = append(*resources, resource)
+
+		writeJSON(w, http.StatusCreated, resource)
+	case subPath == "" || r.Method == http.MethodPost:
+		writeError(w, http.StatusMethodNotAllowed, "", "method not allowed")
+	case index < 0:
+		writeError(w, http.StatusNotFound, "", "resource "+subPath+" not found")
+	case r.Method == http.MethodPut:
+		resource, ok := decodeResource[T](w, r)
+		if !ok {
+			return
+		}
+
+		baseResource(&resource).ID = subPath
+		(*resources)[index] = resource
+
+		writeJSON(w, http.StatusOK, resource)
+	default:
+		*resources = slices.Delete(*resources, index, index+1)
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func decodeResource[T any](w http.ResponseWriter, r *http.Request) (T, bool) {
+	var resource T
+
+	err := json.NewDecoder(r.Body).Decode(&resource)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return resource, false
+	}
+
+	return resource, true
+}
+
+func baseResource(resource any) *scim.BaseResource {
+	switch r := resource.(type) {
+	case *scim.User:
+		return &r.BaseResource
+	case *scim.Group:
+		return &r.BaseResource
+	default:
+		return &scim.BaseResource{}
+	}
+}
+
+func (s *Server) serveList(w http.ResponseWriter, filter string, listPage page, resources []any) {
+	response := listResponse{
+		Schemas:   []string{ListResponseSchema},
+		Resources: []any{},
+	}
+
+	for _, resource := range resources {
+		matched, ok := matches(filter, resource)
+		if !ok {
+			writeError(w, http.StatusBadRequest, ScimTypeInvalidFilter, "unsupported filter: "+filter)
+			return
+		}
+
+		if matched {
+			response.Resources = append(response.Resources, resource)
+		}
+	}
+
+	response.TotalResults = len(response.Resources)
+
+	offset, err := strconv.Atoi(listPage.cursor)
+	if listPage.cursor != "" && (err != nil || offset < 0) {
+		writeError(w, http.StatusBadRequest, ScimTypeInvalidCursor, "invalid cursor: "+listPage.cursor)
+		return
+	}
+
+	response.Resources = response.Resources[min(offset, len(response.Resources)):]
+	if listPage.count > 0 && listPage.count < len(response.Resources) {
+		response.Resources = response.Resources[:listPage.count]
+		response.NextCursor = strconv.Itoa(offset + listPage.count)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// matches reports whether the resource matches the filter. The second
+// return value is false if the filter is not supported by the mock.
+func matches(filter string, resource any) (bool, bool) {
+	if filter == "" {
+		return true, true
+	}
+
+	groups := comparisonPattern.FindStringSubmatch(strings.TrimSpace(filter))
+	if groups == nil {
+		return false, false
+	}
+
+	comparison := scim.FilterComparison{
+		Attribute: groups[1],
+		Operator:  scim.FilterOperator(groups[2] + groups[4]),
+		Value:     groups[3],
+	}
+
+	return comparison.Evaluate(resource), true
+}
+
+func resourceID(resource any) string {
+	switch r := resource.(type) {
+	case scim.User:
+		return r.ID
+	case scim.Group:
+		return r.ID
+	default:
+		return ""
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, scimType, detail string) {
+	writeJSON(w, status, errorResponse{
+		Schemas:  []string{ErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", scim.ApplicationSCIMJson)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,226 @@
+package scimtest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim/scimtest"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+func getClient(t *testing.T) *scim.Client {
+	t.Helper()
+
+	client, err := scim.NewClient(
+		commoncfg.SecretRef{
+			Type: commoncfg.BasicSecretType,
+			Basic: commoncfg.BasicAuth{
+				Username: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+				Password: commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue},
+			},
+		},
+		hclog.New(&hclog.LoggerOptions{Level: hclog.Error}),
+	)
+	assert.NoError(t, err)
+
+	return client
+}
+
+func getServer() *scimtest.Server {
+	server := scimtest.NewServer()
+	server.AddUsers(
+		scim.User{BaseResource: scim.BaseResource{ID: "user1"}, UserName: "alice", DisplayName: "Alice"},
+		scim.User{BaseResource: scim.BaseResource{ID: "user2"}, UserName: "bob", DisplayName: "Bob"},
+	)
+	server.AddGroups(
+		scim.Group{BaseResource: scim.BaseResource{ID: "group1"}, DisplayName: "KeyAdmin"},
+		scim.Group{BaseResource: scim.BaseResource{ID: "group2"}, DisplayName: "Auditor"},
+	)
+
+	return server
+}
+
+func TestListFilter(t *testing.T) {
+	server := getServer()
+	defer server.Close()
+
+	client := getClient(t)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			groups, err := client.ListGroups(t.Context(), scim.RequestParams{
+				Host:   server.URL,
+				Method: method,
+				Filter: scim.FilterComparison{
+					Attribute: "displayName",
+					Operator:  scim.FilterOperatorEqual,
+					Value:     "KeyAdmin",
+				},
+			})
+			assert.NoError(t, err)
+			assert.Len(t, groups.Resources, 1)
+			assert.Equal(t, "group1", groups.Resources[0].ID)
+
+			users, err := client.ListUsers(t.Context(), scim.RequestParams{
+				Host:   server.URL,
+				Method: method,
+				Filter: scim.FilterComparison{
+					Attribute: "displayName",
+					Operator:  scim.FilterOperatorEqual,
+					Value:     "Nobody",
+				},
+			})
+			assert.NoError(t, err)
+			assert.Empty(t, users.Resources)
+		})
+	}
+}
+
+func TestListUnsupportedFilter(t *testing.T) {
+	server := getServer()
+	defer server.Close()
+
+	_, err := getClient(t).ListUsers(t.Context(), scim.RequestParams{
+		Host:   server.URL,
+		Method: http.MethodGet,
+		Filter: scim.FilterLogicalGroupAnd{Expressions: []scim.FilterExpression{
+			scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "alice"},
+			scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "bob"},
+		}},
+	})
+	assert.ErrorIs(t, err, httpclient.ErrUnexpectedStatusCode)
+}
+
+func TestGetByID(t *testing.T) {
+	server := getServer()
+	defer server.Close()
+
+	client := getClient(t)
+
+	user, err := client.GetUser(t.Context(), "user2", scim.RequestParams{Host: server.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", user.UserName)
+
+	group, err := client.GetGroup(t.Context(), "group1", "", scim.RequestParams{Host: server.URL})
+	assert.NoError(t, err)
+	assert.Equal(t, "KeyAdmin", group.DisplayName)
+
+	_, err = client.GetUser(t.Context(), "unknown", scim.RequestParams{Host: server.URL})
+	assert.ErrorIs(t, err, scim.ErrGetUser)
+	assert.ErrorContains(t, err, "404")
+
+	_, err = client.GetGroup(t.Context(), "unknown", "", scim.RequestParams{Host: server.URL})
+	assert.ErrorIs(t, err, scim.ErrGetGroup)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestWrite(t *testing.T) {
+	server := getServer()
+	defer server.Close()
+
+	client := getClient(t)
+	params := scim.RequestParams{Host: server.URL}
+
+	created, err := client.CreateUser(t.Context(), scim.User{UserName: "carol"}, params)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+
+	created.DisplayName = "Carol"
+	replaced, err := client.ReplaceUser(t.Context(), created.ID, *created, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "Carol", replaced.DisplayName)
+
+	user, err := client.GetUser(t.Context(), created.ID, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "Carol", user.DisplayName)
+
+	err = client.DeleteGroup(t.Context(), "group1", params)
+	assert.NoError(t, err)
+
+	_, err = client.GetGroup(t.Context(), "group1", "", params)
+	assert.ErrorIs(t, err, httpclient.ErrUnexpectedStatusCode)
+
+	_, err = client.ReplaceGroup(t.Context(), "group1", scim.Group{DisplayName: "KeyAdmin"}, params)
+	assert.ErrorIs(t, err, httpclient.ErrUnexpectedStatusCode)
+}
+
+func TestListPagination(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	for _, id := range []string{"user1", "user2", "user3", "user4", "user5"} {
+		server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: id}, UserName: id})
+	}
+
+	client := getClient(t)
+	filter := scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorStartsWith, Value: "user"}
+
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		t.Run(method, func(t *testing.T) {
+			users, err := client.ListUsers(t.Context(), scim.RequestParams{
+				Host:   server.URL,
+				Method: method,
+				Filter: filter,
+				Count:  ptr.To(2),
+				Cursor: ptr.To("2"),
+			})
+			assert.NoError(t, err)
+			assert.Len(t, users.Resources, 2)
+			assert.Equal(t, "user3", users.Resources[0].ID)
+			assert.Equal(t, "4", users.NextCursor)
+
+			all, err := client.ListAllUsers(t.Context(), scim.RequestParams{
+				Host:   server.URL,
+				Method: method,
+				Filter: filter,
+				Count:  ptr.To(2),
+			})
+			assert.NoError(t, err)
+			assert.Len(t, all, 5)
+		})
+	}
+}
+
+func TestRequestsAndHooks(t *testing.T) {
+	server := getServer()
+	defer server.Close()
+
+	server.OnRequest(func(w http.ResponseWriter, r *http.Request) bool {
+		if r.URL.Path != "/Users/user2" {
+			return false
+		}
+
+		w.WriteHeader(http.StatusForbidden)
+
+		return true
+	})
+
+	client := getClient(t)
+
+	_, err := client.GetUser(t.Context(), "user1", scim.RequestParams{Host: server.URL})
+	assert.NoError(t, err)
+
+	_, err = client.GetUser(t.Context(), "user2", scim.RequestParams{Host: server.URL})
+	assert.ErrorContains(t, err, "403")
+
+	_, err = client.ListUsers(t.Context(), scim.RequestParams{
+		Host:   server.URL,
+		Method: http.MethodPost,
+		Filter: scim.FilterComparison{Attribute: "userName", Operator: scim.FilterOperatorEqual, Value: "bob"},
+	})
+	assert.NoError(t, err)
+
+	requests := server.Requests()
+	if assert.Len(t, requests, 3) {
+		assert.Equal(t, "/Users/user1", requests[0].Path)
+		assert.Equal(t, "/Users/user2", requests[1].Path)
+		assert.Equal(t, http.MethodPost, requests[2].Method)
+		assert.Contains(t, string(requests[2].Body), `userName eq \"bob\"`)
+	}
+}
@@ -0,0 +1,188 @@
+package scim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+var (
+	ErrCreateUser   = errors.New("error creating SCIM user")
+	ErrReplaceUser  = errors.New("error replacing SCIM user")
+	ErrDeleteUser   = errors.New("error deleting SCIM user")
+	ErrCreateGroup  = errors.New("error creating SCIM group")
+	ErrReplaceGroup = errors.New("error replacing SCIM group")
+	ErrDeleteGroup  = errors.New("error deleting SCIM group")
+)
+
+// CreateUser creates the user with a POST request, returning the user
+// as stored by the server. The core User schema is set if none is.
+func (c *Client) CreateUser(ctx context.Context, user User, params RequestParams) (*User, error) {
+	user.Schemas = defaultSchemas(user.Schemas, UserSchema)
+
+	created, err := writeResource[User](ctx, c, http.MethodPost, BasePathUsers, user, params, http.StatusCreated)
+	if err != nil {
+		c.audit(ctx, "CreateUser", ResourceTypeUser, "", err)
+		return nil, errs.Wrap(ErrCreateUser, err)
+	}
+
+	c.audit(ctx, "CreateUser", ResourceTypeUser, created.ID, nil)
+
+	return created, nil
+}
+
+// ReplaceUser replaces the user with the given ID with a PUT request,
+// returning the user as stored by the server. Set IfMatch in the params
+// to replace it only if unchanged since read.
+func (c *Client) ReplaceUser(ctx context.Context, id string, user User, params RequestParams) (*User, error) {
+	user.Schemas = defaultSchemas(user.Schemas, UserSchema)
+
+	replaced, err := writeResource[User](ctx, c, http.MethodPut, BasePathUsers+"/"+id, user, params, http.StatusOK)
+	c.audit(ctx, "ReplaceUser", ResourceTypeUser, id, err)
+
+	if err != nil {
+		return nil, errs.Wrap(ErrReplaceUser, err)
+	}
+
+	return replaced, nil
+}
+
+// DeleteUser deletes the user with the given ID.
+func (c *Client) DeleteUser(ctx context.Context, id string, params RequestParams) error {
+	err := c.deleteResource(ctx, BasePathUsers+"/"+id, params)
+	c.audit(ctx, "DeleteUser", ResourceTypeUser, id, err)
+
+	if err != nil {
+		return errs.Wrap(ErrDeleteUser, err)
+	}
+
+	return nil
+}
+
+// CreateGroup creates the group with a POST request, returning the group
+// as stored by the server. The core Group schema is set if none is.
+func (c *Client) CreateGroup(ctx context.Context, group Group, params RequestParams) (*Group, error) {
+	group.Schemas = defaultSchemas(group.Schemas, GroupSchema)
+
+	created, err := writeResource[Group](ctx, c, http.MethodPost, BasePathGroups, group, params, http.StatusCreated)
+	if err != nil {
+		c.audit(ctx, "CreateGroup", ResourceTypeGroup, "", err)
+		return nil, errs.Wrap(ErrCreateGroup, err)
+	}
+
+	c.audit(ctx, "CreateGroup", ResourceTypeGroup, created.ID, nil)
+
+	return created, nil
+}
+
+// ReplaceGroup replaces the group with the given ID with a PUT request,
+// returning the group as stored by the server. Set IfMatch in the params
+// to replace it only if unchanged since read.
+func (c *Client) ReplaceGroup(ctx context.Context, id string, group Group, params RequestParams) (*Group, error) {
+	group.Schemas = defaultSchemas(group.Schemas, GroupSchema)
+
+	replaced, err := writeResource[Group](ctx, c, http.MethodPut, BasePathGroups+"/"+id, group, params, http.StatusOK)
+	c.audit(ctx, "ReplaceGroup", ResourceTypeGroup, id, err)
+
+	if err != nil {
+		return nil, errs.Wrap(ErrReplaceGroup, err)
+	}
+
+	return replaced, nil
+}
+
+// DeleteGroup deletes the group with the given ID.
+func (c *Client) DeleteGroup(ctx context.Context, id string, params RequestParams) error {
+	err := c.deleteResource(ctx, BasePathGroups+"/"+id, params)
+	c.audit(ctx, "DeleteGroup", ResourceTypeGroup, id, err)
+
+	if err != nil {
+		return errs.Wrap(ErrDeleteGroup, err)
+	}
+
+	return nil
+}
+
+// writeResource sends the resource with the method to the resource path
+// and decodes the resource returned with the expected status.
+func writeResource[T User | Group](
+	ctx context.Context,
+	c *Client,
+	method string,
+	resourcePath string,
+	resource any,
+	params RequestParams,
+	expectedStatus int,
+) (*T, error) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	err = c.checkBodySize(body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := conditionalHeaders(params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.baseCreateAndExecuteHTTPRequest(ctx, params.Host, method, resourcePath, nil, body, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	defer c.closeBody(resp, method+" "+resourcePath)
+
+	err = c.normalizeResponseCase(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	written, err := httpclient.DecodeResponse[T](ctx, "SCIM", resp, expectedStatus, c.decodeOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	resolveID(written)
+
+	return written, nil
+}
+
+// deleteResource deletes the resource at the path, expecting no content.
+func (c *Client) deleteResource(ctx context.Context, resourcePath string, params RequestParams) error {
+	headers, err := conditionalHeaders(params)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.baseCreateAndExecuteHTTPRequest(ctx, params.Host, http.MethodDelete, resourcePath, nil, nil, headers)
+	if err != nil {
+		return err
+	}
+
+	defer c.closeBody(resp, "DELETE "+resourcePath)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid response from SCIM: %w",
+			&httpclient.StatusCodeError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	return nil
+}
+
+// defaultSchemas returns the schemas, or the core schema if there are none.
+func defaultSchemas(schemas []string, coreSchema string) []string {
+	if len(schemas) > 0 {
+		return schemas
+	}
+
+	return []string{coreSchema}
+}
@@ -0,0 +1,153 @@
+package scim_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/httpclient"
+)
+
+func TestWriteResources(t *testing.T) {
+	tests := []struct {
+		name            string
+		write           func(client *scim.Client, params scim.RequestParams) (string, error)
+		ifMatch         string
+		expectedMethod  string
+		expectedPath    string
+		expectedSchema  string
+		expectedIfMatch string
+		responseStatus  int
+		responseBody    string
+		expectedID      string
+		expectedError   error
+	}{
+		{
+			name: "Create user",
+			write: func(client *scim.Client, params scim.RequestParams) (string, error) {
+				user, err := client.CreateUser(t.Context(), scim.User{UserName: "alice"}, params)
+				if err != nil {
+					return "", err
+				}
+
+				return user.ID, nil
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/Users",
+			expectedSchema: scim.UserSchema,
+			responseStatus: http.StatusCreated,
+			responseBody:   `{"id":"user1","userName":"alice"}`,
+			expectedID:     "user1",
+		},
+		{
+			name: "Replace user",
+			write: func(client *scim.Client, params scim.RequestParams) (string, error) {
+				user, err := client.ReplaceUser(t.Context(), "user1", scim.User{UserName: "alice"}, params)
+				if err != nil {
+					return "", err
+				}
+
+				return user.ID, nil
+			},
+			ifMatch:         `W/"1"`,
+			expectedMethod:  http.MethodPut,
+			expectedPath:    "/Users/user1",
+			expectedSchema:  scim.UserSchema,
+			expectedIfMatch: `W/"1"`,
+			responseStatus:  http.StatusOK,
+			responseBody:    `{"id":"user1","userName":"alice"}`,
+			expectedID:      "user1",
+		},
+		{
+			name: "Delete user",
+			write: func(client *scim.Client, params scim.RequestParams) (string, error) {
+				return "", client.DeleteUser(t.Context(), "user1", params)
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/Users/user1",
+			responseStatus: http.StatusNoContent,
+		},
+		{
+			name: "Create group",
+			write: func(client *scim.Client, params scim.RequestParams) (string, error) {
+				group, err := client.CreateGroup(t.Context(), scim.Group{DisplayName: "KeyAdmin"}, params)
+				if err != nil {
+					return "", err
+				}
+
+				return group.ID, nil
+			},
+			expectedMethod: http.MethodPost,
+			expectedPath:   "/Groups",
+			expectedSchema: scim.GroupSchema,
+			responseStatus: http.StatusCreated,
+			responseBody:   `{"id":"group1","displayName":"KeyAdmin"}`,
+			expectedID:     "group1",
+		},
+		{
+			name: "Replace group conflict",
+			write: func(client *scim.Client, params scim.RequestParams) (string, error) {
+				_, err := client.ReplaceGroup(t.Context(), "group1", scim.Group{DisplayName: "KeyAdmin"}, params)
+				return "", err
+			},
+			ifMatch:         `"1"`,
+			expectedMethod:  http.MethodPut,
+			expectedPath:    "/Groups/group1",
+			expectedSchema:  scim.GroupSchema,
+			expectedIfMatch: `"1"`,
+			responseStatus:  http.StatusPreconditionFailed,
+			expectedError:   scim.ErrReplaceGroup,
+		},
+		{
+			name: "Delete missing group",
+			write: func(client *scim.Client, params scim.RequestParams) (string, error) {
+				return "", client.DeleteGroup(t.Context(), "group1", params)
+			},
+			expectedMethod: http.MethodDelete,
+			expectedPath:   "/Groups/group1",
+			responseStatus: http.StatusNotFound,
+			expectedError:  httpclient.ErrUnexpectedStatusCode,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.expectedMethod, r.Method)
+				assert.Equal(t, tt.expectedPath, r.URL.Path)
+				assert.Equal(t, tt.expectedIfMatch, r.Header.Get(scim.HeaderIfMatch))
+
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+
+				if tt.expectedSchema != "" {
+					var resource scim.BaseResource
+
+					assert.NoError(t, json.Unmarshal(body, &resource))
+					assert.Equal(t, []string{tt.expectedSchema}, resource.Schemas)
+				} else {
+					assert.Empty(t, body)
+				}
+
+				w.WriteHeader(tt.responseStatus)
+				_, err = w.Write([]byte(tt.responseBody))
+				assert.NoError(t, err)
+			}))
+			defer server.Close()
+
+			id, err := tt.write(getBasicClient(), scim.RequestParams{Host: server.URL, IfMatch: tt.ifMatch})
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedID, id)
+		})
+	}
+}
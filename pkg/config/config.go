@@ -8,6 +8,39 @@ type Params struct {
 	GroupMembersAttribute   commoncfg.SourceRef `yaml:"groupMembersAttribute"`
 	ListMethod              commoncfg.SourceRef `yaml:"listMethod"`
 	AllowSearchUsersByGroup commoncfg.SourceRef `yaml:"allowSearchUsersByGroup"`
+	MaxRetries              commoncfg.SourceRef `yaml:"maxRetries"`
+	RetryBudget             commoncfg.SourceRef `yaml:"retryBudget"`
+	RequestsPerSecond       commoncfg.SourceRef `yaml:"requestsPerSecond"`
+	RequestBurst            commoncfg.SourceRef `yaml:"requestBurst"`
+	MaxConcurrentRequests   commoncfg.SourceRef `yaml:"maxConcurrentRequests"`
+	CircuitBreakerThreshold commoncfg.SourceRef `yaml:"circuitBreakerThreshold"`
+	CircuitBreakerCooldown  commoncfg.SourceRef `yaml:"circuitBreakerCooldown"`
+	MaxQueryLength          commoncfg.SourceRef `yaml:"maxQueryLength"`
+	PaginationParams        commoncfg.SourceRef `yaml:"paginationParams"`
+	MaxRequestBodySize      commoncfg.SourceRef `yaml:"maxRequestBodySize"`
+	MinimalFilterEncoding   commoncfg.SourceRef `yaml:"minimalFilterEncoding"`
+	EnableHTTP2             commoncfg.SourceRef `yaml:"enableHTTP2"`
+	RedirectPolicy          commoncfg.SourceRef `yaml:"redirectPolicy"`
+	DefaultHeaders          commoncfg.SourceRef `yaml:"defaultHeaders"`
+	ForwardedMetadata       commoncfg.SourceRef `yaml:"forwardedMetadata"`
+	VerifyGroupExists       commoncfg.SourceRef `yaml:"verifyGroupExists"`
+	EmptyFilterPolicies     commoncfg.SourceRef `yaml:"emptyFilterPolicies"`
+	RequireAuthContextHost  commoncfg.SourceRef `yaml:"requireAuthContextHost"`
+	NotFoundAsEmpty         commoncfg.SourceRef `yaml:"notFoundAsEmpty"`
+	ListResourcesKey        commoncfg.SourceRef `yaml:"listResourcesKey"`
+	BatchConcurrency        commoncfg.SourceRef `yaml:"batchConcurrency"`
+	ExactGroupNameMatch     commoncfg.SourceRef `yaml:"exactGroupNameMatch"`
+	MultipleMatchPolicy     commoncfg.SourceRef `yaml:"multipleMatchPolicy"`
+	ResolveGroupName        commoncfg.SourceRef `yaml:"resolveGroupName"`
+	MemberIDsOnly           commoncfg.SourceRef `yaml:"memberIDsOnly"`
+	UseMemberDisplay        commoncfg.SourceRef `yaml:"useMemberDisplay"`
+	MaxGroupMembers         commoncfg.SourceRef `yaml:"maxGroupMembers"`
+	TruncateLargeGroups     commoncfg.SourceRef `yaml:"truncateLargeGroups"`
+	MemberTimeout           commoncfg.SourceRef `yaml:"memberTimeout"`
+	SkipTimedOutMembers     commoncfg.SourceRef `yaml:"skipTimedOutMembers"`
+	PartialMemberResults    commoncfg.SourceRef `yaml:"partialMemberResults"`
+	ETagCacheTTL            commoncfg.SourceRef `yaml:"etagCacheTTL"`
+	CacheTTLJitterPercent   commoncfg.SourceRef `yaml:"cacheTTLJitterPercent"`
 }
 
 type Config struct {
@@ -15,10 +48,32 @@ type Config struct {
 	Auth        commoncfg.SecretRef `yaml:"auth"`
 	AuthContext commoncfg.SourceRef `yaml:"authContext"`
 	Params      Params              `yaml:"params"`
+
+	// ParamsRef supplies the params as a single YAML or JSON document
+	// of plain values; params set individually take precedence
+	ParamsRef commoncfg.SourceRef `yaml:"paramsRef"`
 }
 
 type AuthContextConfig struct {
-	HostField    string            `yaml:"hostField"`
-	HeaderFields map[string]string `yaml:"headerFields"`
-	BasePath     string            `yaml:"basePath"`
+	HostField string `yaml:"hostField"`
+	// HostFields are further candidate fields of the host,
+	// tried in order after HostField
+	HostFields []string `yaml:"hostFields"`
+
+	HeaderFields map[string]HeaderTemplate `yaml:"headerFields"`
+	BasePath     string                    `yaml:"basePath"`
+
+	// RequiredHeaderFields names headers of HeaderFields whose auth
+	// context fields must be present, failing requests otherwise
+	RequiredHeaderFields []string `yaml:"requiredHeaderFields"`
+}
+
+// HostFieldCandidates returns the auth context fields the host
+// may be provided in, in the order they are tried.
+func (c AuthContextConfig) HostFieldCandidates() []string {
+	if c.HostField == "" {
+		return c.HostFields
+	}
+
+	return append([]string{c.HostField}, c.HostFields...)
 }
@@ -0,0 +1,36 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+)
+
+func TestHostFieldCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.AuthContextConfig
+		expected []string
+	}{
+		{name: "None", expected: nil},
+		{name: "Host field", config: config.AuthContextConfig{HostField: "host"}, expected: []string{"host"}},
+		{
+			name:     "Host fields",
+			config:   config.AuthContextConfig{HostFields: []string{"host", "url"}},
+			expected: []string{"host", "url"},
+		},
+		{
+			name:     "Host field before host fields",
+			config:   config.AuthContextConfig{HostField: "host", HostFields: []string{"url"}},
+			expected: []string{"host", "url"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.config.HostFieldCandidates())
+		})
+	}
+}
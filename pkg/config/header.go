@@ -0,0 +1,67 @@
+package config
+
+import (
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// HeaderTemplate renders a request header value from auth-context data.
+// A plain field name copies that field, while a template such as
+// "Bearer {{.accessToken}}" is executed against the auth-context data.
+type HeaderTemplate struct {
+	source string
+	tmpl   *template.Template
+}
+
+// ParseHeaderTemplate parses a header mapping value, which is treated
+// as a template if it contains an action.
+func ParseHeaderTemplate(source string) (HeaderTemplate, error) {
+	if !strings.Contains(source, "{{") {
+		return HeaderTemplate{source: source}, nil
+	}
+
+	tmpl, err := template.New("header").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return HeaderTemplate{}, err
+	}
+
+	return HeaderTemplate{source: source, tmpl: tmpl}, nil
+}
+
+func (h *HeaderTemplate) UnmarshalYAML(value *yaml.Node) error {
+	var source string
+
+	err := value.Decode(&source)
+	if err != nil {
+		return err
+	}
+
+	*h, err = ParseHeaderTemplate(source)
+
+	return err
+}
+
+// String returns the field name or template the header was parsed from.
+func (h HeaderTemplate) String() string {
+	return h.source
+}
+
+// Render returns the header value, or false if a referenced
+// auth-context field is missing.
+func (h HeaderTemplate) Render(data map[string]string) (string, bool) {
+	if h.tmpl == nil {
+		value, ok := data[h.source]
+		return value, ok
+	}
+
+	var sb strings.Builder
+
+	err := h.tmpl.Execute(&sb, data)
+	if err != nil {
+		return "", false
+	}
+
+	return sb.String(), true
+}
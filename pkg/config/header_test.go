@@ -0,0 +1,76 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+)
+
+func TestHeaderTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		source        string
+		data          map[string]string
+		expectedValue string
+		expectedOK    bool
+	}{
+		{
+			name:          "Field name",
+			source:        "tenantId",
+			data:          map[string]string{"tenantId": "tenant1"},
+			expectedValue: "tenant1",
+			expectedOK:    true,
+		},
+		{
+			name:   "Missing field name",
+			source: "tenantId",
+			data:   map[string]string{},
+		},
+		{
+			name:          "Bearer template",
+			source:        "Bearer {{.accessToken}}",
+			data:          map[string]string{"accessToken": "abc123"},
+			expectedValue: "Bearer abc123",
+			expectedOK:    true,
+		},
+		{
+			name:          "Template combining fields",
+			source:        "{{.tenantId}}:{{.zoneId}}",
+			data:          map[string]string{"tenantId": "tenant1", "zoneId": "zone1"},
+			expectedValue: "tenant1:zone1",
+			expectedOK:    true,
+		},
+		{
+			name:   "Template with missing field",
+			source: "Bearer {{.accessToken}}",
+			data:   map[string]string{"tenantId": "tenant1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var header config.HeaderTemplate
+
+			err := yaml.Unmarshal([]byte(`"`+tt.source+`"`), &header)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.source, header.String())
+
+			value, ok := header.Render(tt.data)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedValue, value)
+		})
+	}
+}
+
+func TestHeaderTemplateInvalid(t *testing.T) {
+	_, err := config.ParseHeaderTemplate("Bearer {{.accessToken")
+	assert.Error(t, err)
+
+	var cfg config.AuthContextConfig
+
+	err = yaml.Unmarshal([]byte(`headerFields: {Authorization: "Bearer {{.accessToken"}`), &cfg)
+	assert.Error(t, err)
+}
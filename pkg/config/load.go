@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+var (
+	ErrParseConfig            = errors.New("failed to parse configuration")
+	ErrMissingField           = errors.New("required configuration field missing")
+	ErrInvalidField           = errors.New("invalid configuration field")
+	ErrLoadAuthContext        = errors.New("failed to load auth context")
+	ErrUnmappedRequiredHeader = errors.New("required header not in header fields")
+)
+
+// Load parses and validates the YAML configuration, resolving params
+// supplied as a single document, and resolves and validates its auth
+// context and params, returning the params resolved.
+func Load(yamlConfig []byte) (*Config, *ResolvedParams, error) {
+	cfg := &Config{}
+
+	err := yaml.Unmarshal(yamlConfig, cfg)
+	if err != nil {
+		return nil, nil, errs.Wrap(ErrParseConfig, err)
+	}
+
+	if cfg.ParamsRef.Source != "" {
+		params, err := loadParamsRef(cfg.ParamsRef)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		cfg.Params = cfg.Params.withDefaults(params)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authContextBytes, err := commoncfg.LoadValueFromSourceRef(cfg.AuthContext)
+	if err != nil {
+		return nil, nil, errs.Wrap(ErrLoadAuthContext, err)
+	}
+
+	authContext := &AuthContextConfig{}
+
+	err = yaml.Unmarshal(authContextBytes, authContext)
+	if err != nil {
+		return nil, nil, errs.Wrap(ErrLoadAuthContext, err)
+	}
+
+	err = authContext.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params, err := cfg.resolveParams(*authContext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, params, nil
+}
+
+// Validate checks that the required fields are set, their values
+// being checked as the params are resolved.
+func (c Config) Validate() error {
+	if c.Auth.Type == "" {
+		return errs.Wrapf(ErrMissingField, "auth.type")
+	}
+
+	required := []struct {
+		name string
+		ref  commoncfg.SourceRef
+	}{
+		{name: "host", ref: c.Host},
+		{name: "authContext", ref: c.AuthContext},
+		{name: "params.groupAttribute", ref: c.Params.GroupAttribute},
+		{name: "params.userAttribute", ref: c.Params.UserAttribute},
+		{name: "params.groupMembersAttribute", ref: c.Params.GroupMembersAttribute},
+		{name: "params.allowSearchUsersByGroup", ref: c.Params.AllowSearchUsersByGroup},
+	}
+
+	for _, field := range required {
+		if field.ref.Source == "" {
+			return errs.Wrapf(ErrMissingField, field.name)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the required header fields are mapped.
+func (c AuthContextConfig) Validate() error {
+	for _, key := range c.RequiredHeaderFields {
+		if _, ok := c.HeaderFields[key]; !ok {
+			return errs.Wrapf(ErrUnmappedRequiredHeader, key)
+		}
+	}
+
+	return nil
+}
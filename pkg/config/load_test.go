@@ -0,0 +1,222 @@
+package config_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+)
+
+const completeConfig = `
+host:
+  source: embedded
+  value: https://scim.example.com
+auth:
+  type: basic
+  basic:
+    username:
+      source: embedded
+      value: user
+    password:
+      source: embedded
+      value: secret
+authContext:
+  source: embedded
+  value: |
+    hostField: host
+    headerFields:
+      X-Tenant: tenant
+    requiredHeaderFields: [X-Tenant]
+params:
+  groupAttribute:
+    source: embedded
+    value: displayName
+  userAttribute:
+    source: embedded
+    value: userName
+  groupMembersAttribute:
+    source: embedded
+    value: members
+  allowSearchUsersByGroup:
+    source: embedded
+    value: "true"
+`
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		expectedError error
+		errorContains string
+	}{
+		{
+			name:   "Complete config",
+			config: completeConfig,
+		},
+		{
+			name:          "Invalid YAML",
+			config:        "host: [",
+			expectedError: config.ErrParseConfig,
+		},
+		{
+			name: "Missing required field",
+			config: strings.Replace(completeConfig, `  userAttribute:
+    source: embedded
+    value: userName
+`, "", 1),
+			expectedError: config.ErrMissingField,
+			errorContains: "params.userAttribute",
+		},
+		{
+			name:          "Missing auth type",
+			config:        strings.Replace(completeConfig, "type: basic", "type: \"\"", 1),
+			expectedError: config.ErrMissingField,
+			errorContains: "auth.type",
+		},
+		{
+			name:          "Bad bool",
+			config:        strings.Replace(completeConfig, `value: "true"`, `value: "sometimes"`, 1),
+			expectedError: config.ErrInvalidField,
+			errorContains: "params.allowSearchUsersByGroup",
+		},
+		{
+			name: "Bad int",
+			config: completeConfig + `  maxRetries:
+    source: embedded
+    value: many
+`,
+			expectedError: config.ErrInvalidField,
+			errorContains: "params.maxRetries",
+		},
+		{
+			name: "Bad policy",
+			config: completeConfig + `  multipleMatchPolicy:
+    source: embedded
+    value: last
+`,
+			expectedError: config.ErrInvalidMultipleMatchPolicy,
+			errorContains: "params.multipleMatchPolicy",
+		},
+		{
+			name:          "Invalid auth context",
+			config:        strings.Replace(completeConfig, "hostField: host", "hostField: [", 1),
+			expectedError: config.ErrLoadAuthContext,
+		},
+		{
+			name:          "Unmapped required header",
+			config:        strings.Replace(completeConfig, "X-Tenant: tenant", "X-Other: tenant", 1),
+			expectedError: config.ErrUnmappedRequiredHeader,
+			errorContains: "X-Tenant",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, params, err := config.Load([]byte(tt.config))
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Nil(t, cfg)
+				assert.Nil(t, params)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, commoncfg.BasicSecretType, cfg.Auth.Type)
+			assert.Equal(t, "https://scim.example.com", params.BaseHost)
+			assert.Equal(t, "host", params.AuthContext.HostField)
+			assert.Equal(t, []string{"X-Tenant"}, params.AuthContext.RequiredHeaderFields)
+			assert.True(t, params.AllowSearchUsersByGroup)
+
+			// Params not set take their defaults
+			assert.Equal(t, config.DefaultListMethod, params.ListMethod)
+			assert.Equal(t, config.DefaultRequestBurst, params.RequestBurst)
+			assert.Equal(t, config.DefaultCircuitBreakerCooldown, params.CircuitBreakerCooldown)
+			assert.Equal(t, config.DefaultBatchConcurrency, params.BatchConcurrency)
+			assert.Equal(t, config.MultipleMatchError, params.MultipleMatchPolicy)
+			assert.True(t, params.EnableHTTP2)
+		})
+	}
+}
+
+const paramsRefConfig = `
+host:
+  source: embedded
+  value: https://scim.example.com
+auth:
+  type: basic
+authContext:
+  source: embedded
+  value: "{}"
+params:
+  userAttribute:
+    source: embedded
+    value: emails.value
+paramsRef:
+  source: embedded
+  value: |
+    groupAttribute: displayName
+    userAttribute: userName
+    groupMembersAttribute: members
+    allowSearchUsersByGroup: %s
+    maxRetries: 3
+    paginationParams:
+      count: limit
+`
+
+func TestLoadParamsRef(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        string
+		expectedError error
+	}{
+		{
+			name:   "Params from single document",
+			config: fmt.Sprintf(paramsRefConfig, "true"),
+		},
+		{
+			name:          "Invalid value",
+			config:        fmt.Sprintf(paramsRefConfig, "sometimes"),
+			expectedError: config.ErrInvalidField,
+		},
+		{
+			name:          "Unknown param",
+			config:        fmt.Sprintf(paramsRefConfig, "true\n    unknownParam: 1"),
+			expectedError: config.ErrLoadParams,
+		},
+		{
+			name:          "Not a map",
+			config:        strings.Replace(fmt.Sprintf(paramsRefConfig, "true"), "groupAttribute: displayName", "- groupAttribute", 1),
+			expectedError: config.ErrLoadParams,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, params, err := config.Load([]byte(tt.config))
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "displayName", cfg.Params.GroupAttribute.Value)
+			assert.Equal(t, "members", cfg.Params.GroupMembersAttribute.Value)
+			assert.Equal(t, "3", cfg.Params.MaxRetries.Value)
+			assert.Equal(t, "count: limit\n", cfg.Params.PaginationParams.Value)
+
+			// Params set individually take precedence
+			assert.Equal(t, "emails.value", cfg.Params.UserAttribute.Value)
+
+			assert.True(t, params.AllowSearchUsersByGroup)
+			assert.Equal(t, 3, params.MaxRetries)
+			assert.Equal(t, "limit", params.PaginationParams.Count)
+			assert.Equal(t, "emails.value", params.UserAttribute)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+var ErrLoadParams = errors.New("failed to load params")
+
+// loadParamsRef loads params supplied as a single YAML or JSON document
+// of plain values, e.g. {groupAttribute: displayName, maxRetries: 3},
+// as params with an embedded source reference per field.
+func loadParamsRef(ref commoncfg.SourceRef) (Params, error) {
+	value, err := commoncfg.LoadValueFromSourceRef(ref)
+	if err != nil {
+		return Params{}, errs.Wrap(ErrLoadParams, err)
+	}
+
+	var fields map[string]yaml.Node
+
+	err = yaml.Unmarshal(value, &fields)
+	if err != nil {
+		return Params{}, errs.Wrap(ErrLoadParams, err)
+	}
+
+	refs := make(map[string]commoncfg.SourceRef, len(fields))
+
+	for name, node := range fields {
+		fieldValue := node.Value
+
+		// Maps such as the pagination params are themselves loaded as YAML
+		if node.Kind != yaml.ScalarNode {
+			fieldBytes, err := yaml.Marshal(&node)
+			if err != nil {
+				return Params{}, errs.Wrap(ErrLoadParams, err)
+			}
+
+			fieldValue = string(fieldBytes)
+		}
+
+		refs[name] = commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: fieldValue}
+	}
+
+	refsBytes, err := yaml.Marshal(refs)
+	if err != nil {
+		return Params{}, errs.Wrap(ErrLoadParams, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(refsBytes))
+	decoder.KnownFields(true)
+
+	var params Params
+
+	err = decoder.Decode(&params)
+	if err != nil {
+		return Params{}, errs.Wrap(ErrLoadParams, err)
+	}
+
+	return params, nil
+}
+
+// withDefaults returns the params with the fields not set taken from defaults.
+func (p Params) withDefaults(defaults Params) Params {
+	fields := reflect.ValueOf(&p).Elem()
+	defaultFields := reflect.ValueOf(defaults)
+
+	for i := range fields.NumField() {
+		if fields.Field(i).IsZero() {
+			fields.Field(i).Set(defaultFields.Field(i))
+		}
+	}
+
+	return p
+}
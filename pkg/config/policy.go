@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+// EmptyFilterPolicy decides how a lookup RPC handles a request
+// without a value to filter by.
+type EmptyFilterPolicy string
+
+const (
+	// EmptyFilterReject fails the request.
+	EmptyFilterReject EmptyFilterPolicy = "reject"
+	// EmptyFilterFetchAll lists all resources, as GetAllGroups does.
+	EmptyFilterFetchAll EmptyFilterPolicy = "fetchAll"
+)
+
+// MultipleMatchPolicy decides which group GetGroup returns
+// if several groups match the requested name.
+type MultipleMatchPolicy string
+
+const (
+	// MultipleMatchError fails the request.
+	MultipleMatchError MultipleMatchPolicy = "error"
+	// MultipleMatchFirst returns the first group listed by the server.
+	MultipleMatchFirst MultipleMatchPolicy = "first"
+	// MultipleMatchExactCase returns the only group whose name matches the
+	// requested one including case, failing if there is no such single group.
+	MultipleMatchExactCase MultipleMatchPolicy = "exactCase"
+)
+
+var (
+	ErrInvalidEmptyFilterPolicy   = errors.New("invalid empty filter policy")
+	ErrInvalidMultipleMatchPolicy = errors.New("invalid multiple match policy")
+)
+
+// EmptyFilterPolicyMethods are the RPCs whose empty filter policy can be configured.
+var EmptyFilterPolicyMethods = []string{"GetGroup", "GetGroupsForUser", "GetUsersForGroup"}
+
+// parseEmptyFilterPolicies parses the policies per RPC name from a YAML
+// map, e.g. {GetUsersForGroup: fetchAll}.
+func parseEmptyFilterPolicies(value string) (map[string]EmptyFilterPolicy, error) {
+	policies := make(map[string]EmptyFilterPolicy)
+
+	err := yaml.Unmarshal([]byte(value), &policies)
+	if err != nil {
+		return nil, err
+	}
+
+	for method, policy := range policies {
+		if !slices.Contains(EmptyFilterPolicyMethods, method) {
+			return nil, errs.Wrapf(ErrInvalidEmptyFilterPolicy, "unknown method "+method)
+		}
+
+		if policy != EmptyFilterReject && policy != EmptyFilterFetchAll {
+			return nil, errs.Wrapf(ErrInvalidEmptyFilterPolicy, string(policy))
+		}
+	}
+
+	return policies, nil
+}
+
+// parseMultipleMatchPolicy parses a multiple match policy.
+func parseMultipleMatchPolicy(value string) (MultipleMatchPolicy, error) {
+	policy := MultipleMatchPolicy(value)
+
+	switch policy {
+	case MultipleMatchError, MultipleMatchFirst, MultipleMatchExactCase:
+		return policy, nil
+	default:
+		return "", errs.Wrapf(ErrInvalidMultipleMatchPolicy, value)
+	}
+}
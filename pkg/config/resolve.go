@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"gopkg.in/yaml.v3"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+const (
+	DefaultListMethod             = scim.ListMethodPost
+	DefaultRequestBurst           = 1
+	DefaultCircuitBreakerCooldown = 30 * time.Second
+	DefaultBatchConcurrency       = 8
+)
+
+// ResolvedParams are the params loaded from their source references,
+// parsed and with defaults applied for those not set.
+type ResolvedParams struct {
+	BaseHost                string // Fallback host if not provided in auth context
+	GroupAttribute          string // Comma-separated candidate attributes tried in order
+	UserAttribute           string // Comma-separated candidate attributes tried in order
+	GroupMembersAttribute   string
+	ListMethod              scim.ListMethod
+	AllowSearchUsersByGroup bool
+	AuthContext             AuthContextConfig
+
+	ClientParams
+
+	RetryBudget            int                          // Total retries shared across one RPC fan-out, unbounded if zero
+	VerifyGroupExists      bool                         // Check the group exists before listing its users by group attribute
+	EmptyFilterPolicies    map[string]EmptyFilterPolicy // Per RPC name, rejecting empty filters if unset
+	RequireAuthContextHost bool                         // Fail requests without an auth context host instead of using BaseHost
+	BatchConcurrency       int                          // Lookups in flight in batch methods, defaulting if not positive
+	ExactGroupNameMatch    bool                         // Drop groups whose name differs in case from the requested one
+	MultipleMatchPolicy    MultipleMatchPolicy          // Group GetGroup returns if several match the name
+	ResolveGroupName       bool                         // Take the GetUsersForGroup group ID as a name, resolved like GetGroup
+	MemberIDsOnly          bool                         // Return group members with only their ID, without resolving each user
+	UseMemberDisplay       bool                         // Name members with a display from it, without resolving the user and its email
+	MaxGroupMembers        int                          // Members resolved one by one above which a group is rejected, unlimited if zero
+	TruncateLargeGroups    bool                         // Resolve only the first MaxGroupMembers members instead of rejecting
+	MemberTimeout          time.Duration                // Deadline of each member GetUser within the RPC one, disabled if zero
+	SkipTimedOutMembers    bool                         // Skip members whose GetUser times out instead of failing the RPC
+	PartialMemberResults   bool                         // Return the members resolved despite member GetUser errors, logging the failures
+}
+
+// ClientParams are the params the SCIM client is built from, the
+// client being kept on reconfiguration while they are unchanged.
+type ClientParams struct {
+	MaxRetries              int // Retries per SCIM request, disabled if zero
+	RequestsPerSecond       int // Client-side rate limit of SCIM requests, disabled if zero
+	RequestBurst            int
+	MaxConcurrentRequests   int // SCIM requests in flight across all RPCs, unbounded if zero
+	CircuitBreakerThreshold int // Consecutive failures tripping the circuit breaker, disabled if zero
+	CircuitBreakerCooldown  time.Duration
+	MaxQueryLength          int                   // Query length above which GET lists switch to POST, disabled if zero
+	PaginationParams        scim.PaginationParams // Pagination parameter names, SCIM ones unless overridden
+	MaxRequestBodySize      int                   // Bytes above which write requests are rejected, unlimited if zero
+	MinimalFilterEncoding   bool                  // Keep quotes literal in GET filters for servers rejecting encoded ones
+	EnableHTTP2             bool
+	DefaultHeaders          map[string]string   `describe:"redact"` // Static headers sent with every request, beneath auth context ones
+	ForwardedMetadata       []string            // Incoming gRPC metadata keys sent as headers, beneath auth context ones
+	RedirectPolicy          scim.RedirectPolicy // Redirects followed, none by default
+	NotFoundAsEmpty         bool                // Treat 404 list responses as empty for servers answering no matches that way
+	ListResourcesKey        string              // List response attribute holding the resources if not Resources
+	ETagCacheTTL            time.Duration       // Caches GET responses for ETag revalidation, disabled if zero
+	CacheTTLJitterPercent   int                 // Random ± spread of cache entry expiry
+}
+
+// resolveParams loads and parses the params of the validated
+// configuration, returning the error of the first param failing.
+func (c Config) resolveParams(authContext AuthContextConfig) (*ResolvedParams, error) {
+	r := &resolver{}
+	p := c.Params
+
+	params := &ResolvedParams{
+		BaseHost:                resolve(r, "host", c.Host, "", parseString),
+		GroupAttribute:          resolve(r, "params.groupAttribute", p.GroupAttribute, "", parseString),
+		UserAttribute:           resolve(r, "params.userAttribute", p.UserAttribute, "", parseString),
+		GroupMembersAttribute:   resolve(r, "params.groupMembersAttribute", p.GroupMembersAttribute, "", parseString),
+		ListMethod:              resolve(r, "params.listMethod", p.ListMethod, DefaultListMethod, parseListMethod),
+		AllowSearchUsersByGroup: resolve(r, "params.allowSearchUsersByGroup", p.AllowSearchUsersByGroup, false, strconv.ParseBool),
+		AuthContext:             authContext,
+		ClientParams: ClientParams{
+			MaxRetries:              resolve(r, "params.maxRetries", p.MaxRetries, 0, strconv.Atoi),
+			RequestsPerSecond:       resolve(r, "params.requestsPerSecond", p.RequestsPerSecond, 0, strconv.Atoi),
+			RequestBurst:            resolve(r, "params.requestBurst", p.RequestBurst, DefaultRequestBurst, strconv.Atoi),
+			MaxConcurrentRequests:   resolve(r, "params.maxConcurrentRequests", p.MaxConcurrentRequests, 0, strconv.Atoi),
+			CircuitBreakerThreshold: resolve(r, "params.circuitBreakerThreshold", p.CircuitBreakerThreshold, 0, strconv.Atoi),
+			CircuitBreakerCooldown:  resolve(r, "params.circuitBreakerCooldown", p.CircuitBreakerCooldown, DefaultCircuitBreakerCooldown, time.ParseDuration),
+			MaxQueryLength:          resolve(r, "params.maxQueryLength", p.MaxQueryLength, 0, strconv.Atoi),
+			PaginationParams:        resolve(r, "params.paginationParams", p.PaginationParams, scim.DefaultPaginationParams, parsePaginationParams),
+			MaxRequestBodySize:      resolve(r, "params.maxRequestBodySize", p.MaxRequestBodySize, 0, strconv.Atoi),
+			MinimalFilterEncoding:   resolve(r, "params.minimalFilterEncoding", p.MinimalFilterEncoding, false, strconv.ParseBool),
+			EnableHTTP2:             resolve(r, "params.enableHTTP2", p.EnableHTTP2, true, strconv.ParseBool),
+			DefaultHeaders:          resolve(r, "params.defaultHeaders", p.DefaultHeaders, nil, parseYAML[map[string]string]),
+			ForwardedMetadata:       resolve(r, "params.forwardedMetadata", p.ForwardedMetadata, nil, parseList),
+			RedirectPolicy:          resolve(r, "params.redirectPolicy", p.RedirectPolicy, scim.RedirectNone, scim.ParseRedirectPolicy),
+			NotFoundAsEmpty:         resolve(r, "params.notFoundAsEmpty", p.NotFoundAsEmpty, false, strconv.ParseBool),
+			ListResourcesKey:        resolve(r, "params.listResourcesKey", p.ListResourcesKey, "", parseString),
+			ETagCacheTTL:            resolve(r, "params.etagCacheTTL", p.ETagCacheTTL, 0, time.ParseDuration),
+			CacheTTLJitterPercent:   resolve(r, "params.cacheTTLJitterPercent", p.CacheTTLJitterPercent, 0, strconv.Atoi),
+		},
+		RetryBudget:            resolve(r, "params.retryBudget", p.RetryBudget, 0, strconv.Atoi),
+		VerifyGroupExists:      resolve(r, "params.verifyGroupExists", p.VerifyGroupExists, false, strconv.ParseBool),
+		EmptyFilterPolicies:    resolve(r, "params.emptyFilterPolicies", p.EmptyFilterPolicies, nil, parseEmptyFilterPolicies),
+		RequireAuthContextHost: resolve(r, "params.requireAuthContextHost", p.RequireAuthContextHost, false, strconv.ParseBool),
+		BatchConcurrency:       resolve(r, "params.batchConcurrency", p.BatchConcurrency, DefaultBatchConcurrency, strconv.Atoi),
+		ExactGroupNameMatch:    resolve(r, "params.exactGroupNameMatch", p.ExactGroupNameMatch, false, strconv.ParseBool),
+		MultipleMatchPolicy:    resolve(r, "params.multipleMatchPolicy", p.MultipleMatchPolicy, MultipleMatchError, parseMultipleMatchPolicy),
+		ResolveGroupName:       resolve(r, "params.resolveGroupName", p.ResolveGroupName, false, strconv.ParseBool),
+		MemberIDsOnly:          resolve(r, "params.memberIDsOnly", p.MemberIDsOnly, false, strconv.ParseBool),
+		UseMemberDisplay:       resolve(r, "params.useMemberDisplay", p.UseMemberDisplay, false, strconv.ParseBool),
+		MaxGroupMembers:        resolve(r, "params.maxGroupMembers", p.MaxGroupMembers, 0, strconv.Atoi),
+		TruncateLargeGroups:    resolve(r, "params.truncateLargeGroups", p.TruncateLargeGroups, false, strconv.ParseBool),
+		MemberTimeout:          resolve(r, "params.memberTimeout", p.MemberTimeout, 0, time.ParseDuration),
+		SkipTimedOutMembers:    resolve(r, "params.skipTimedOutMembers", p.SkipTimedOutMembers, false, strconv.ParseBool),
+		PartialMemberResults:   resolve(r, "params.partialMemberResults", p.PartialMemberResults, false, strconv.ParseBool),
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return params, nil
+}
+
+// resolver keeps the error of the first param failing to resolve.
+type resolver struct {
+	err error
+}
+
+// resolve loads and parses the value of the named param, returning def
+// if the param is not set or a previous one failed.
+func resolve[T any](r *resolver, name string, ref commoncfg.SourceRef, def T, parse func(string) (T, error)) T {
+	if r.err != nil || ref.Source == "" {
+		return def
+	}
+
+	value, err := commoncfg.LoadValueFromSourceRef(ref)
+	if err != nil {
+		r.err = errs.Wrap(ErrLoadValue, fmt.Errorf("%s: %w", name, err))
+		return def
+	}
+
+	parsed, err := parse(string(value))
+	if err != nil {
+		r.err = errs.Wrap(ErrInvalidField, fmt.Errorf("%s: %w", name, err))
+		return def
+	}
+
+	return parsed
+}
+
+func parseString(value string) (string, error) {
+	return value, nil
+}
+
+// parseList parses a comma-separated list, dropping empty elements.
+func parseList(value string) ([]string, error) {
+	var list []string
+
+	for element := range strings.SplitSeq(value, ",") {
+		element = strings.TrimSpace(element)
+		if element != "" {
+			list = append(list, element)
+		}
+	}
+
+	return list, nil
+}
+
+func parseYAML[T any](value string) (T, error) {
+	var parsed T
+
+	err := yaml.Unmarshal([]byte(value), &parsed)
+
+	return parsed, err
+}
+
+// parseListMethod parses a list method, defaulting if empty.
+func parseListMethod(value string) (scim.ListMethod, error) {
+	if value == "" {
+		return DefaultListMethod, nil
+	}
+
+	return scim.ParseListMethod(value)
+}
+
+// parsePaginationParams parses the pagination parameter names from a
+// YAML map, e.g. {count: limit}, keeping the SCIM names of those not set.
+func parsePaginationParams(value string) (scim.PaginationParams, error) {
+	names := struct {
+		Count      string `yaml:"count"`
+		StartIndex string `yaml:"startIndex"`
+		Cursor     string `yaml:"cursor"`
+	}(scim.DefaultPaginationParams)
+
+	err := yaml.Unmarshal([]byte(value), &names)
+	if err != nil {
+		return scim.PaginationParams{}, err
+	}
+
+	return scim.PaginationParams(names), nil
+}
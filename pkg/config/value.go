@@ -0,0 +1,44 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+var ErrLoadValue = errors.New("failed to load configuration value")
+
+// LoadBool loads the value of the source reference and parses it
+// as a boolean.
+func LoadBool(ref commoncfg.SourceRef) (bool, error) {
+	value, err := commoncfg.LoadValueFromSourceRef(ref)
+	if err != nil {
+		return false, errs.Wrap(ErrLoadValue, err)
+	}
+
+	b, err := strconv.ParseBool(string(value))
+	if err != nil {
+		return false, errs.Wrap(ErrInvalidField, err)
+	}
+
+	return b, nil
+}
+
+// LoadInt loads the value of the source reference and parses it
+// as an integer.
+func LoadInt(ref commoncfg.SourceRef) (int, error) {
+	value, err := commoncfg.LoadValueFromSourceRef(ref)
+	if err != nil {
+		return 0, errs.Wrap(ErrLoadValue, err)
+	}
+
+	i, err := strconv.Atoi(string(value))
+	if err != nil {
+		return 0, errs.Wrap(ErrInvalidField, err)
+	}
+
+	return i, nil
+}
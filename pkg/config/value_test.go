@@ -0,0 +1,96 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/openkcm/common-sdk/pkg/commoncfg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/config"
+)
+
+func TestLoadBool(t *testing.T) {
+	tests := []struct {
+		name          string
+		ref           commoncfg.SourceRef
+		expected      bool
+		expectedError error
+	}{
+		{
+			name:     "True",
+			ref:      commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "true"},
+			expected: true,
+		},
+		{
+			name:     "False",
+			ref:      commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "0"},
+			expected: false,
+		},
+		{
+			name:          "Invalid value",
+			ref:           commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "maybe"},
+			expectedError: config.ErrInvalidField,
+		},
+		{
+			name:          "Unset source",
+			ref:           commoncfg.SourceRef{},
+			expectedError: config.ErrLoadValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := config.LoadBool(tt.ref)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestLoadInt(t *testing.T) {
+	tests := []struct {
+		name          string
+		ref           commoncfg.SourceRef
+		expected      int
+		expectedError error
+	}{
+		{
+			name:     "Positive",
+			ref:      commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "100"},
+			expected: 100,
+		},
+		{
+			name:     "Negative",
+			ref:      commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "-1"},
+			expected: -1,
+		},
+		{
+			name:          "Invalid value",
+			ref:           commoncfg.SourceRef{Source: commoncfg.EmbeddedSourceValue, Value: "ten"},
+			expectedError: config.ErrInvalidField,
+		},
+		{
+			name:          "Unset source",
+			ref:           commoncfg.SourceRef{},
+			expectedError: config.ErrLoadValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := config.LoadInt(tt.ref)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Join aggregates the given errors, ignoring nil values.
+// It returns nil if all errors are nil.
+func Join(errs ...error) error {
+	return errors.Join(errs...)
+}
+
+// Joinf aggregates the given errors like Join and prefixes the result with str.
+// It returns nil if all errors are nil.
+func Joinf(str string, errs ...error) error {
+	joined := Join(errs...)
+	if joined == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", str, joined)
+}
@@ -0,0 +1,50 @@
+package errs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+var (
+	errTest1 = errors.New("test1")
+	errTest2 = errors.New("test2")
+)
+
+func TestJoin(t *testing.T) {
+	t.Run("Should return nil for no errors", func(t *testing.T) {
+		assert.NoError(t, errs.Join())
+	})
+
+	t.Run("Should return nil when all errors are nil", func(t *testing.T) {
+		assert.NoError(t, errs.Join(nil, nil))
+	})
+
+	t.Run("Should return single error", func(t *testing.T) {
+		joined := errs.Join(nil, errTest1)
+		assert.ErrorIs(t, joined, errTest1)
+		assert.Equal(t, "test1", joined.Error())
+	})
+
+	t.Run("Should return joined errors", func(t *testing.T) {
+		joined := errs.Join(errTest1, nil, errTest2)
+		assert.ErrorIs(t, joined, errTest1)
+		assert.ErrorIs(t, joined, errTest2)
+	})
+}
+
+func TestJoinf(t *testing.T) {
+	t.Run("Should return nil when all errors are nil", func(t *testing.T) {
+		assert.NoError(t, errs.Joinf("prefix", nil))
+	})
+
+	t.Run("Should return prefixed joined errors", func(t *testing.T) {
+		joined := errs.Joinf("prefix", errTest1, errTest2)
+		assert.ErrorIs(t, joined, errTest1)
+		assert.ErrorIs(t, joined, errTest2)
+		assert.Equal(t, "prefix: test1\ntest2", joined.Error())
+	})
+}
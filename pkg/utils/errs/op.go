@@ -0,0 +1,37 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithOp prefixes err with a stable operation label while preserving
+// the wrapped chain. It returns nil if err is nil.
+func WithOp(op string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// HasSentinel reports whether any of the sentinels is in the chain of err.
+func HasSentinel(err error, sentinels ...error) bool {
+	for _, sentinel := range sentinels {
+		if errors.Is(err, sentinel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Is is a passthrough to errors.Is.
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+// As is a passthrough to errors.As.
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
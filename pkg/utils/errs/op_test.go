@@ -0,0 +1,43 @@
+package errs_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/errs"
+)
+
+func TestWithOp(t *testing.T) {
+	t.Run("Should return nil for nil error", func(t *testing.T) {
+		assert.NoError(t, errs.WithOp("GetGroup", nil))
+	})
+
+	t.Run("Should prefix the operation and keep the chain", func(t *testing.T) {
+		annotated := errs.WithOp("GetGroup", errs.Wrap(errTest1, errTest2))
+		assert.Equal(t, "GetGroup: test1: test2", annotated.Error())
+		assert.ErrorIs(t, annotated, errTest1)
+		assert.ErrorIs(t, annotated, errTest2)
+		assert.True(t, errs.Is(annotated, errTest2))
+	})
+}
+
+func TestHasSentinel(t *testing.T) {
+	annotated := errs.WithOp("GetUsersForGroup", errTest1)
+
+	assert.True(t, errs.HasSentinel(annotated, errTest2, errTest1))
+	assert.False(t, errs.HasSentinel(annotated, errTest2))
+	assert.False(t, errs.HasSentinel(annotated))
+}
+
+func TestAs(t *testing.T) {
+	annotated := errs.WithOp("GetUser", &url.Error{Op: "Get", URL: "badurl", Err: errTest1})
+
+	var urlErr *url.Error
+
+	assert.True(t, errs.As(annotated, &urlErr))
+	assert.Equal(t, "badurl", urlErr.URL)
+	assert.False(t, errs.As(errors.New("plain"), &urlErr))
+}
@@ -12,12 +12,40 @@ var (
 	ErrUnexpectedStatusCode = errors.New("unexpected status code")
 )
 
+// StatusCodeError reports an unexpected HTTP response status.
+// It matches ErrUnexpectedStatusCode with errors.Is.
+type StatusCodeError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusCodeError) Error() string {
+	return fmt.Sprintf("%s %s", ErrUnexpectedStatusCode, e.Status)
+}
+
+func (e *StatusCodeError) Is(target error) bool {
+	return target == ErrUnexpectedStatusCode
+}
+
+// DecodeOption configures the JSON decoder of DecodeResponse.
+type DecodeOption func(*json.Decoder)
+
+// DisallowUnknownFields makes decoding fail on fields of the response
+// body not present in the target type, to catch schema drift in tests.
+func DisallowUnknownFields() DecodeOption {
+	return func(dec *json.Decoder) {
+		dec.DisallowUnknownFields()
+	}
+}
+
 // DecodeResponse decodes the HTTP response body into the provided type T.
+// Unknown fields are ignored unless DisallowUnknownFields is given.
 func DecodeResponse[T any](
 	ctx context.Context,
 	apiName string,
 	resp *http.Response,
 	expectedStatus int,
+	opts ...DecodeOption,
 ) (*T, error) {
 	var (
 		respErr error
@@ -25,9 +53,14 @@ func DecodeResponse[T any](
 	)
 
 	if resp.StatusCode == expectedStatus {
-		respErr = json.NewDecoder(resp.Body).Decode(&result)
+		dec := json.NewDecoder(resp.Body)
+		for _, opt := range opts {
+			opt(dec)
+		}
+
+		respErr = dec.Decode(&result)
 	} else {
-		respErr = fmt.Errorf("%w %s", ErrUnexpectedStatusCode, resp.Status)
+		respErr = &StatusCodeError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	if respErr != nil {
@@ -1,6 +1,7 @@
 package httpclient_test
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -20,6 +21,7 @@ func TestDecodeResponse(t *testing.T) {
 		statusCode     int
 		responseBody   string
 		expectedStatus int
+		strict         bool
 		expectedResult *Response
 		expectError    bool
 		errorContains  string
@@ -41,6 +43,24 @@ func TestDecodeResponse(t *testing.T) {
 			expectError:    true,
 			errorContains:  "unexpected status code",
 		},
+		{
+			name:           "Unknown field lenient",
+			statusCode:     http.StatusOK,
+			responseBody:   `{"message": "success", "extra": true}`,
+			expectedStatus: http.StatusOK,
+			expectedResult: &Response{Message: "success"},
+			expectError:    false,
+		},
+		{
+			name:           "Unknown field strict",
+			statusCode:     http.StatusOK,
+			responseBody:   `{"message": "success", "extra": true}`,
+			expectedStatus: http.StatusOK,
+			strict:         true,
+			expectedResult: nil,
+			expectError:    true,
+			errorContains:  `unknown field "extra"`,
+		},
 		{
 			name:           "Invalid JSON",
 			statusCode:     http.StatusOK,
@@ -70,12 +90,23 @@ func TestDecodeResponse(t *testing.T) {
 				defer resp.Body.Close()
 			}
 
-			result, err := httpclient.DecodeResponse[Response](t.Context(), "TestAPI", resp, tt.expectedStatus)
+			var opts []httpclient.DecodeOption
+			if tt.strict {
+				opts = append(opts, httpclient.DisallowUnknownFields())
+			}
+
+			result, err := httpclient.DecodeResponse[Response](t.Context(), "TestAPI", resp, tt.expectedStatus, opts...)
 
 			if tt.expectError {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.errorContains)
 				assert.Nil(t, result)
+
+				var statusErr *httpclient.StatusCodeError
+				if errors.As(err, &statusErr) {
+					assert.ErrorIs(t, err, httpclient.ErrUnexpectedStatusCode)
+					assert.Equal(t, tt.statusCode, statusErr.StatusCode)
+				}
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedResult, result)
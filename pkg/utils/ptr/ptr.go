@@ -0,0 +1,40 @@
+package ptr
+
+// PointTo returns a pointer to the given value.
+func PointTo[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value p points to, or def if p is nil.
+func Deref[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+
+	return *p
+}
+
+// OrZero returns the value p points to, or the zero value if p is nil.
+func OrZero[T any](p *T) T {
+	var zero T
+	return Deref(p, zero)
+}
+
+// Equal reports whether both pointers are nil or point to equal values.
+func Equal[T comparable](a, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// To is an alias of PointTo.
+func To[T any](v T) *T {
+	return PointTo(v)
+}
+
+// String returns a pointer to the given string, including the empty string.
+func String(v string) *string {
+	return &v
+}
@@ -0,0 +1,65 @@
+package ptr_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/ptr"
+)
+
+func TestPointTo(t *testing.T) {
+	p := ptr.PointTo(5)
+	assert.Equal(t, 5, *p)
+}
+
+func TestDeref(t *testing.T) {
+	assert.Equal(t, "cursor", ptr.Deref(ptr.PointTo("cursor"), "default"))
+	assert.Equal(t, "default", ptr.Deref(nil, "default"))
+}
+
+func TestOrZero(t *testing.T) {
+	assert.Equal(t, 100, ptr.OrZero(ptr.PointTo(100)))
+	assert.Equal(t, 0, ptr.OrZero[int](nil))
+	assert.Empty(t, ptr.OrZero[string](nil))
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        *int
+		b        *int
+		expected bool
+	}{
+		{name: "Both nil", a: nil, b: nil, expected: true},
+		{name: "First nil", a: nil, b: ptr.PointTo(1), expected: false},
+		{name: "Second nil", a: ptr.PointTo(1), b: nil, expected: false},
+		{name: "Equal values", a: ptr.PointTo(1), b: ptr.PointTo(1), expected: true},
+		{name: "Different values", a: ptr.PointTo(1), b: ptr.PointTo(2), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ptr.Equal(tt.a, tt.b))
+		})
+	}
+}
+
+func TestTo(t *testing.T) {
+	p := ptr.To("x")
+	assert.Equal(t, "x", *p)
+}
+
+func TestString(t *testing.T) {
+	t.Run("Should point to the given string", func(t *testing.T) {
+		p := ptr.String("x")
+		assert.NotNil(t, p)
+		assert.Equal(t, "x", *p)
+	})
+
+	t.Run("Should point to the empty string", func(t *testing.T) {
+		p := ptr.String("")
+		assert.NotNil(t, p)
+		assert.Empty(t, *p)
+	})
+}
@@ -0,0 +1,93 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Mask replaces the value of every redacted field.
+const Mask = "[REDACTED]"
+
+// DefaultFields are the SCIM attributes and headers redacted when
+// no field list is configured.
+var DefaultFields = []string{
+	"emails",
+	"password",
+	"passwordDetails",
+	"Authorization",
+}
+
+// Redactor masks sensitive fields in SCIM payloads and HTTP headers
+// so they can be logged safely. Field names are matched case-insensitively
+// at any nesting depth, as SCIM attribute names are case-insensitive.
+type Redactor struct {
+	fields map[string]struct{}
+}
+
+// New creates a Redactor for the given fields, falling back to
+// DefaultFields if none are provided.
+func New(fields ...string) *Redactor {
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+
+	r := &Redactor{fields: make(map[string]struct{}, len(fields))}
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = struct{}{}
+	}
+
+	return r
+}
+
+// JSON returns a copy of the JSON payload with all configured fields masked.
+func (r *Redactor) JSON(payload []byte) ([]byte, error) {
+	var decoded any
+
+	err := json.Unmarshal(payload, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(r.redactValue(decoded))
+}
+
+// Headers returns a copy of the headers with all configured headers masked.
+func (r *Redactor) Headers(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for key := range redacted {
+		if r.isSensitive(key) {
+			redacted[key] = []string{Mask}
+		}
+	}
+
+	return redacted
+}
+
+func (r *Redactor) redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, nested := range v {
+			if r.isSensitive(key) {
+				v[key] = Mask
+			} else {
+				v[key] = r.redactValue(nested)
+			}
+		}
+
+		return v
+	case []any:
+		for i, nested := range v {
+			v[i] = r.redactValue(nested)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+func (r *Redactor) isSensitive(field string) bool {
+	_, ok := r.fields[strings.ToLower(field)]
+	return ok
+}
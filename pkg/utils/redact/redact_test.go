@@ -0,0 +1,89 @@
+package redact_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/utils/redact"
+)
+
+const userPayload = `{"id":"aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee","userName":"cloudanalyst",` +
+	`"name":{"familyName":"Analyst","givenName":"Cloud"},` +
+	`"emails":[{"value":"cloud.analyst@example.com","primary":true}],` +
+	`"urn:ietf:params:scim:schemas:extension:sap:2.0:User":` +
+	`{"emails":[{"value":"cloud.analyst@example.com"}],"userId":"P000011",` +
+	`"passwordDetails":{"failedLoginAttempts":0,"status":"initial"}}}`
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []string
+		expected map[string]any
+	}{
+		{
+			name:   "Default fields",
+			fields: nil,
+			expected: map[string]any{
+				"id":       "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+				"userName": "cloudanalyst",
+				"name":     map[string]any{"familyName": "Analyst", "givenName": "Cloud"},
+				"emails":   redact.Mask,
+				"urn:ietf:params:scim:schemas:extension:sap:2.0:User": map[string]any{
+					"emails":          redact.Mask,
+					"userId":          "P000011",
+					"passwordDetails": redact.Mask,
+				},
+			},
+		},
+		{
+			name:   "Configured fields",
+			fields: []string{"Name", "userName"},
+			expected: map[string]any{
+				"id":       "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+				"userName": redact.Mask,
+				"name":     redact.Mask,
+				"emails": []any{
+					map[string]any{"value": "cloud.analyst@example.com", "primary": true},
+				},
+				"urn:ietf:params:scim:schemas:extension:sap:2.0:User": map[string]any{
+					"emails":          []any{map[string]any{"value": "cloud.analyst@example.com"}},
+					"userId":          "P000011",
+					"passwordDetails": map[string]any{"failedLoginAttempts": float64(0), "status": "initial"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted, err := redact.New(tt.fields...).JSON([]byte(userPayload))
+			assert.NoError(t, err)
+
+			var result map[string]any
+
+			err = json.Unmarshal(redacted, &result)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	t.Run("Invalid JSON", func(t *testing.T) {
+		_, err := redact.New().JSON([]byte("invalid-json"))
+		assert.Error(t, err)
+	})
+}
+
+func TestHeaders(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Authorization", "Basic c2VjcmV0")
+	headers.Set("Accept", "application/scim+json")
+
+	redacted := redact.New().Headers(headers)
+
+	assert.Equal(t, redact.Mask, redacted.Get("Authorization"))
+	assert.Equal(t, "application/scim+json", redacted.Get("Accept"))
+	assert.Equal(t, "Basic c2VjcmV0", headers.Get("Authorization"))
+}
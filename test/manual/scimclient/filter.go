@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+var errConflictingFilters = errors.New("--filter, --attribute and --displayName are mutually exclusive")
+
+// rawFilter passes a SCIM filter given on the command line through as is.
+type rawFilter string
+
+func (f rawFilter) ToString() string {
+	return string(f)
+}
+
+// Evaluate matches every resource, as only the server evaluates raw filters.
+func (f rawFilter) Evaluate(_ any) bool {
+	return true
+}
+
+// buildFilter returns the raw filter, the structured comparison or the
+// display name filter, whichever is given, and no filter otherwise.
+func buildFilter(filter, attribute, operator, value, displayName string) (scim.FilterExpression, error) {
+	given := 0
+
+	for _, option := range []string{filter, attribute, displayName} {
+		if option != "" {
+			given++
+		}
+	}
+
+	switch {
+	case given > 1:
+		return nil, errConflictingFilters
+	case filter != "":
+		return rawFilter(filter), nil
+	case attribute != "":
+		return scim.FilterComparison{
+			Attribute: attribute,
+			Operator:  scim.FilterOperator(operator),
+			Value:     value,
+		}, nil
+	default:
+		return displayNameFilter(displayName), nil
+	}
+}
+
+// displayNameFilter matches the display name if given, and anything otherwise.
+func displayNameFilter(displayName string) scim.FilterExpression {
+	if displayName == "" {
+		return scim.NullFilterExpression{}
+	}
+
+	return scim.FilterComparison{
+		Attribute: "displayName",
+		Operator:  scim.FilterOperatorEqual,
+		Value:     displayName,
+	}
+}
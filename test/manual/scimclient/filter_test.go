@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestBuildFilter(t *testing.T) {
+	tests := []struct {
+		name           string
+		filter         string
+		attribute      string
+		operator       string
+		value          string
+		displayName    string
+		expectedFilter string
+		expectedError  error
+	}{
+		{
+			name: "No filter",
+		},
+		{
+			name:           "Raw filter",
+			filter:         `userName sw "cloud" and active eq "true"`,
+			expectedFilter: `userName sw "cloud" and active eq "true"`,
+		},
+		{
+			name:           "Comparison",
+			attribute:      "emails.value",
+			operator:       "co",
+			value:          "example.com",
+			expectedFilter: `emails.value co "example.com"`,
+		},
+		{
+			name:           "Presence",
+			attribute:      "externalId",
+			operator:       "pr",
+			expectedFilter: "externalId pr",
+		},
+		{
+			name:           "Display name",
+			displayName:    "KeyAdmin",
+			expectedFilter: `displayName eq "KeyAdmin"`,
+		},
+		{
+			name:          "Conflicting filters",
+			filter:        `userName eq "cloudanalyst"`,
+			displayName:   "KeyAdmin",
+			expectedError: errConflictingFilters,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := buildFilter(tt.filter, tt.attribute, tt.operator, tt.value, tt.displayName)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedFilter, filter.ToString())
+		})
+	}
+}
+
+func TestFilterReachesServer(t *testing.T) {
+	const filter = `userName sw "cloud"`
+
+	var received []string
+
+	mock := newMockServer()
+	defer mock.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			received = append(received, r.URL.Query().Get("filter"))
+		}
+
+		mock.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client, err := scim.NewClient(getSecretRef("mock", "", "", ""), getLogger())
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+
+	err = listUsers(t.Context(), client, &out, outputText, server.URL, listOptions{
+		method: http.MethodGet,
+		count:  defaultCount,
+		filter: rawFilter(filter),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filter}, received)
+	assert.Equal(t, "Found Users:\ncloudanalyst\n", out.String())
+}
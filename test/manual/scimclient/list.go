@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+// listOptions select the users or groups to list.
+type listOptions struct {
+	method     string
+	cursor     string
+	count      int
+	filter     scim.FilterExpression
+	all        bool // Follow the cursor through all pages
+	maxResults int  // Unbounded if zero
+}
+
+// listResources lists the page selected by the options, or with all set,
+// every page from it, returning at most maxResults resources.
+func listResources[T any](
+	ctx context.Context,
+	host string,
+	opts listOptions,
+	listAll func(context.Context, scim.RequestParams) ([]T, error),
+	listPage func(context.Context, scim.RequestParams) ([]T, error),
+) ([]T, error) {
+	params := scim.RequestParams{
+		Host:       host,
+		Method:     opts.method,
+		Filter:     opts.filter,
+		Cursor:     &opts.cursor,
+		Count:      &opts.count,
+		MaxResults: opts.maxResults,
+	}
+
+	if opts.all {
+		return listAll(ctx, params)
+	}
+
+	resources, err := listPage(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.maxResults > 0 && len(resources) > opts.maxResults {
+		resources = resources[:opts.maxResults]
+	}
+
+	return resources, nil
+}
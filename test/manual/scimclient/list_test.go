@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim/scimtest"
+)
+
+func TestListAllPages(t *testing.T) {
+	server := scimtest.NewServer()
+	defer server.Close()
+
+	for _, name := range []string{"user1", "user2", "user3", "user4", "user5"} {
+		server.AddUsers(scim.User{BaseResource: scim.BaseResource{ID: name}, UserName: name})
+	}
+
+	client, err := scim.NewClient(getSecretRef("mock", "", "", ""), getLogger())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		all      bool
+		max      int
+		expected string
+	}{
+		{
+			name:     "First page",
+			expected: "Found Users:\nuser1\nuser2\n",
+		},
+		{
+			name:     "All pages",
+			all:      true,
+			expected: "Found Users:\nuser1\nuser2\nuser3\nuser4\nuser5\n",
+		},
+		{
+			name:     "All pages bounded",
+			all:      true,
+			max:      3,
+			expected: "Found Users:\nuser1\nuser2\nuser3\n",
+		},
+		{
+			name:     "First page bounded",
+			max:      1,
+			expected: "Found Users:\nuser1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := listUsers(t.Context(), client, &out, outputText, server.URL, listOptions{
+				method:     http.MethodGet,
+				count:      2,
+				filter:     scim.NullFilterExpression{},
+				all:        tt.all,
+				maxResults: tt.max,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
@@ -5,31 +5,51 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/openkcm/common-sdk/pkg/commoncfg"
 
 	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim/scimtest"
 )
 
 const usage = `Script to test SCIM API calls.
 Usage: scimclient [options]
 Options:
-	--action	Action to perform (GetUser, ListUsers, GetGroup, ListGroups) (Required)
+	--action	Action to perform (GetUser, ListUsers, GetGroup, ListGroups,
+		CreateUser, UpdateUser, DeleteUser, CreateGroup, UpdateGroup, DeleteGroup) (Required)
 	--host		The SCIM server host (Required)
-	--clientID	Client ID for authentication (Required)
+	--clientID	Client ID for authentication (Required unless using OAuth2)
 	--clientSecret  Client secret value (if using secret auth)
 	--certPath      Path to the client certificate file (if using cert-based auth)
 	--keyPath       Path to the client private key file (if using cert-based auth)
+	--tokenURL	OAuth2 token endpoint URL (if using OAuth2 client credentials auth)
+	--oauthClientID	OAuth2 client ID (if using OAuth2 auth)
+	--oauthClientSecret	OAuth2 client secret (if using OAuth2 auth)
+	--scopes	Comma-separated OAuth2 scopes to request (if using OAuth2 auth)
 	--useHTTPPost	Use HTTP POST to /.search endpoint instead of GET for listing users/groups
-	--id		ID of the user or group to retrieve
+	--id		ID of the user or group to retrieve, update or delete
+	--data		Inline JSON user or group for create and update actions
+	--dataFile	Path to a JSON file with the user or group for create and update actions
 	--cursor	Cursor for pagination
 	--count	Limit for pagination
+	--all		Follow the cursor through all pages when listing users/groups
+	--max		Maximum number of users/groups listed, unbounded if zero
 	--displayName	Search for groups/users by DisplayName attribute
+	--filter	Raw SCIM filter for listing groups/users, e.g. 'userName sw "a"'
+	--attribute	Attribute of a single comparison filter for listing groups/users
+	--operator	Operator of the comparison filter (eq, ne, co, sw, ew, gt, ge, lt, le, pr), eq by default
+	--value		Value of the comparison filter
+	--insecureSkipVerify	Skip TLS verification of the server certificate, e.g. for a self-signed dev instance (UNSAFE, testing only)
+	--output	Output format of the retrieved users/groups (text, json, csv), text by default
+	--raw		Print the unparsed JSON response body instead of the parsed fields
+	--mock		Run against an in-memory mock SCIM server seeded with sample data (ignores --host)
 `
 
 const defaultCount = 100
@@ -42,30 +62,64 @@ func main() {
 	log.SetOutput(os.Stdout)
 	slog.SetLogLoggerLevel(slog.LevelDebug)
 
+	os.Exit(run())
+}
+
+// run runs the script, returning its exit code rather than exiting so
+// that deferred cleanups, e.g. closing the mock server, run.
+func run() int {
 	var (
-		action, host, clientID, clientSecret, certPath, keyPath, id, cursor, displayName string
-		useHTTPPost                                                                      bool
-		count                                                                            int
+		action, host, clientID, clientSecret, certPath, keyPath, id, cursor, displayName, data, dataFile string
+		tokenURL, oauthClientID, oauthClientSecret, scopes                                               string
+		rawFilterFlag, attribute, operator, value, output                                                string
+		useHTTPPost, mock, insecureSkipVerify, raw, all                                                  bool
+		count, maxResults                                                                                int
 	)
 
-	flag.StringVar(&action, "action", "", "Action to perform (GetUser, ListUsers, GetGroup, ListGroups)")
+	flag.StringVar(&action, "action", "", "Action to perform (GetUser, ListUsers, GetGroup, ListGroups, "+
+		"CreateUser, UpdateUser, DeleteUser, CreateGroup, UpdateGroup, DeleteGroup)")
 	flag.StringVar(&host, "host", "", "SCIM server host")
 	flag.StringVar(&clientID, "clientID", "", "Client ID")
 	flag.StringVar(&clientSecret, "clientSecret", "", "Client Secret")
 	flag.StringVar(&certPath, "certPath", "", "Client Certificate Path")
 	flag.StringVar(&keyPath, "keyPath", "", "Client Private Key Path")
-	flag.StringVar(&id, "id", "", "ID of the user or group to retrieve")
+	flag.StringVar(&tokenURL, "tokenURL", "", "OAuth2 token endpoint URL")
+	flag.StringVar(&oauthClientID, "oauthClientID", "", "OAuth2 client ID")
+	flag.StringVar(&oauthClientSecret, "oauthClientSecret", "", "OAuth2 client secret")
+	flag.StringVar(&scopes, "scopes", "", "Comma-separated OAuth2 scopes to request")
+	flag.StringVar(&id, "id", "", "ID of the user or group to retrieve, update or delete")
+	flag.StringVar(&data, "data", "", "Inline JSON user or group for create and update actions")
+	flag.StringVar(&dataFile, "dataFile", "", "Path to a JSON file with the user or group for create and update actions")
 	flag.StringVar(&cursor, "cursor", "", "Cursor for pagination")
 	flag.StringVar(&displayName, "displayName", "", "Search for groups/users by DisplayName attribute")
+	flag.StringVar(&rawFilterFlag, "filter", "", "Raw SCIM filter for listing users/groups, e.g. 'userName sw \"a\"'")
+	flag.StringVar(&attribute, "attribute", "", "Attribute of a single comparison filter for listing users/groups")
+	flag.StringVar(&operator, "operator", string(scim.FilterOperatorEqual), "Operator of the comparison filter")
+	flag.StringVar(&value, "value", "", "Value of the comparison filter")
 	flag.IntVar(&count, "count", defaultCount, "Limit for pagination")
+	flag.BoolVar(&all, "all", false, "Follow the cursor through all pages when listing users/groups")
+	flag.IntVar(&maxResults, "max", 0, "Maximum number of users/groups listed, unbounded if zero")
 	flag.BoolVar(&useHTTPPost, "useHTTPPost", false,
 		"Use HTTP POST to /.search endpoint instead of GET for listing users/groups")
+	flag.BoolVar(&insecureSkipVerify, "insecureSkipVerify", false,
+		"Skip TLS verification of the server certificate (UNSAFE, testing only)")
+	flag.StringVar(&output, "output", string(outputText), "Output format of the retrieved users/groups (text, json, csv)")
+	flag.BoolVar(&raw, "raw", false, "Print the unparsed JSON response body instead of the parsed fields")
+	flag.BoolVar(&mock, "mock", false, "Run against an in-memory mock SCIM server seeded with sample data")
 
 	flag.Parse()
 
-	if action == "" || host == "" || clientID == "" {
+	if mock {
+		server := newMockServer()
+		defer server.Close()
+
+		host = server.URL
+		clientID = "mock"
+	}
+
+	if action == "" || host == "" || (clientID == "" && tokenURL == "") {
 		fmt.Print(usage)
-		os.Exit(1)
+		return 1
 	}
 
 	var (
@@ -74,9 +128,110 @@ func main() {
 
 	ctx := context.Background()
 
-	var secretRef commoncfg.SecretRef
+	secretRef := getSecretRef(clientID, clientSecret, certPath, keyPath)
+
+	var opts []scim.Option
+	if tokenURL != "" {
+		secretRef = getOAuth2SecretRef(tokenURL, oauthClientID, oauthClientSecret)
+
+		if scopes != "" {
+			opts = append(opts, scim.WithOAuth2Scopes(strings.Split(scopes, ",")...))
+		}
+	}
+
+	if insecureSkipVerify {
+		fmt.Println("WARNING: TLS certificate verification is disabled, use for testing only")
+
+		opts = append(opts, scim.WithInsecureSkipVerify())
+	}
+
+	client, err := scim.NewClient(secretRef, getLogger(), opts...)
+	if err != nil {
+		fmt.Println("Error creating SCIM client:", err.Error())
+		return 1
+	}
+
+	method := http.MethodGet
+	if useHTTPPost {
+		method = http.MethodPost
+	}
+
+	filter, err := buildFilter(rawFilterFlag, attribute, operator, value, displayName)
+	if err != nil {
+		fmt.Println("Error building filter:", err.Error())
+		return 1
+	}
+
+	format, err := parseOutputFormat(output)
+	if err != nil {
+		fmt.Println("Error parsing output format:", err.Error())
+		return 1
+	}
+
+	listOpts := listOptions{
+		method:     method,
+		cursor:     cursor,
+		count:      count,
+		filter:     filter,
+		all:        all,
+		maxResults: maxResults,
+	}
+
+	var payload []byte
+	if isWriteAction(action) {
+		payload, err = loadPayload(data, dataFile)
+		if err != nil {
+			fmt.Println("Error reading payload:", err.Error())
+			return 1
+		}
+	}
+
+	err = runAction(ctx, os.Stdout, raw, func(ctx context.Context, out io.Writer) error {
+		switch action {
+		case "GetUser":
+			return getUser(ctx, client, out, format, host, id)
+		case "ListUsers":
+			return listUsers(ctx, client, out, format, host, listOpts)
+		case "GetGroup":
+			return getGroup(ctx, client, out, format, host, id)
+		case "ListGroups":
+			return listGroups(ctx, client, out, format, host, listOpts)
+		default:
+			return runWriteAction(ctx, client, out, host, action, id, payload)
+		}
+	})
+	if err != nil {
+		fmt.Println("Error performing "+action+":", err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// runAction runs the action writing to out, or with raw set, writes the
+// undecoded body of the last response instead of the action output.
+func runAction(ctx context.Context, out io.Writer, raw bool, action func(context.Context, io.Writer) error) error {
+	if !raw {
+		return action(ctx, out)
+	}
+
+	var body []byte
+
+	err := action(scim.ContextWithRawBody(ctx, &body), io.Discard)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(out, strings.TrimSpace(string(body)))
+
+	return err
+}
+
+// getSecretRef returns mTLS credentials if a certificate and key are
+// given, and Basic ones otherwise.
+func getSecretRef(clientID, clientSecret, certPath, keyPath string) commoncfg.SecretRef {
 	if certPath != "" && keyPath != "" {
-		secretRef = commoncfg.SecretRef{
+		return commoncfg.SecretRef{
 			Type: commoncfg.MTLSSecretType,
 			MTLS: commoncfg.MTLS{
 				Cert: commoncfg.SourceRef{
@@ -95,146 +250,141 @@ func main() {
 				},
 			},
 		}
-	} else {
-		secretRef = commoncfg.SecretRef{
-			Type: commoncfg.BasicSecretType,
-			Basic: commoncfg.BasicAuth{
-				Username: commoncfg.SourceRef{
+	}
+
+	return commoncfg.SecretRef{
+		Type: commoncfg.BasicSecretType,
+		Basic: commoncfg.BasicAuth{
+			Username: commoncfg.SourceRef{
+				Source: commoncfg.EmbeddedSourceValue,
+				Value:  clientID,
+			},
+			Password: commoncfg.SourceRef{
+				Source: commoncfg.EmbeddedSourceValue,
+				Value:  clientSecret,
+			},
+		},
+	}
+}
+
+// getOAuth2SecretRef returns OAuth2 client credentials.
+func getOAuth2SecretRef(tokenURL, clientID, clientSecret string) commoncfg.SecretRef {
+	return commoncfg.SecretRef{
+		Type: commoncfg.OAuth2SecretType,
+		OAuth2: commoncfg.OAuth2{
+			URL: &commoncfg.SourceRef{
+				Source: commoncfg.EmbeddedSourceValue,
+				Value:  tokenURL,
+			},
+			Credentials: commoncfg.OAuth2Credentials{
+				ClientID: commoncfg.SourceRef{
 					Source: commoncfg.EmbeddedSourceValue,
 					Value:  clientID,
 				},
-				Password: commoncfg.SourceRef{
+				ClientSecret: &commoncfg.SourceRef{
 					Source: commoncfg.EmbeddedSourceValue,
 					Value:  clientSecret,
 				},
 			},
-		}
-	}
-
-	client, err := scim.NewClient(secretRef, getLogger())
-	if err != nil {
-		fmt.Println("Error creating SCIM client:", err.Error())
-		os.Exit(1)
+		},
 	}
+}
 
-	method := http.MethodGet
-	if useHTTPPost {
-		method = http.MethodPost
-	}
+func newMockServer() *scimtest.Server {
+	server := scimtest.NewServer()
+	server.AddUsers(scim.User{
+		BaseResource: scim.BaseResource{ID: "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"},
+		UserName:     "cloudanalyst",
+		DisplayName:  "Cloud Analyst",
+		Active:       true,
+		Emails:       []scim.MultiValuedAttribute{{Primary: true, Value: "cloud.analyst@example.com"}},
+	})
+	server.AddGroups(scim.Group{
+		BaseResource: scim.BaseResource{ID: "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa"},
+		DisplayName:  "KeyAdmin",
+		Members:      []scim.MultiValuedAttribute{{Type: "User", Value: "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"}},
+	})
 
-	switch action {
-	case "GetUser":
-		getUser(ctx, client, host, id)
-	case "ListUsers":
-		listUsers(ctx, client, host, method, cursor, count, displayName)
-	case "GetGroup":
-		getGroup(ctx, client, host, id)
-	case "ListGroups":
-		listGroups(ctx, client, host, method, cursor, count, displayName)
-	default:
-		fmt.Println("Invalid action. Supported actions are: GetUser, ListUsers, GetGroup, ListGroups")
-		os.Exit(1)
-	}
+	return server
 }
 
-func getUser(ctx context.Context, client *scim.Client, host, id string) {
+func getUser(ctx context.Context, client *scim.Client, out io.Writer, format outputFormat, host, id string) error {
 	user, err := client.GetUser(ctx, id, scim.RequestParams{Host: host})
 	if err != nil {
-		fmt.Println("Error getting user:", err.Error())
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println("Found User:", user.UserName)
+	return printResources(out, format, user, []scim.User{*user}, userRow, []string{"Found User: " + user.UserName})
 }
 
-func listUsers(ctx context.Context,
+func listUsers(
+	ctx context.Context,
 	client *scim.Client,
+	out io.Writer,
+	format outputFormat,
 	host string,
-	method string,
-	cursor string,
-	count int,
-	displayName string,
-) {
-	var filter scim.FilterExpression
-	if displayName != "" {
-		filter = scim.FilterComparison{
-			Attribute: "displayName",
-			Operator:  scim.FilterOperatorEqual,
-			Value:     displayName,
-		}
-	} else {
-		filter = scim.NullFilterExpression{}
-	}
+	opts listOptions,
+) error {
+	users, err := listResources(ctx, host, opts, client.ListAllUsers,
+		func(ctx context.Context, params scim.RequestParams) ([]scim.User, error) {
+			users, err := client.ListUsers(ctx, params)
+			if err != nil {
+				return nil, err
+			}
 
-	users, err := client.ListUsers(ctx, scim.RequestParams{
-		Host:   host,
-		Method: method,
-		Filter: filter,
-		Cursor: &cursor,
-		Count:  &count,
-	})
+			return users.Resources, nil
+		})
 	if err != nil {
-		fmt.Println("Error listing users:", err.Error())
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println("Found Users:")
-
-	for _, user := range users.Resources {
-		fmt.Println(user.UserName)
+	lines := []string{"Found Users:"}
+	for _, user := range users {
+		lines = append(lines, user.UserName)
 	}
+
+	return printResources(out, format, users, users, userRow, lines)
 }
 
-func getGroup(ctx context.Context, client *scim.Client, host string, id string) {
+func getGroup(ctx context.Context, client *scim.Client, out io.Writer, format outputFormat, host, id string) error {
 	if id == "" {
-		fmt.Println("ID is required for GetGroup action")
-		os.Exit(1)
+		return errNoID
 	}
 
 	group, err := client.GetGroup(ctx, id, "members", scim.RequestParams{Host: host})
 	if err != nil {
-		fmt.Println("Error getting group:", err.Error())
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println("Found Group:", group.DisplayName)
+	return printResources(out, format, group, []scim.Group{*group}, groupRow,
+		[]string{"Found Group: " + group.DisplayName})
 }
 
 func listGroups(
 	ctx context.Context,
 	client *scim.Client,
+	out io.Writer,
+	format outputFormat,
 	host string,
-	method string,
-	cursor string,
-	count int,
-	displayName string,
-) {
-	var filter scim.FilterExpression
-	if displayName != "" {
-		filter = scim.FilterComparison{
-			Attribute: "displayName",
-			Operator:  scim.FilterOperatorEqual,
-			Value:     displayName,
-		}
-	} else {
-		filter = scim.NullFilterExpression{}
-	}
+	opts listOptions,
+) error {
+	groups, err := listResources(ctx, host, opts, client.ListAllGroups,
+		func(ctx context.Context, params scim.RequestParams) ([]scim.Group, error) {
+			groups, err := client.ListGroups(ctx, params)
+			if err != nil {
+				return nil, err
+			}
 
-	groups, err := client.ListGroups(ctx, scim.RequestParams{
-		Host:   host,
-		Method: method,
-		Filter: filter,
-		Cursor: &cursor,
-		Count:  &count,
-	})
+			return groups.Resources, nil
+		})
 	if err != nil {
-		fmt.Println("Error listing groups:", err.Error())
-		os.Exit(1)
+		return err
 	}
 
-	fmt.Println("Found Groups:")
-
-	for _, group := range groups.Resources {
-		fmt.Println(group.DisplayName)
+	lines := []string{"Found Groups:"}
+	for _, group := range groups {
+		lines = append(lines, group.DisplayName)
 	}
+
+	return printResources(out, format, groups, groups, groupRow, lines)
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestRunActionRaw(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	client, err := scim.NewClient(getSecretRef("mock", "", "", ""), getLogger())
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		action func(ctx context.Context, out io.Writer) error
+		field  string
+	}{
+		{
+			name: "GetUser",
+			action: func(ctx context.Context, out io.Writer) error {
+				return getUser(ctx, client, out, outputText, server.URL, "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee")
+			},
+			field: "userName",
+		},
+		{
+			name: "ListGroups",
+			action: func(ctx context.Context, out io.Writer) error {
+				return listGroups(ctx, client, out, outputText, server.URL, listOptions{
+					method: http.MethodPost,
+					count:  defaultCount,
+					filter: displayNameFilter("KeyAdmin"),
+				})
+			},
+			field: "Resources",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parsed bytes.Buffer
+
+			err := runAction(t.Context(), &parsed, false, tt.action)
+			assert.NoError(t, err)
+			assert.False(t, json.Valid(parsed.Bytes()))
+
+			var raw bytes.Buffer
+
+			err = runAction(t.Context(), &raw, true, tt.action)
+			assert.NoError(t, err)
+			assert.True(t, json.Valid(raw.Bytes()))
+
+			var body map[string]any
+
+			assert.NoError(t, json.Unmarshal(raw.Bytes(), &body))
+			assert.Contains(t, body, tt.field)
+		})
+	}
+}
+
+func TestOAuth2(t *testing.T) {
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "client", r.PostForm.Get("client_id"))
+		assert.Equal(t, "secret", r.PostForm.Get("client_secret"))
+		assert.Equal(t, "scim.read", r.PostForm.Get("scope"))
+
+		_, err := w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+		assert.NoError(t, err)
+	}))
+	defer tokens.Close()
+
+	mock := newMockServer()
+	defer mock.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(scim.HeaderAuthorization) != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mock.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client, err := scim.NewClient(
+		getOAuth2SecretRef(tokens.URL, "client", "secret"),
+		getLogger(),
+		scim.WithOAuth2Scopes("scim.read"),
+		scim.WithTokenCache(scim.NewTokenCache()),
+	)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+
+	err = getUser(t.Context(), client, &out, outputText, server.URL, "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee")
+	assert.NoError(t, err)
+	assert.Equal(t, "Found User: cloudanalyst\n", out.String())
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+// outputFormat is the format the retrieved resources are printed in.
+type outputFormat string
+
+const (
+	outputText outputFormat = "text"
+	outputJSON outputFormat = "json"
+	outputCSV  outputFormat = "csv"
+)
+
+var errInvalidOutputFormat = errors.New("invalid output format")
+
+// csvHeader is the header row of the flat CSV table of resources.
+var csvHeader = []string{"id", "name", "email"}
+
+func parseOutputFormat(format string) (outputFormat, error) {
+	switch outputFormat(format) {
+	case outputText, outputJSON, outputCSV:
+		return outputFormat(format), nil
+	default:
+		return "", fmt.Errorf("%w: %q, expected text, json or csv", errInvalidOutputFormat, format)
+	}
+}
+
+// printResources prints the decoded value as JSON, the resources as a
+// CSV table of the rows, or the text lines.
+func printResources[T any](
+	out io.Writer,
+	format outputFormat,
+	value any,
+	resources []T,
+	row func(T) []string,
+	lines []string,
+) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	case outputCSV:
+		writer := csv.NewWriter(out)
+
+		err := writer.Write(csvHeader)
+		if err != nil {
+			return err
+		}
+
+		for _, resource := range resources {
+			err = writer.Write(row(resource))
+			if err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+
+		return writer.Error()
+	default:
+		for _, line := range lines {
+			_, err := fmt.Fprintln(out, line)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func userRow(user scim.User) []string {
+	return []string{user.ID, user.UserName, primaryEmail(user)}
+}
+
+func groupRow(group scim.Group) []string {
+	return []string{group.ID, group.DisplayName, ""}
+}
+
+// primaryEmail returns the primary email of the user, or else the first one.
+func primaryEmail(user scim.User) string {
+	for _, email := range user.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+
+	if len(user.Emails) > 0 {
+		return user.Emails[0].Value
+	}
+
+	return ""
+}
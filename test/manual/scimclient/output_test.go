@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestOutputFormats(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	client, err := scim.NewClient(getSecretRef("mock", "", "", ""), getLogger())
+	assert.NoError(t, err)
+
+	listAll := listOptions{method: http.MethodGet, count: defaultCount, filter: scim.NullFilterExpression{}}
+
+	t.Run("JSON", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := listUsers(t.Context(), client, &out, outputJSON, server.URL, listAll)
+		assert.NoError(t, err)
+
+		var users []scim.User
+
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &users))
+		assert.Len(t, users, 1)
+		assert.Equal(t, "cloudanalyst", users[0].UserName)
+
+		out.Reset()
+
+		err = getGroup(t.Context(), client, &out, outputJSON, server.URL, "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa")
+		assert.NoError(t, err)
+
+		var group scim.Group
+
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &group))
+		assert.Equal(t, "KeyAdmin", group.DisplayName)
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := listUsers(t.Context(), client, &out, outputCSV, server.URL, listAll)
+		assert.NoError(t, err)
+
+		records, err := csv.NewReader(&out).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"id", "name", "email"},
+			{"aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee", "cloudanalyst", "cloud.analyst@example.com"},
+		}, records)
+
+		out.Reset()
+
+		err = listGroups(t.Context(), client, &out, outputCSV, server.URL, listAll)
+		assert.NoError(t, err)
+
+		records, err = csv.NewReader(&out).ReadAll()
+		assert.NoError(t, err)
+		assert.Equal(t, [][]string{
+			{"id", "name", "email"},
+			{"16e720aa-a009-4949-9bf9-aaaaaaaaaaaa", "KeyAdmin", ""},
+		}, records)
+	})
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, format := range []string{"text", "json", "csv"} {
+		parsed, err := parseOutputFormat(format)
+		assert.NoError(t, err)
+		assert.Equal(t, outputFormat(format), parsed)
+	}
+
+	_, err := parseOutputFormat("yaml")
+	assert.ErrorIs(t, err, errInvalidOutputFormat)
+}
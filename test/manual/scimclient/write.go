@@ -0,0 +1,131 @@
+//nolint:forbidigo
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+var (
+	errNoPayload     = errors.New("--data or --dataFile is required")
+	errNoID          = errors.New("--id is required")
+	errUnknownAction = errors.New("unknown action")
+)
+
+// isWriteAction reports whether the action creates, updates or deletes a resource.
+func isWriteAction(action string) bool {
+	switch action {
+	case "CreateUser", "UpdateUser", "DeleteUser", "CreateGroup", "UpdateGroup", "DeleteGroup":
+		return true
+	default:
+		return false
+	}
+}
+
+// loadPayload returns the inline JSON payload, or the content of the file if given.
+func loadPayload(data, dataFile string) ([]byte, error) {
+	if dataFile != "" {
+		return os.ReadFile(dataFile)
+	}
+
+	if data != "" {
+		return []byte(data), nil
+	}
+
+	return nil, nil
+}
+
+// runWriteAction performs the write action and prints the resource
+// returned by the server to out.
+func runWriteAction(
+	ctx context.Context,
+	client *scim.Client,
+	out io.Writer,
+	host, action, id string,
+	payload []byte,
+) error {
+	params := scim.RequestParams{Host: host}
+
+	var (
+		resource any
+		err      error
+	)
+
+	switch action {
+	case "CreateUser":
+		resource, err = writeWithPayload(payload, func(user scim.User) (*scim.User, error) {
+			return client.CreateUser(ctx, user, params)
+		})
+	case "UpdateUser":
+		if id == "" {
+			return errNoID
+		}
+
+		resource, err = writeWithPayload(payload, func(user scim.User) (*scim.User, error) {
+			return client.ReplaceUser(ctx, id, user, params)
+		})
+	case "CreateGroup":
+		resource, err = writeWithPayload(payload, func(group scim.Group) (*scim.Group, error) {
+			return client.CreateGroup(ctx, group, params)
+		})
+	case "UpdateGroup":
+		if id == "" {
+			return errNoID
+		}
+
+		resource, err = writeWithPayload(payload, func(group scim.Group) (*scim.Group, error) {
+			return client.ReplaceGroup(ctx, id, group, params)
+		})
+	case "DeleteUser", "DeleteGroup":
+		if id == "" {
+			return errNoID
+		}
+
+		if action == "DeleteUser" {
+			err = client.DeleteUser(ctx, id, params)
+		} else {
+			err = client.DeleteGroup(ctx, id, params)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(out, "Deleted", id)
+
+		return err
+	default:
+		return fmt.Errorf("%w: %s", errUnknownAction, action)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(resource)
+}
+
+// writeWithPayload decodes the payload into the resource and writes it.
+func writeWithPayload[T any](payload []byte, write func(T) (*T, error)) (*T, error) {
+	if len(payload) == 0 {
+		return nil, errNoPayload
+	}
+
+	var resource T
+
+	err := json.Unmarshal(payload, &resource)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+
+	return write(resource)
+}
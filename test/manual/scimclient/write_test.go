@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openkcm/identity-management-plugins/pkg/clients/scim"
+)
+
+func TestRunWriteAction(t *testing.T) {
+	server := newMockServer()
+	defer server.Close()
+
+	client, err := scim.NewClient(getSecretRef("mock", "", "", ""), getLogger())
+	assert.NoError(t, err)
+
+	run := func(action, id, payload string) (string, error) {
+		var out bytes.Buffer
+
+		err := runWriteAction(t.Context(), client, &out, server.URL, action, id, []byte(payload))
+
+		return out.String(), err
+	}
+
+	out, err := run("CreateUser", "", `{"userName":"carol"}`)
+	assert.NoError(t, err)
+
+	var user scim.User
+
+	assert.NoError(t, json.Unmarshal([]byte(out), &user))
+	assert.Equal(t, "carol", user.UserName)
+	assert.NotEmpty(t, user.ID)
+
+	out, err = run("UpdateUser", user.ID, `{"userName":"carol","displayName":"Carol"}`)
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"displayName": "Carol"`)
+
+	out, err = run("CreateGroup", "", `{"displayName":"Auditor"}`)
+	assert.NoError(t, err)
+	assert.Contains(t, out, `"displayName": "Auditor"`)
+
+	_, err = run("UpdateGroup", "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa", `{"displayName":"KeyAdmins"}`)
+	assert.NoError(t, err)
+
+	out, err = run("DeleteUser", user.ID, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "Deleted "+user.ID+"\n", out)
+
+	_, err = run("DeleteGroup", "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa", "")
+	assert.NoError(t, err)
+
+	_, err = run("DeleteGroup", "16e720aa-a009-4949-9bf9-aaaaaaaaaaaa", "")
+	assert.ErrorIs(t, err, scim.ErrDeleteGroup)
+
+	_, err = run("CreateUser", "", "")
+	assert.ErrorIs(t, err, errNoPayload)
+
+	_, err = run("UpdateUser", "", `{"userName":"carol"}`)
+	assert.ErrorIs(t, err, errNoID)
+}